@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"fcstask-backend/internal/config"
+)
+
+// BuildInfo - ответ GET /debug/buildinfo
+type BuildInfo struct {
+	Path      string            `json:"path"`
+	Version   string            `json:"version"`
+	GoVersion string            `json:"goVersion"`
+	Settings  map[string]string `json:"settings"` // vcs.revision, vcs.time, ...
+}
+
+// RuntimeStats - ответ GET /debug/runtime
+type RuntimeStats struct {
+	Uptime       string `json:"uptime"`
+	Goroutines   int    `json:"goroutines"`
+	GOMAXPROCS   int    `json:"gomaxprocs"`
+	NumCPU       int    `json:"numCpu"`
+	HeapAlloc    uint64 `json:"heapAlloc"`
+	HeapInuse    uint64 `json:"heapInuse"`
+	HeapObjects  uint64 `json:"heapObjects"`
+	Sys          uint64 `json:"sys"`
+	NumGC        uint32 `json:"numGc"`
+	PauseTotalNs uint64 `json:"pauseTotalNs"`
+	LastGC       string `json:"lastGc,omitempty"`
+}
+
+// NewHandler собирает диагностические эндпоинты админского порта.
+// Наружу их не публикуем: порт по умолчанию слушает только localhost
+func NewHandler(cfg *config.Config, started time.Time) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	mux.HandleFunc("GET /debug/buildinfo", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, buildInfo())
+	})
+
+	mux.HandleFunc("GET /debug/runtime", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, runtimeStats(started))
+	})
+
+	mux.HandleFunc("GET /debug/config", func(w http.ResponseWriter, _ *http.Request) {
+		data, err := yaml.Marshal(cfg.Redacted())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(data)
+	})
+
+	return mux
+}
+
+func buildInfo() BuildInfo {
+	info := BuildInfo{GoVersion: runtime.Version(), Settings: map[string]string{}}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.Path = bi.Main.Path
+	info.Version = bi.Main.Version
+	for _, setting := range bi.Settings {
+		info.Settings[setting.Key] = setting.Value
+	}
+
+	return info
+}
+
+func runtimeStats(started time.Time) RuntimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := RuntimeStats{
+		Uptime:       time.Since(started).Round(time.Second).String(),
+		Goroutines:   runtime.NumGoroutine(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		NumCPU:       runtime.NumCPU(),
+		HeapAlloc:    mem.HeapAlloc,
+		HeapInuse:    mem.HeapInuse,
+		HeapObjects:  mem.HeapObjects,
+		Sys:          mem.Sys,
+		NumGC:        mem.NumGC,
+		PauseTotalNs: mem.PauseTotalNs,
+	}
+	if mem.LastGC > 0 {
+		stats.LastGC = time.Unix(0, int64(mem.LastGC)).UTC().Format(time.RFC3339)
+	}
+
+	return stats
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
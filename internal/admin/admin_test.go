@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fcstask-backend/internal/config"
+)
+
+func get(t *testing.T, h http.Handler, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: expected 200, got %d", path, rec.Code)
+	}
+	return rec
+}
+
+func TestConfigIsRedacted(t *testing.T) {
+	cfg := config.Default()
+	cfg.Provision.GitlabToken = "glpat-secret"
+	cfg.Mail.SMTPPassword = "smtp-secret"
+	cfg.Mail.VerificationSecret = "hmac-secret"
+
+	body := get(t, NewHandler(cfg, time.Now()), "/debug/config").Body.String()
+
+	for _, secret := range []string{"glpat-secret", "smtp-secret", "hmac-secret"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("config leaks %q:\n%s", secret, body)
+		}
+	}
+	if !strings.Contains(body, "gitlab_token: '[redacted]'") || !strings.Contains(body, "shutdown_timeout: 5s") {
+		t.Errorf("unexpected config dump:\n%s", body)
+	}
+	if cfg.Provision.GitlabToken != "glpat-secret" {
+		t.Error("redaction must not modify the running config")
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	h := NewHandler(config.Default(), time.Now().Add(-time.Minute))
+
+	var stats RuntimeStats
+	json.Unmarshal(get(t, h, "/debug/runtime").Body.Bytes(), &stats)
+	if stats.Goroutines == 0 || stats.Uptime != "1m0s" {
+		t.Errorf("unexpected runtime stats: %+v", stats)
+	}
+
+	var info BuildInfo
+	json.Unmarshal(get(t, h, "/debug/buildinfo").Body.Bytes(), &info)
+	if info.GoVersion == "" {
+		t.Errorf("unexpected build info: %+v", info)
+	}
+
+	if !strings.Contains(get(t, h, "/debug/pprof/").Body.String(), "goroutine") {
+		t.Error("pprof index is not served")
+	}
+}
@@ -12,10 +12,51 @@ import (
 )
 
 func RegisterHandlers(e *echo.Echo, apiServer *server.Server) {
+	// Ручка из api/openapi.yaml
+	e.POST("/v1/echo", apiServer.PostV1Echo)
+
 	e.GET("/api/courses", handler.GetCoursesHandler)
-	e.GET("/api/coursses/:courseId", handler.GetCourseHandler)
 	e.POST("/api/courses", handler.CreateCourseHandler)
-	e.PUT("/api/courses/:courseId", handler.UpdateCourseHandler)
 
-	e.GET("/api/courses/:courseId/board", handler.GetCourseBoardHandler)
+	// Запросы по старому slug'у переименованного курса перенаправляются на текущий
+	alias := handler.ResolveCourseAlias
+
+	e.GET("/api/courses/:courseId", handler.GetCourseHandler, alias)
+	e.PUT("/api/courses/:courseId", handler.UpdateCourseHandler, alias)
+	e.PATCH("/api/courses/:courseId", handler.PatchCourseHandler, alias)
+	e.DELETE("/api/courses/:courseId", handler.DeleteCourseHandler, alias)
+	e.POST("/api/courses/:courseId/restore", handler.RestoreCourseHandler, alias)
+	e.POST("/api/courses/:courseId/rename", handler.RenameCourseHandler, alias)
+	e.POST("/api/courses/:courseId/clone", handler.CloneCourseHandler, alias)
+	e.POST("/api/courses/:courseId/move", handler.MoveCourseHandler, alias)
+
+	e.GET("/api/courses/:courseId/board", handler.GetCourseBoardHandler, alias)
+	e.GET("/api/courses/:courseId/structure", handler.GetCourseStructureHandler, alias)
+	e.PUT("/api/courses/:courseId/structure", handler.PutCourseStructureHandler, alias)
+	e.GET("/api/courses/:courseId/scores", handler.GetCourseScoresHandler, alias)
+
+	e.GET("/api/courses/:courseId/enrollments", handler.GetEnrollmentsHandler, alias)
+	e.POST("/api/courses/:courseId/enrollments", handler.CreateEnrollmentHandler, alias)
+	e.PUT("/api/courses/:courseId/enrollments/:userId", handler.UpdateEnrollmentHandler, alias)
+	e.GET("/api/me/courses", handler.GetMyCoursesHandler)
+
+	e.GET("/api/namespaces", handler.GetNamespacesHandler)
+	e.POST("/api/namespaces", handler.CreateNamespaceHandler)
+	e.GET("/api/namespaces/:namespaceId", handler.GetNamespaceHandler)
+	e.POST("/api/namespaces/:namespaceId/users", handler.AddNamespaceUserHandler)
+	e.PUT("/api/namespaces/:namespaceId/users/:userId", handler.UpdateNamespaceUserHandler)
+
+	e.GET("/api/trash/courses", handler.GetTrashHandler)
+	e.GET("/api/audit", handler.GetAuditHandler)
+	e.GET("/api/instance/summary", handler.GetInstanceSummaryHandler)
+	e.POST("/api/demo/reset", handler.DemoResetHandler)
+
+	e.POST("/api/invites", handler.CreateInviteHandler)
+	e.GET("/api/invites", handler.GetInvitesHandler)
+	e.DELETE("/api/invites/:code", handler.RevokeInviteHandler)
+
+	e.POST("/api/signup", handler.SignupHandler)
+	e.GET("/api/signup/status", handler.GetSignupStatusHandler)
+	e.GET("/api/signup/verify", handler.VerifyEmailHandler)
+	e.POST("/api/users/:userId/verification", handler.ResendVerificationHandler)
 }
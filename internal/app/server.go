@@ -2,59 +2,280 @@ package app
 
 import (
 	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 
+	"fcstask-backend/internal/admin"
 	"fcstask-backend/internal/api"
+	"fcstask-backend/internal/config"
+	"fcstask-backend/internal/database"
+	"fcstask-backend/internal/fixtures"
+	"fcstask-backend/internal/health"
+	"fcstask-backend/internal/logging"
+	"fcstask-backend/internal/mailer"
+	"fcstask-backend/internal/metrics"
+	"fcstask-backend/internal/migrate"
+	"fcstask-backend/internal/provision"
 	"fcstask-backend/internal/server"
+	"fcstask-backend/internal/server/handler"
 )
 
 type App struct {
-	echo            *echo.Echo
+	logger          *slog.Logger
+	server          *server.Server
+	admin           *server.Server // nil, если админский порт выключен
+	probe           *health.Probe
 	shutdownTimeout time.Duration
+	drainDelay      time.Duration
 }
 
-func New(
-	host string,
-	port int,
-	shutdownTimeout time.Duration,
-) *App {
+func New(cfg *config.Config, logger *slog.Logger) (*App, error) {
+	// Невалидный конфиг ломает сервер не сразу, а в фоновых задачах
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	e := echo.New()
-	apiServer := &server.Server{}
+	e.HideBanner = true
 
-	api.RegisterHandlers(e, apiServer)
+	m := metrics.New()
+	m.CoursesByStatus(handler.CourseStatusCounts)
+	e.Use(otelecho.Middleware(cfg.Tracing.ServiceName, otelecho.WithSkipper(func(c echo.Context) bool {
+		return c.Path() == "/metrics" || c.Path() == "/healthz" || c.Path() == "/readyz"
+	})))
+	e.Use(logging.Middleware(logger, func() string { return uuid.NewString() }))
+	e.Use(m.Middleware())
+	e.GET("/metrics", echo.WrapHandler(m.Handler()))
 
-	addr := fmt.Sprintf("%s:%d", host, port)
+	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
+	srv := server.NewServer(addr, e, server.Options{
+		ReadTimeout:    cfg.Server.ReadTimeout,
+		WriteTimeout:   cfg.Server.WriteTimeout,
+		IdleTimeout:    cfg.Server.IdleTimeout,
+		MaxHeaderBytes: cfg.Server.MaxHeaderBytes,
+	})
 
-	e.HideBanner = true
-	e.Server.Addr = addr
+	api.RegisterHandlers(e, srv)
+
+	// В демо-режиме база не нужна: данные из фикстур живут в памяти
+	var db *sql.DB
+	if cfg.Demo.Enabled {
+		f, err := fixtures.Load(cfg.Demo.Fixtures)
+		if err != nil {
+			return nil, fmt.Errorf("load fixtures: %w", err)
+		}
+		if err := handler.ApplyFixtures(f); err != nil {
+			return nil, fmt.Errorf("apply fixtures: %w", err)
+		}
+		handler.SetDemoFixtures(f)
+		logger.Warn("demo mode: data is kept in memory and reset on restart", "fixtures", cfg.Demo.Fixtures)
+	} else {
+		var err error
+		if db, err = openStorage(cfg.Database, logger); err != nil {
+			return nil, err
+		}
+		e.Use(handler.SyncState(db))
+
+		// База регистрируется первой, значит закрывается последней
+		srv.AddWorker("database", func(ctx context.Context) {
+			<-ctx.Done()
+			_ = db.Close()
+		})
+	}
+
+	handler.SetTrashRetention(cfg.Trash.Retention)
+	srv.AddWorker("trash", func(ctx context.Context) {
+		handler.RunTrashPurge(ctx, db, cfg.Trash.PurgeInterval, logger, func(err error) {
+			m.SchedulerRun("trash_purge", err)
+		})
+	})
+
+	vcs, err := newVCS(cfg.Provision)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := provision.NewQueue(vcs, cfg.Provision.QueueSize, cfg.Provision.MaxAttempts, cfg.Provision.RetryDelay)
+	queue.OnFailed(func(job provision.Job, err error) {
+		m.ProvisioningFailures.Inc()
+		logger.Error("repository provisioning failed", "group", job.Group, "name", job.Name, "error", err)
+	})
+	handler.SetProvisioner(queue)
+	srv.AddWorker("provisioning", queue.Run)
+
+	mail, err := newMailer(cfg.Mail)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := []byte(cfg.Mail.VerificationSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+
+	handler.SetEmailVerification(&handler.EmailVerification{
+		Mailer:  mail,
+		Secret:  secret,
+		LinkURL: strings.TrimRight(cfg.Mail.PublicURL, "/") + "/api/signup/verify",
+		TTL:     cfg.Mail.VerificationTTL,
+	})
+
+	checks := health.NewRegistry(cfg.Server.HealthCheckTimeout)
+	if db != nil {
+		checks.Register("database", health.CheckerFunc(db.PingContext))
+	}
+	checks.Register("queue", queue)
+	if checker, ok := vcs.(health.Checker); ok {
+		checks.Register("vcs", checker)
+	}
+	if checker, ok := mail.(health.Checker); ok {
+		checks.Register("mailer", checker)
+	}
+	handler.SetHealthChecker(checks)
+
+	// Готовность: без базы и обработчика очереди запросы не обслужить до конца
+	readiness := health.NewRegistry(cfg.Server.HealthCheckTimeout)
+	if db != nil {
+		readiness.Register("database", health.CheckerFunc(db.PingContext))
+	}
+	readiness.Register("queue", queue)
+
+	probe := health.NewProbe(readiness)
+	e.GET("/healthz", echo.WrapHandler(http.HandlerFunc(probe.Live)))
+	e.GET("/readyz", echo.WrapHandler(http.HandlerFunc(probe.Ready)))
+
+	var adminServer *server.Server
+	if cfg.Server.Admin.Enabled {
+		adminAddr := fmt.Sprintf("%s:%d", cfg.Server.Admin.Host, cfg.Server.Admin.Port)
+		adminServer = server.NewServer(adminAddr, admin.NewHandler(cfg, time.Now()), server.Options{
+			ReadTimeout: cfg.Server.ReadTimeout,
+			IdleTimeout: cfg.Server.IdleTimeout,
+		})
+	}
 
 	return &App{
-		echo:            e,
-		shutdownTimeout: shutdownTimeout,
+		logger:          logger,
+		server:          srv,
+		admin:           adminServer,
+		probe:           probe,
+		shutdownTimeout: cfg.Server.ShutdownTimeout,
+		drainDelay:      cfg.Server.DrainDelay,
+	}, nil
+}
+
+// openStorage открывает базу, применяет миграции и загружает сохраненные данные
+func openStorage(cfg config.DatabaseConfig, logger *slog.Logger) (*sql.DB, error) {
+	db, err := database.Open(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+
+	if cfg.AutoMigrate {
+		migrator, err := migrate.New(db, migrate.Embedded())
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("migrate database: %w", err)
+		}
+		for _, migration := range applied {
+			logger.Info("migration applied", "version", migration.Version, "name", migration.Name)
+		}
+	}
+
+	loaded, err := handler.LoadState(context.Background(), db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("load state: %w", err)
+	}
+	if !loaded {
+		logger.Warn("database is empty, run \"fcstask seed\" to load demo data")
+	}
+
+	return db, nil
+}
+
+func newVCS(cfg config.ProvisionConfig) (provision.VCS, error) {
+	switch cfg.Provider {
+	case "fake":
+		return &provision.Fake{BaseURL: cfg.GitlabURL}, nil
+	case "gitlab":
+		return provision.NewGitLab(cfg.GitlabURL, cfg.GitlabToken, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown provision provider %q", cfg.Provider)
+	}
+}
+
+func newMailer(cfg config.MailConfig) (mailer.Mailer, error) {
+	switch cfg.Driver {
+	case "outbox":
+		return mailer.NewOutbox(cfg.OutboxDir, cfg.From)
+	case "smtp":
+		return mailer.NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
 	}
 }
 
 func (a *App) Run(ctx context.Context) error {
-	errCh := make(chan error, 1)
+	errCh := make(chan error, 2)
+
+	a.logger.Info("http server started", "addr", a.server.Addr())
 
 	go func() {
-		errCh <- a.echo.Start(a.echo.Server.Addr)
+		errCh <- a.server.Start(ctx)
 	}()
 
+	if a.admin != nil {
+		a.logger.Info("admin listener started", "addr", a.admin.Addr())
+		go func() {
+			if err := a.admin.Start(ctx); err != nil {
+				errCh <- fmt.Errorf("admin listener: %w", err)
+			}
+		}()
+	}
+
 	select {
 	case err := <-errCh:
 		return err
 
 	case <-ctx.Done():
+		a.logger.Info("shutting down", "drain_delay", a.drainDelay.String(), "timeout", a.shutdownTimeout.String())
+
+		// Сначала перестаем быть готовыми и даем балансировщику время это заметить
+		a.probe.Drain()
+		select {
+		case <-time.After(a.drainDelay):
+		case err := <-errCh:
+			return err
+		}
+
 		shutdownCtx, cancel := context.WithTimeout(
 			context.Background(),
 			a.shutdownTimeout,
 		)
 		defer cancel()
 
-		return a.echo.Shutdown(shutdownCtx)
+		err := a.server.Shutdown(shutdownCtx)
+
+		// Админский порт закрываем последним, чтобы профилировать и саму остановку
+		if a.admin != nil {
+			err = errors.Join(err, a.admin.Shutdown(shutdownCtx))
+		}
+		return err
 	}
 }
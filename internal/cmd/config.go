@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"fcstask-backend/internal/config"
+)
+
+// runConfig - fcstask config validate: конфиг читается и проверяется целиком
+func runConfig(path string, args []string, out io.Writer) error {
+	if len(args) != 1 || args[0] != "validate" {
+		return fmt.Errorf("%w: expected config validate", errUsage)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("%s is invalid:\n  %s", path, strings.ReplaceAll(err.Error(), "\n", "\n  "))
+	}
+
+	fmt.Fprintf(out, "%s is valid\n", path)
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"fcstask-backend/internal/server/handler"
+)
+
+const courseUsage = `usage: fcstask course <command>
+
+commands:
+  list [-status s,...] [-q text] [-sort f] ...  list courses
+  create -slug s -name n -namespace id ...      create a course
+  set-status <course> <status>                  change the course status
+  rename <course> <new-slug>                    change the slug, the old one keeps redirecting
+  clone -start date <course> <new-slug>         copy the course into a new semester`
+
+// runCourse - fcstask course list|create|set-status|rename|clone
+func runCourse(ctx context.Context, env *env, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: course command is required\n%s", errUsage, courseUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		return courseList(ctx, env, args[1:])
+	case "create":
+		return courseCreate(ctx, env, args[1:])
+	case "set-status":
+		return courseSetStatus(ctx, env, args[1:])
+	case "rename":
+		return courseRename(ctx, env, args[1:])
+	case "clone":
+		return courseClone(ctx, env, args[1:])
+	default:
+		return fmt.Errorf("%w: unknown course command %q\n%s", errUsage, args[0], courseUsage)
+	}
+}
+
+func printCourses(out output, courses []handler.Course) error {
+	return out.print(courses, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tNAME\tSTATUS\tNAMESPACE\tSTART\tEND\tOWNERS")
+		for _, c := range courses {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				c.ID, c.Name, c.Status, c.NamespaceID, c.StartDate, c.EndDate, strings.Join(c.Owners, ","))
+		}
+	})
+}
+
+func courseList(ctx context.Context, env *env, args []string) error {
+	var q handler.CourseQuery
+
+	fs := flag.NewFlagSet("course list", flag.ContinueOnError)
+	status := fs.String("status", "", "only courses in these statuses, comma-separated")
+	fs.StringVar(&q.NamespaceID, "namespace", "", "only courses of this namespace")
+	fs.StringVar(&q.Search, "q", "", "search in names and descriptions, case-insensitive")
+	fs.StringVar(&q.Sort, "sort", "", "sort by name, startDate or status; prefix with - to reverse")
+	if err := env.parseFlags(fs, args, 0, ""); err != nil {
+		return err
+	}
+
+	if *status != "" {
+		q.Statuses = strings.Split(*status, ",")
+	}
+
+	var courses []handler.Course
+	err := env.read(ctx, func(actor handler.User) error {
+		q.Viewer = &actor
+		page, err := handler.QueryCourses(q)
+		courses = page.Courses
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return printCourses(env.out, courses)
+}
+
+func courseCreate(ctx context.Context, env *env, args []string) error {
+	var req handler.PostCourseRequest
+
+	fs := flag.NewFlagSet("course create", flag.ContinueOnError)
+	fs.StringVar(&req.Slug, "slug", "", "course slug, also its ID and GitLab group")
+	fs.StringVar(&req.Name, "name", "", "course name")
+	fs.StringVar(&req.NamespaceID, "namespace", "", "namespace ID")
+	fs.StringVar(&req.Status, "status", "created", "initial status")
+	fs.StringVar(&req.StartDate, "start", "", "start date, YYYY-MM-DD")
+	fs.StringVar(&req.EndDate, "end", "", "end date, YYYY-MM-DD")
+	fs.StringVar(&req.Timezone, "timezone", "UTC", "IANA time zone of the course dates and deadlines")
+	fs.StringVar(&req.RepoTemplate, "repo-template", "", "template repository for student repositories")
+	fs.StringVar(&req.Description, "description", "", "course description")
+	owners := fs.String("owners", "", "comma-separated usernames of course owners")
+	if err := env.parseFlags(fs, args, 0, ""); err != nil {
+		return err
+	}
+
+	if *owners != "" {
+		req.Owners = strings.Split(*owners, ",")
+	}
+	// Оператор CLI - не пользователь инстанса и не может быть владельцем по умолчанию
+	if len(req.Owners) == 0 && env.as == "" {
+		return fmt.Errorf("%w: -owners is required unless -as is set", errUsage)
+	}
+
+	var course handler.Course
+	err := env.write(ctx, func(actor handler.User) error {
+		var err error
+		course, err = handler.CreateCourse(actor, req)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return printCourses(env.out, []handler.Course{course})
+}
+
+func courseSetStatus(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("course set-status", flag.ContinueOnError)
+	if err := env.parseFlags(fs, args, 2, "<course> <status>"); err != nil {
+		return err
+	}
+
+	var course handler.Course
+	err := env.write(ctx, func(actor handler.User) error {
+		var err error
+		course, err = handler.SetCourseStatus(actor, fs.Arg(0), 0, fs.Arg(1))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return printCourses(env.out, []handler.Course{course})
+}
+
+func courseRename(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("course rename", flag.ContinueOnError)
+	if err := env.parseFlags(fs, args, 2, "<course> <new-slug>"); err != nil {
+		return err
+	}
+
+	var course handler.Course
+	err := env.write(ctx, func(actor handler.User) error {
+		var err error
+		course, err = handler.RenameCourse(actor, fs.Arg(0), 0, fs.Arg(1))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return printCourses(env.out, []handler.Course{course})
+}
+
+func courseClone(ctx context.Context, env *env, args []string) error {
+	var req handler.CloneCourseRequest
+
+	fs := flag.NewFlagSet("course clone", flag.ContinueOnError)
+	fs.StringVar(&req.StartDate, "start", "", "start date of the new course, YYYY-MM-DD")
+	if err := env.parseFlags(fs, args, 2, "-start date <course> <new-slug>"); err != nil {
+		return err
+	}
+	req.Slug = fs.Arg(1)
+
+	var course handler.Course
+	err := env.write(ctx, func(actor handler.User) error {
+		var err error
+		course, err = handler.CloneCourse(actor, fs.Arg(0), req)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return printCourses(env.out, []handler.Course{course})
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os/user"
+	"text/tabwriter"
+
+	"fcstask-backend/internal/config"
+	"fcstask-backend/internal/database"
+	"fcstask-backend/internal/migrate"
+	"fcstask-backend/internal/server/handler"
+)
+
+// Сколько раз повторять изменение, если базу одновременно записал сервер
+const writeAttempts = 3
+
+// env - окружение команд, работающих с данными: база, от чьего имени и куда печатать
+type env struct {
+	cfg    *config.Config
+	as     string
+	out    output
+	stderr io.Writer
+}
+
+// output печатает результат: таблицей для людей или JSON для скриптов
+type output struct {
+	w    io.Writer
+	json bool
+}
+
+func (o output) print(v any, table func(w io.Writer)) error {
+	if o.json {
+		enc := json.NewEncoder(o.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', 0)
+	table(w)
+	return w.Flush()
+}
+
+// operator - пользователь CLI по умолчанию: права инстанс-админа,
+// в аудит попадает как cli:<логин в системе>
+func operator() handler.User {
+	name := "cli"
+	if current, err := user.Current(); err == nil {
+		name += ":" + current.Username
+	}
+	return handler.User{Username: name, Role: handler.RoleInstanceAdmin, EmailVerified: true}
+}
+
+// parseFlags разбирает флаги подкоманды и проверяет число позиционных аргументов
+func (e *env) parseFlags(fs *flag.FlagSet, args []string, nargs int, positional string) error {
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: fcstask %s [flags] %s\n", fs.Name(), positional)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	if fs.NArg() != nargs {
+		fs.Usage()
+		return fmt.Errorf("%w: %s expects %d arguments, got %d", errUsage, fs.Name(), nargs, fs.NArg())
+	}
+	return nil
+}
+
+func (e *env) actor() (handler.User, error) {
+	if e.as == "" {
+		return operator(), nil
+	}
+	return handler.LookupUser(e.as)
+}
+
+func (e *env) open(ctx context.Context) (*sql.DB, error) {
+	db, err := database.Open(e.cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.cfg.Database.AutoMigrate {
+		migrator, err := migrate.New(db, migrate.Embedded())
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		if _, err := migrator.Up(ctx); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("migrate database: %w", err)
+		}
+	}
+
+	return db, nil
+}
+
+// read загружает данные из базы и выполняет fn от имени actor
+func (e *env) read(ctx context.Context, fn func(actor handler.User) error) error {
+	db, err := e.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := handler.LoadState(ctx, db); err != nil {
+		return err
+	}
+
+	actor, err := e.actor()
+	if err != nil {
+		return err
+	}
+	return fn(actor)
+}
+
+// write как read, но сохраняет изменения. Если базу тем временем записал
+// сервер, изменение повторяется на свежих данных
+func (e *env) write(ctx context.Context, fn func(actor handler.User) error) error {
+	db, err := e.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for attempt := 1; ; attempt++ {
+		if _, err := handler.LoadState(ctx, db); err != nil {
+			return err
+		}
+
+		actor, err := e.actor()
+		if err != nil {
+			return err
+		}
+		if err := fn(actor); err != nil {
+			return err
+		}
+
+		err = handler.SaveState(ctx, db)
+		if !errors.Is(err, handler.ErrStateConflict) || attempt == writeAttempts {
+			return err
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"fcstask-backend/internal/server/handler"
+)
+
+const inviteUsage = `usage: fcstask invite <command>
+
+commands:
+  create (-course id | -namespace id) [-role r] [-expires d] [-max-uses n]   issue an invite code`
+
+// runInvite - fcstask invite create
+func runInvite(ctx context.Context, env *env, args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		return fmt.Errorf("%w: expected invite create\n%s", errUsage, inviteUsage)
+	}
+
+	var req handler.PostInviteRequest
+
+	fs := flag.NewFlagSet("invite create", flag.ContinueOnError)
+	fs.StringVar(&req.CourseID, "course", "", "invite to this course (student role only)")
+	fs.StringVar(&req.NamespaceID, "namespace", "", "invite to this namespace")
+	fs.StringVar(&req.Role, "role", handler.RoleStudent, "role granted by the invite")
+	fs.IntVar(&req.MaxUses, "max-uses", 1, "how many times the invite can be used")
+	expires := fs.Duration("expires", 7*24*time.Hour, "how long the invite stays valid")
+	if err := env.parseFlags(fs, args[1:], 0, ""); err != nil {
+		return err
+	}
+	req.ExpiresAt = time.Now().Add(*expires).UTC().Format(time.RFC3339)
+
+	var invite handler.Invite
+	err := env.write(ctx, func(actor handler.User) error {
+		var err error
+		invite, err = handler.CreateInvite(actor, req)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return env.out.print(invite, func(w io.Writer) {
+		fmt.Fprintln(w, "CODE\tCOURSE\tNAMESPACE\tROLE\tEXPIRES AT\tMAX USES")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n",
+			invite.Code, invite.CourseID, invite.NamespaceID, invite.Role, invite.ExpiresAt.Format(time.RFC3339), invite.MaxUses)
+	})
+}
@@ -2,37 +2,106 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"os"
-	"os/signal"
-	"syscall"
 
-	"fcstask-backend/internal/app"
 	"fcstask-backend/internal/config"
 )
 
+const usage = `usage: fcstask [-config path] [-json] [-as username] <command> [arguments]
+
+commands:
+  serve [-demo] [-fixtures path]     run the HTTP server (default)
+  migrate up|down|status|create      manage the database schema
+  seed [-file path] [-force]         load fixtures (built-in demo data) into the database
+  course list|create|...             list, create, rename, clone courses and change their status
+  user add|set-role                  manage users and their roles
+  invite create                      issue an invite code
+  scores export <course>             print the course gradebook (CSV, JSON with -json)
+  config validate                    check the config file
+
+Commands that change data work on the database shared with the server; a running
+server picks the changes up on its next request. Run "fcstask <command> -h" for flags.
+
+global flags:`
+
+// errUsage - неверный вызов команды, код выхода 2
+var errUsage = errors.New("usage")
+
 func main() {
-	cfg, err := config.Load("config/config.yaml")
+	os.Exit(runCLI(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// runCLI разбирает глобальные флаги и запускает команду, возвращает код выхода
+func runCLI(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("fcstask", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", "config/config.yaml", "path to the config file")
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	as := fs.String("as", "", "act as this user, with their permissions (default: operator with instance admin rights)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, usage)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	command, rest := "serve", []string{}
+	if fs.NArg() > 0 {
+		command, rest = fs.Arg(0), fs.Args()[1:]
+	}
+
+	if command == "config" {
+		return exitCode(runConfig(*configPath, rest, stdout), stderr)
+	}
+
+	cfg, err := config.Load(*configPath)
 	if err != nil {
-		log.Fatal(err)
-	}
-
-	ctx, stop := signal.NotifyContext(
-		context.Background(),
-		os.Interrupt,
-		syscall.SIGTERM,
-	)
-	defer stop()
-	
-	
-	app := app.New(
-		cfg.Server.Host,
-		cfg.Server.Port,
-		cfg.Server.ShutdownTimeout,
-	)
-	
-	
-	if err := app.Run(ctx); err != nil {
-		log.Fatal(err)
+		return exitCode(err, stderr)
+	}
+
+	out := output{w: stdout, json: *asJSON}
+	env := &env{cfg: cfg, as: *as, out: out, stderr: stderr}
+
+	switch command {
+	case "serve":
+		err = runServe(cfg, rest, stderr)
+	case "migrate":
+		err = runMigrate(ctx, cfg, rest, stdout)
+	case "seed":
+		err = runSeed(ctx, env, rest)
+	case "course":
+		err = runCourse(ctx, env, rest)
+	case "user":
+		err = runUser(ctx, env, rest)
+	case "invite":
+		err = runInvite(ctx, env, rest)
+	case "scores":
+		err = runScores(ctx, env, rest)
+	default:
+		fs.Usage()
+		err = fmt.Errorf("%w: unknown command %q", errUsage, command)
+	}
+
+	return exitCode(err, stderr)
+}
+
+func exitCode(err error, stderr io.Writer) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+
+	fmt.Fprintln(stderr, "fcstask:", err)
+	if errors.Is(err, errUsage) {
+		return 2
 	}
+	return 1
 }
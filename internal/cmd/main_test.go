@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig - конфиг с базой в каталоге теста
+func writeConfig(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := "database:\n" +
+		"  dsn: \"file:" + filepath.Join(dir, "fcstask.db") + "?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)\"\n" +
+		"  auto_migrate: true\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// cli запускает команду и возвращает stdout, stderr и код выхода
+func cli(t *testing.T, args ...string) (string, string, int) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI(context.Background(), args, &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestCLI_DataCommands(t *testing.T) {
+	config := writeConfig(t)
+
+	if _, errOut, code := cli(t, "-config", config, "seed"); code != 0 {
+		t.Fatalf("seed failed (%d): %s", code, errOut)
+	}
+
+	out, errOut, code := cli(t, "-config", config, "-json", "user", "add", "-email", "bob@example.com", "bob")
+	if code != 0 {
+		t.Fatalf("user add failed (%d): %s", code, errOut)
+	}
+	var created struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+		Token    string `json:"token"`
+	}
+	if err := json.Unmarshal([]byte(out), &created); err != nil || created.Username != "bob" || created.Token == "" {
+		t.Fatalf("unexpected user add output: %s", out)
+	}
+
+	// изменения каждой команды видны следующей: данные живут в базе
+	if _, errOut, code := cli(t, "-config", config, "user", "set-role", "-namespace", "ns-01", "bob", "namespace_admin"); code != 0 {
+		t.Fatalf("user set-role failed (%d): %s", code, errOut)
+	}
+
+	_, errOut, code = cli(t, "-config", config, "-as", "bob", "course", "create",
+		"-slug", "os", "-name", "Operating Systems", "-namespace", "ns-01",
+		"-start", "2025-02-01", "-end", "2025-05-30", "-timezone", "Europe/Moscow",
+		"-repo-template", "git@gitlab.local:os.git", "-description", "Операционные системы")
+	if code != 0 {
+		t.Fatalf("course create failed (%d): %s", code, errOut)
+	}
+
+	if _, errOut, code := cli(t, "-config", config, "course", "set-status", "os", "in_progress"); code != 0 {
+		t.Fatalf("course set-status failed (%d): %s", code, errOut)
+	}
+
+	out, errOut, code = cli(t, "-config", config, "-json", "course", "list", "-status", "in_progress", "-namespace", "ns-01")
+	if code != 0 {
+		t.Fatalf("course list failed (%d): %s", code, errOut)
+	}
+	var courses []struct {
+		ID          string   `json:"id"`
+		Owners      []string `json:"owners"`
+		EndsAtLocal string   `json:"endsAtLocal"`
+	}
+	json.Unmarshal([]byte(out), &courses)
+	found := false
+	for _, course := range courses {
+		if course.ID == "os" {
+			found = len(course.Owners) == 1 && course.Owners[0] == "bob" && course.EndsAtLocal == "2025-05-30T23:59:59+03:00"
+		}
+	}
+	if !found {
+		t.Fatalf("created course missing or with wrong owner or timezone: %s", out)
+	}
+
+	out, errOut, code = cli(t, "-config", config, "invite", "create", "-course", "os", "-max-uses", "30")
+	if code != 0 || !strings.Contains(out, "CODE") || !strings.Contains(out, "os") {
+		t.Fatalf("invite create failed (%d): %s%s", code, out, errOut)
+	}
+
+	// возврат к прежнему slug'у разрешен: псевдоним ведет на этот же курс
+	out, errOut, code = cli(t, "-config", config, "course", "rename", "os", "operating-systems")
+	if code != 0 || !strings.Contains(out, "operating-systems") {
+		t.Fatalf("course rename failed (%d): %s%s", code, out, errOut)
+	}
+	if _, errOut, code := cli(t, "-config", config, "course", "rename", "operating-systems", "os"); code != 0 {
+		t.Fatalf("renaming back to the old slug failed (%d): %s", code, errOut)
+	}
+
+	out, errOut, code = cli(t, "-config", config, "course", "clone", "-start", "2026-02-02", "os", "os-2026")
+	if code != 0 || !strings.Contains(out, "os-2026") || !strings.Contains(out, "2026-02-02") || !strings.Contains(out, "created") {
+		t.Fatalf("course clone failed (%d): %s%s", code, out, errOut)
+	}
+
+	out, errOut, code = cli(t, "-config", config, "scores", "export", "os")
+	if code != 0 || out != "id,student,score,submitted\n" {
+		t.Fatalf("unexpected scores export (%d): %q %s", code, out, errOut)
+	}
+
+	// ошибки сервисного слоя - код 1, ошибки вызова - код 2
+	if _, errOut, code := cli(t, "-config", config, "course", "set-status", "os", "bogus"); code != 1 || !strings.Contains(errOut, "invalid status value") {
+		t.Fatalf("expected validation error, got %d: %s", code, errOut)
+	}
+	if _, _, code := cli(t, "-config", config, "-as", "nobody", "course", "list"); code != 1 {
+		t.Fatalf("expected error for unknown -as user, got %d", code)
+	}
+	if _, _, code := cli(t, "-config", config, "course", "set-status", "os"); code != 2 {
+		t.Fatalf("expected usage error, got %d", code)
+	}
+	// CLI проверяет курс по тем же правилам, что и API, и сообщает обо всех ошибках сразу
+	_, errOut, code = cli(t, "-config", config, "course", "create", "-owners", "alex", "-slug", "OS", "-name", "OS",
+		"-namespace", "ns-01", "-start", "2025-02-01", "-end", "2025-01-01", "-repo-template", "git@gitlab.local/os.git")
+	if code != 1 || !strings.Contains(errOut, "slug:") || !strings.Contains(errOut, "dateRange:") || !strings.Contains(errOut, "repoTemplate:") {
+		t.Fatalf("expected all validation errors, got %d: %s", code, errOut)
+	}
+	if _, _, code := cli(t, "-config", config, "course", "create", "-slug", "x"); code != 2 {
+		t.Fatalf("operator must name the owners, got %d", code)
+	}
+	if _, _, code := cli(t, "-config", config, "frobnicate"); code != 2 {
+		t.Fatalf("expected usage error for unknown command, got %d", code)
+	}
+}
+
+func TestCLI_Seed(t *testing.T) {
+	config := writeConfig(t)
+
+	out, errOut, code := cli(t, "-config", config, "seed")
+	if code != 0 || !strings.Contains(out, "seeded 3 users, 2 namespaces and 6 courses") {
+		t.Fatalf("seed failed (%d): %s%s", code, out, errOut)
+	}
+
+	// существующие данные не затираются без -force
+	if _, errOut, code := cli(t, "-config", config, "seed"); code != 1 || !strings.Contains(errOut, "-force") {
+		t.Fatalf("expected refusal to overwrite, got %d: %s", code, errOut)
+	}
+
+	file := filepath.Join(t.TempDir(), "fixtures.yaml")
+	os.WriteFile(file, []byte(`
+users:
+  - {id: u-1, username: kate, role: instance_admin, token: kate-token}
+namespaces:
+  - {id: ns-01, name: Team, slug: team, members: {kate: namespace_admin}}
+courses:
+  - {slug: os, name: Operating Systems, startDate: "2025-02-01", endDate: "2025-05-30", repoTemplate: "git@gitlab.local:os.git", namespace: ns-01, owners: [kate]}
+`), 0o644)
+
+	if _, errOut, code := cli(t, "-config", config, "seed", "-file", file, "-force"); code != 0 {
+		t.Fatalf("seed -force failed (%d): %s", code, errOut)
+	}
+
+	out, errOut, code = cli(t, "-config", config, "-as", "kate", "course", "list")
+	if code != 0 || !strings.Contains(out, "os") || strings.Contains(out, "algorithms") {
+		t.Fatalf("fixtures must replace the data (%d): %s%s", code, out, errOut)
+	}
+
+	os.WriteFile(file, []byte("courses:\n  - {slug: os, name: OS, owners: [nobody]}\n"), 0o644)
+	if _, errOut, code := cli(t, "-config", config, "seed", "-file", file, "-force"); code != 1 || !strings.Contains(errOut, "unknown owner") {
+		t.Fatalf("expected fixtures error, got %d: %s", code, errOut)
+	}
+}
+
+func TestCLI_ConfigValidate(t *testing.T) {
+	config := writeConfig(t)
+
+	out, _, code := cli(t, "-config", config, "config", "validate")
+	if code != 0 || !strings.Contains(out, "is valid") {
+		t.Fatalf("expected valid config, got %d: %s", code, out)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.yaml")
+	os.WriteFile(bad, []byte("log:\n  format: xml\nprovision:\n  provider: gitlab\n"), 0o644)
+
+	_, errOut, code := cli(t, "-config", bad, "config", "validate")
+	if code != 1 || !strings.Contains(errOut, "log.format") || !strings.Contains(errOut, "provision.gitlab_token") {
+		t.Fatalf("expected all errors reported, got %d: %s", code, errOut)
+	}
+}
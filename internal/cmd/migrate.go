@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"fcstask-backend/internal/config"
+	"fcstask-backend/internal/database"
+	"fcstask-backend/internal/migrate"
+)
+
+const migrateUsage = `usage: fcstask migrate <command>
+
+commands:
+  up              apply all pending migrations
+  down [steps]    revert the last applied migrations (default 1)
+  status          show applied and pending migrations
+  create <name>   add an empty migration pair to -dir`
+
+// runMigrate - fcstask migrate up|down|status|create
+func runMigrate(ctx context.Context, cfg *config.Config, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(out)
+	dir := fs.String("dir", "internal/migrate/migrations", "migrations source directory for create")
+	fs.Usage = func() { fmt.Fprintln(out, migrateUsage) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("migrate: command is required")
+	}
+
+	command, rest := fs.Arg(0), fs.Args()[1:]
+
+	if command == "create" {
+		if len(rest) != 1 {
+			return errors.New("migrate create: name is required")
+		}
+		up, down, err := migrate.Create(*dir, rest[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "created %s\ncreated %s\n", up, down)
+		return nil
+	}
+
+	db, err := database.Open(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db, migrate.Embedded())
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(out, "applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "database is up to date")
+		}
+		return err
+
+	case "down":
+		steps := 1
+		if len(rest) > 0 {
+			if steps, err = strconv.Atoi(rest[0]); err != nil || steps < 1 {
+				return fmt.Errorf("migrate down: invalid steps %q", rest[0])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Fprintf(out, "reverted %04d_%s\n", m.Version, m.Name)
+		}
+		return err
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			at := "pending"
+			if s.AppliedAt != nil {
+				at = s.AppliedAt.Local().Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, at)
+		}
+		return w.Flush()
+
+	default:
+		fs.Usage()
+		return fmt.Errorf("migrate: unknown command %q", command)
+	}
+}
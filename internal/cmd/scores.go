@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"fcstask-backend/internal/server/handler"
+)
+
+// runScores - fcstask scores export <course>: ведомость в CSV или JSON
+func runScores(ctx context.Context, env *env, args []string) error {
+	if len(args) == 0 || args[0] != "export" {
+		return fmt.Errorf("%w: expected scores export <course>", errUsage)
+	}
+
+	fs := flag.NewFlagSet("scores export", flag.ContinueOnError)
+	if err := env.parseFlags(fs, args[1:], 1, "<course>"); err != nil {
+		return err
+	}
+
+	var rows []handler.ScoreRow
+	err := env.read(ctx, func(actor handler.User) error {
+		var err error
+		rows, err = handler.CourseScores(ctx, actor, fs.Arg(0))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if env.out.json {
+		return env.out.print(rows, nil)
+	}
+
+	w := csv.NewWriter(env.out.w)
+	_ = w.Write([]string{"id", "student", "score", "submitted"})
+	for _, row := range rows {
+		_ = w.Write([]string{strconv.Itoa(row.ID), row.Student, strconv.Itoa(row.Score), row.Submitted})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"fcstask-backend/internal/fixtures"
+	"fcstask-backend/internal/server/handler"
+)
+
+// runSeed - fcstask seed: загружает фикстуры в базу вместо ее содержимого
+func runSeed(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	file := fs.String("file", "", "fixtures file (default: built-in demo data)")
+	force := fs.Bool("force", false, "replace data that is already in the database")
+	if err := env.parseFlags(fs, args, 0, ""); err != nil {
+		return err
+	}
+
+	f, err := fixtures.Load(*file)
+	if err != nil {
+		return err
+	}
+
+	db, err := env.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for attempt := 1; ; attempt++ {
+		loaded, err := handler.LoadState(ctx, db)
+		if err != nil {
+			return err
+		}
+		if loaded && !*force {
+			return errors.New("database already has data, use -force to replace it")
+		}
+
+		if err := handler.ApplyFixtures(f); err != nil {
+			return err
+		}
+
+		err = handler.SaveState(ctx, db)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, handler.ErrStateConflict) || attempt == writeAttempts {
+			return err
+		}
+	}
+
+	seeded := struct {
+		Users      int `json:"users"`
+		Namespaces int `json:"namespaces"`
+		Courses    int `json:"courses"`
+	}{len(f.Users), len(f.Namespaces), len(f.Courses)}
+
+	return env.out.print(seeded, func(w io.Writer) {
+		fmt.Fprintf(w, "seeded %d users, %d namespaces and %d courses\n", seeded.Users, seeded.Namespaces, seeded.Courses)
+	})
+}
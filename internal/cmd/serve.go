@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"fcstask-backend/internal/app"
+	"fcstask-backend/internal/config"
+	"fcstask-backend/internal/logging"
+	"fcstask-backend/internal/tracing"
+)
+
+// runServe - fcstask serve: HTTP-сервер до SIGINT/SIGTERM.
+// С -demo данные берутся из фикстур и живут в памяти, база не нужна
+func runServe(cfg *config.Config, args []string, stderr io.Writer) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.BoolVar(&cfg.Demo.Enabled, "demo", cfg.Demo.Enabled, "serve fixtures from memory, without the database")
+	fs.StringVar(&cfg.Demo.Fixtures, "fixtures", cfg.Demo.Fixtures, "fixtures file for -demo (default: built-in demo data)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: serve takes no arguments", errUsage)
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
+	ctx, stop := signal.NotifyContext(
+		context.Background(),
+		os.Interrupt,
+		syscall.SIGTERM,
+	)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, os.Stdout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Досылаем спаны уже после остановки сервера
+		flushCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Warn("failed to flush traces", "error", err)
+		}
+	}()
+
+	app, err := app.New(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	if err := app.Run(ctx); err != nil {
+		logger.Error("server stopped with error", "error", err)
+		return err
+	}
+	logger.Info("server stopped")
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"fcstask-backend/internal/server/handler"
+)
+
+const userUsage = `usage: fcstask user <command>
+
+commands:
+  add [-email e] [-rms-id id] [-role r] <username>   create a user and print their token
+  set-role [-namespace id] <username> <role>         change the instance role, or the namespace role with -namespace`
+
+// newUser - результат user add: токен показывается один раз
+type newUser struct {
+	handler.User
+	Token string `json:"token"`
+}
+
+// runUser - fcstask user add|set-role
+func runUser(ctx context.Context, env *env, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: user command is required\n%s", errUsage, userUsage)
+	}
+
+	switch args[0] {
+	case "add":
+		return userAdd(ctx, env, args[1:])
+	case "set-role":
+		return userSetRole(ctx, env, args[1:])
+	default:
+		return fmt.Errorf("%w: unknown user command %q\n%s", errUsage, args[0], userUsage)
+	}
+}
+
+func userAdd(ctx context.Context, env *env, args []string) error {
+	var req handler.PostUserRequest
+
+	fs := flag.NewFlagSet("user add", flag.ContinueOnError)
+	fs.StringVar(&req.Email, "email", "", "email address")
+	fs.StringVar(&req.RmsID, "rms-id", "", "RMS identifier")
+	fs.StringVar(&req.Role, "role", handler.RoleStudent, "instance role: student or instance_admin")
+	if err := env.parseFlags(fs, args, 1, "<username>"); err != nil {
+		return err
+	}
+	req.Username = fs.Arg(0)
+
+	var created newUser
+	err := env.write(ctx, func(actor handler.User) error {
+		user, err := handler.CreateUser(actor, req)
+		created = newUser{User: user, Token: user.Token}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return env.out.print(created, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tUSERNAME\tROLE\tTOKEN")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", created.ID, created.Username, created.Role, created.Token)
+	})
+}
+
+func userSetRole(ctx context.Context, env *env, args []string) error {
+	fs := flag.NewFlagSet("user set-role", flag.ContinueOnError)
+	namespace := fs.String("namespace", "", "set the role in this namespace instead of the instance role")
+	if err := env.parseFlags(fs, args, 2, "<username> <role>"); err != nil {
+		return err
+	}
+	username, role := fs.Arg(0), fs.Arg(1)
+
+	if *namespace == "" {
+		var updated handler.User
+		err := env.write(ctx, func(actor handler.User) error {
+			user, err := handler.LookupUser(username)
+			if err != nil {
+				return err
+			}
+			updated, err = handler.SetUserRole(actor, user.ID, role)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		return env.out.print(updated, func(w io.Writer) {
+			fmt.Fprintln(w, "ID\tUSERNAME\tROLE")
+			fmt.Fprintf(w, "%s\t%s\t%s\n", updated.ID, updated.Username, updated.Role)
+		})
+	}
+
+	// В namespace роль либо выдается впервые, либо меняется у участника
+	var member handler.NamespaceUser
+	err := env.write(ctx, func(actor handler.User) error {
+		user, err := handler.LookupUser(username)
+		if err != nil {
+			return err
+		}
+
+		details, err := handler.GetNamespaceDetails(actor, *namespace)
+		if err != nil {
+			return err
+		}
+		for _, existing := range details.Users {
+			if existing.ID == user.ID {
+				member, err = handler.SetNamespaceUserRole(actor, *namespace, user.ID, handler.PutNamespaceUserRequest{Role: role})
+				return err
+			}
+		}
+
+		member, err = handler.AddNamespaceUser(actor, *namespace, handler.PostNamespaceUserRequest{Username: username, Role: role})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return env.out.print(member, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tUSERNAME\tNAMESPACE\tROLE")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", member.ID, member.Username, *namespace, member.Role)
+	})
+}
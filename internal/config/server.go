@@ -1,38 +1,277 @@
 package config
 
 import (
-	//"os"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
 	"time"
-	//"gopkg.in/yaml.v3"
+
+	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Server ServerConfig `yaml:"server"`
+	Server    ServerConfig    `yaml:"server"`
+	Log       LogConfig       `yaml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Provision ProvisionConfig `yaml:"provision"`
+	Mail      MailConfig      `yaml:"mail"`
+	Demo      DemoConfig      `yaml:"demo"`
+	Trash     TrashConfig     `yaml:"trash"`
 }
 
 type ServerConfig struct {
 	Host            string        `yaml:"host"`
 	Port            int           `yaml:"port"`
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+
+	ReadTimeout    time.Duration `yaml:"read_timeout"`
+	WriteTimeout   time.Duration `yaml:"write_timeout"`
+	IdleTimeout    time.Duration `yaml:"idle_timeout"`
+	MaxHeaderBytes int           `yaml:"max_header_bytes"`
+
+	// Сколько ждать после перевода /readyz в 503, прежде чем закрывать HTTP-сервер
+	DrainDelay time.Duration `yaml:"drain_delay"`
+
+	// Ограничение на каждую проверку зависимостей в сводке инстанса
+	HealthCheckTimeout time.Duration `yaml:"health_check_timeout"`
+
+	Admin AdminConfig `yaml:"admin"`
 }
 
-func Load(path string) (*Config, error) {
-	/*
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return nil, err
-		}
+// AdminConfig - отдельный порт с pprof и диагностикой, наружу не публикуется
+type AdminConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Host    string `yaml:"host"`
+	Port    int    `yaml:"port"`
+}
 
-		var cfg Config
-		if err := yaml.Unmarshal(data, &cfg); err != nil {
-			return nil, err
-		}
-	*/
+// LogConfig - формат и уровень логов
+type LogConfig struct {
+	Format string `yaml:"format"` // json или text
+	Level  string `yaml:"level"`  // debug, info, warn, error
+}
+
+// TracingConfig - OpenTelemetry-трейсинг
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"` // none, stdout или otlp
+	Endpoint    string  `yaml:"endpoint"` // для otlp: http://collector:4318/v1/traces
+	Insecure    bool    `yaml:"insecure"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// DatabaseConfig - подключение к базе и миграции схемы
+type DatabaseConfig struct {
+	Driver string `yaml:"driver"` // sqlite
+	DSN    string `yaml:"dsn"`
+
+	// Применять миграции при старте под блокировкой, чтобы реплики не гонялись
+	AutoMigrate bool `yaml:"auto_migrate"`
+}
+
+// ProvisionConfig - создание репозиториев студентов после регистрации
+type ProvisionConfig struct {
+	Provider    string        `yaml:"provider"` // fake или gitlab
+	GitlabURL   string        `yaml:"gitlab_url"`
+	GitlabToken string        `yaml:"gitlab_token"`
+	QueueSize   int           `yaml:"queue_size"`
+	MaxAttempts int           `yaml:"max_attempts"`
+	RetryDelay  time.Duration `yaml:"retry_delay"`
+}
+
+// MailConfig - отправка писем и подтверждение почты
+type MailConfig struct {
+	Driver    string `yaml:"driver"` // smtp или outbox
+	From      string `yaml:"from"`
+	OutboxDir string `yaml:"outbox_dir"`
+
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+
+	// Если секрет пустой, он генерируется при старте и ссылки не переживают рестарт
+	VerificationSecret string        `yaml:"verification_secret"`
+	VerificationTTL    time.Duration `yaml:"verification_ttl"`
+	PublicURL          string        `yaml:"public_url"`
+}
+
+// DemoConfig - демо-режим: данные из фикстур в памяти, база не используется
+type DemoConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Fixtures string `yaml:"fixtures"` // пусто - встроенный набор
+}
+
+// TrashConfig - корзина удаленных курсов
+type TrashConfig struct {
+	// Сколько удаленный курс можно восстановить, потом он удаляется окончательно
+	Retention time.Duration `yaml:"retention"`
+
+	// Как часто фоновая задача ищет курсы, которые пора удалить
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+func Default() *Config {
 	return &Config{
 		Server: ServerConfig{
 			Host:            "localhost",
 			Port:            8080,
 			ShutdownTimeout: 5 * time.Second,
+
+			ReadTimeout:    15 * time.Second,
+			WriteTimeout:   30 * time.Second,
+			IdleTimeout:    60 * time.Second,
+			MaxHeaderBytes: 1 << 20,
+
+			HealthCheckTimeout: 3 * time.Second,
+
+			Admin: AdminConfig{
+				Host: "127.0.0.1",
+				Port: 6060,
+			},
 		},
-	}, nil
+		Log: LogConfig{
+			Format: "json",
+			Level:  "info",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "fcstask-backend",
+			SampleRatio: 1,
+		},
+		Database: DatabaseConfig{
+			Driver: "sqlite",
+			DSN:    "file:var/fcstask.db?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)",
+		},
+		Provision: ProvisionConfig{
+			Provider:    "fake",
+			GitlabURL:   "https://gitlab.local",
+			QueueSize:   100,
+			MaxAttempts: 3,
+			RetryDelay:  5 * time.Second,
+		},
+		Mail: MailConfig{
+			Driver:          "outbox",
+			From:            "FCSTask <noreply@fcstask.local>",
+			OutboxDir:       "var/outbox",
+			SMTPPort:        587,
+			VerificationTTL: 72 * time.Hour,
+			PublicURL:       "http://localhost:8080",
+		},
+		Trash: TrashConfig{
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+	}
+}
+
+// Load читает конфиг поверх значений по умолчанию.
+// В файле можно ссылаться на переменные окружения: ${GITLAB_TOKEN}
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+const redacted = "[redacted]"
+
+// Redacted возвращает копию конфига со скрытыми секретами
+func (c Config) Redacted() Config {
+	for _, secret := range []*string{
+		&c.Provision.GitlabToken,
+		&c.Mail.SMTPPassword,
+		&c.Mail.VerificationSecret,
+	} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	return c
+}
+
+// Validate проверяет конфиг целиком и возвращает все найденные ошибки разом
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	oneOf := func(field, value string, allowed ...string) {
+		check(slices.Contains(allowed, value), "%s: %q is not one of %v", field, value, allowed)
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port < 65536, "server.port: %d is out of range", c.Server.Port)
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.HealthCheckTimeout > 0, "server.health_check_timeout must be positive")
+	check(c.Server.ReadTimeout >= 0, "server.read_timeout must not be negative")
+	check(c.Server.WriteTimeout >= 0, "server.write_timeout must not be negative")
+	check(c.Server.IdleTimeout >= 0, "server.idle_timeout must not be negative")
+	check(c.Server.DrainDelay >= 0, "server.drain_delay must not be negative")
+	check(c.Server.MaxHeaderBytes >= 0, "server.max_header_bytes must not be negative")
+	if c.Server.Admin.Enabled {
+		check(c.Server.Admin.Port > 0 && c.Server.Admin.Port < 65536, "server.admin.port: %d is out of range", c.Server.Admin.Port)
+		check(c.Server.Admin.Port != c.Server.Port || c.Server.Admin.Host != c.Server.Host, "server.admin must listen on a different address than server")
+	}
+
+	oneOf("log.format", c.Log.Format, "json", "text")
+	oneOf("log.level", c.Log.Level, "debug", "info", "warn", "error")
+
+	oneOf("tracing.exporter", c.Tracing.Exporter, "none", "stdout", "otlp")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio: %v is not within [0, 1]", c.Tracing.SampleRatio)
+	if c.Tracing.Exporter == "otlp" {
+		check(isURL(c.Tracing.Endpoint), "tracing.endpoint: %q is not a valid URL", c.Tracing.Endpoint)
+	}
+
+	if c.Demo.Enabled {
+		if c.Demo.Fixtures != "" {
+			_, err := os.Stat(c.Demo.Fixtures)
+			check(err == nil, "demo.fixtures: %v", err)
+		}
+	} else {
+		oneOf("database.driver", c.Database.Driver, "sqlite")
+		check(c.Database.DSN != "", "database.dsn is required")
+	}
+
+	oneOf("provision.provider", c.Provision.Provider, "fake", "gitlab")
+	check(isURL(c.Provision.GitlabURL), "provision.gitlab_url: %q is not a valid URL", c.Provision.GitlabURL)
+	if c.Provision.Provider == "gitlab" {
+		check(c.Provision.GitlabToken != "", "provision.gitlab_token is required for the gitlab provider")
+	}
+	check(c.Provision.QueueSize > 0, "provision.queue_size must be positive")
+	check(c.Provision.MaxAttempts > 0, "provision.max_attempts must be positive")
+	check(c.Provision.RetryDelay >= 0, "provision.retry_delay must not be negative")
+
+	oneOf("mail.driver", c.Mail.Driver, "outbox", "smtp")
+	check(c.Mail.From != "", "mail.from is required")
+	switch c.Mail.Driver {
+	case "outbox":
+		check(c.Mail.OutboxDir != "", "mail.outbox_dir is required for the outbox driver")
+	case "smtp":
+		check(c.Mail.SMTPHost != "", "mail.smtp_host is required for the smtp driver")
+		check(c.Mail.SMTPPort > 0 && c.Mail.SMTPPort < 65536, "mail.smtp_port: %d is out of range", c.Mail.SMTPPort)
+	}
+	check(c.Mail.VerificationTTL > 0, "mail.verification_ttl must be positive")
+	check(isURL(c.Mail.PublicURL), "mail.public_url: %q is not a valid URL", c.Mail.PublicURL)
+
+	check(c.Trash.Retention > 0, "trash.retention must be positive")
+	check(c.Trash.PurgeInterval > 0, "trash.purge_interval must be positive")
+
+	return errors.Join(errs...)
+}
+
+func isURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
 }
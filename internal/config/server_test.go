@@ -0,0 +1,64 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultIsValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("default config must be valid: %v", err)
+	}
+}
+
+func TestConfigFileIsValid(t *testing.T) {
+	cfg, err := Load("../../config/config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("config/config.yaml must be valid: %v", err)
+	}
+}
+
+func TestValidate_ReportsAllErrors(t *testing.T) {
+	cfg := Default()
+	cfg.Server.Port = 0
+	cfg.Log.Format = "xml"
+	cfg.Tracing.SampleRatio = 2
+	cfg.Provision.Provider = "gitlab"
+	cfg.Mail.Driver = "smtp"
+	cfg.Trash.PurgeInterval = 0
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	for _, want := range []string{
+		"server.port",
+		"log.format",
+		"tracing.sample_ratio",
+		"provision.gitlab_token",
+		"mail.smtp_host",
+		"trash.purge_interval",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %s:\n%v", want, err)
+		}
+	}
+}
+
+func TestValidate_DemoSkipsDatabase(t *testing.T) {
+	cfg := Default()
+	cfg.Database.DSN = ""
+	cfg.Demo.Enabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("demo mode does not use the database: %v", err)
+	}
+
+	cfg.Demo.Fixtures = "missing.yaml"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "demo.fixtures") {
+		t.Fatalf("expected demo.fixtures error, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite"
+
+	"fcstask-backend/internal/config"
+)
+
+// Open открывает базу; для SQLite заранее создает каталог файла
+func Open(cfg config.DatabaseConfig) (*sql.DB, error) {
+	switch cfg.Driver {
+	case "sqlite":
+		if path := sqlitePath(cfg.DSN); path != "" && path != ":memory:" {
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+
+	db, err := sql.Open(cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.PingContext(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// sqlitePath достает путь к файлу из DSN вида file:var/fcstask.db?_pragma=...
+func sqlitePath(dsn string) string {
+	path, _, _ := strings.Cut(strings.TrimPrefix(dsn, "file:"), "?")
+	return path
+}
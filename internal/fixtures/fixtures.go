@@ -0,0 +1,238 @@
+// Package fixtures - демо-данные инстанса в YAML: пользователи, namespace'ы,
+// курсы с записями студентов, посылками и досками заданий.
+// Один и тот же файл загружают fcstask seed, fcstask serve -demo и POST /api/demo/reset
+package fixtures
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed demo.yaml
+var demo []byte
+
+// Fixtures - содержимое файла. Пользователи везде указываются по логину
+type Fixtures struct {
+	Users      []User      `yaml:"users"`
+	Namespaces []Namespace `yaml:"namespaces"`
+	Courses    []Course    `yaml:"courses"`
+}
+
+type User struct {
+	ID       string `yaml:"id"`
+	Username string `yaml:"username"`
+	RmsID    string `yaml:"rmsId"`
+	Role     string `yaml:"role"`
+	Email    string `yaml:"email"`
+	Telegram string `yaml:"telegram"`
+	Group    string `yaml:"group"`
+	Token    string `yaml:"token"`
+}
+
+type Namespace struct {
+	ID            string   `yaml:"id"`
+	Name          string   `yaml:"name"`
+	Slug          string   `yaml:"slug"`
+	Description   string   `yaml:"description"`
+	GitlabGroupID string   `yaml:"gitlabGroupId"`
+	AllowedRoles  []string `yaml:"allowedRoles"` // пусто - все роли namespace'а
+
+	// логин -> роль в namespace
+	Members map[string]string `yaml:"members"`
+}
+
+type Course struct {
+	Slug         string   `yaml:"slug"`
+	Name         string   `yaml:"name"`
+	Status       string   `yaml:"status"`
+	StartDate    string   `yaml:"startDate"`
+	EndDate      string   `yaml:"endDate"`
+	Timezone     string   `yaml:"timezone"` // IANA, пусто - UTC
+	RepoTemplate string   `yaml:"repoTemplate"`
+	Description  string   `yaml:"description"`
+	Namespace    string   `yaml:"namespace"`
+	Owners       []string `yaml:"owners"`
+
+	// логин студента -> состояние записи
+	Enrollments map[string]string `yaml:"enrollments"`
+	Submissions []Submission      `yaml:"submissions"`
+	Board       *Board            `yaml:"board"`
+}
+
+type Submission struct {
+	Student     string    `yaml:"student"`
+	Task        string    `yaml:"task"`
+	Score       int       `yaml:"score"`
+	SubmittedAt time.Time `yaml:"submittedAt"`
+}
+
+// Board - доска заданий курса, поля как в GET /api/courses/:courseId/board
+type Board struct {
+	SolvedScore   int     `yaml:"solvedScore"`
+	MaxScore      int     `yaml:"maxScore"`
+	SolvedPercent int     `yaml:"solvedPercent"`
+	Groups        []Group `yaml:"groups"`
+}
+
+type Group struct {
+	ID        string     `yaml:"id"`
+	Name      string     `yaml:"name"`
+	IsSpecial bool       `yaml:"isSpecial"`
+	StartedAt string     `yaml:"startedAt"`
+	EndsAt    string     `yaml:"endsAt"`
+	Deadlines []Deadline `yaml:"deadlines"`
+	Tasks     []Task     `yaml:"tasks"`
+}
+
+type Deadline struct {
+	ID      string  `yaml:"id"`
+	Label   string  `yaml:"label"`
+	Percent float64 `yaml:"percent"`
+	DueAt   string  `yaml:"dueAt"`
+	Status  string  `yaml:"status"`
+}
+
+type Task struct {
+	ID          string  `yaml:"id"`
+	Name        string  `yaml:"name"`
+	Score       int     `yaml:"score"`
+	ScoreEarned int     `yaml:"scoreEarned"`
+	Stats       float64 `yaml:"stats"`
+	IsBonus     bool    `yaml:"isBonus"`
+	IsSpecial   bool    `yaml:"isSpecial"`
+	URL         string  `yaml:"url"`
+}
+
+// Demo - встроенный набор демо-данных
+func Demo() *Fixtures {
+	f, err := Parse(bytes.NewReader(demo))
+	if err != nil {
+		panic("fixtures: embedded demo.yaml: " + err.Error())
+	}
+	return f
+}
+
+// Load читает фикстуры из файла, пустой путь - встроенный набор
+func Load(path string) (*Fixtures, error) {
+	if path == "" {
+		return Demo(), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	f, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Parse разбирает YAML и проверяет, что все ссылки внутри файла разрешаются.
+// Значения ролей и статусов проверяет уже хранилище при применении
+func Parse(r io.Reader) (*Fixtures, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	var f Fixtures
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse fixtures: %w", err)
+	}
+
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Validate - обязательные поля, уникальность и ссылки между разделами
+func (f *Fixtures) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	ids := map[string]bool{}
+	usernames := map[string]bool{}
+	for i, user := range f.Users {
+		switch {
+		case user.ID == "" || user.Username == "":
+			fail("users[%d]: id and username are required", i)
+		case ids[user.ID]:
+			fail("users[%d]: duplicate id %q", i, user.ID)
+		case usernames[user.Username]:
+			fail("users[%d]: duplicate username %q", i, user.Username)
+		}
+		ids[user.ID] = true
+		usernames[user.Username] = true
+	}
+
+	namespaces := map[string]bool{}
+	slugs := map[string]bool{}
+	for i, ns := range f.Namespaces {
+		switch {
+		case ns.ID == "" || ns.Slug == "":
+			fail("namespaces[%d]: id and slug are required", i)
+		case namespaces[ns.ID]:
+			fail("namespaces[%d]: duplicate id %q", i, ns.ID)
+		case slugs[ns.Slug]:
+			fail("namespaces[%d]: duplicate slug %q", i, ns.Slug)
+		}
+		namespaces[ns.ID] = true
+		slugs[ns.Slug] = true
+
+		for _, username := range slices.Sorted(maps.Keys(ns.Members)) {
+			if !usernames[username] {
+				fail("namespace %s: unknown member %q", ns.ID, username)
+			}
+		}
+	}
+
+	courses := map[string]bool{}
+	for i, course := range f.Courses {
+		if course.Slug == "" || course.Name == "" {
+			fail("courses[%d]: slug and name are required", i)
+			continue
+		}
+		if courses[course.Slug] {
+			fail("courses[%d]: duplicate slug %q", i, course.Slug)
+		}
+		courses[course.Slug] = true
+
+		if course.Namespace != "" && !namespaces[course.Namespace] {
+			fail("course %s: unknown namespace %q", course.Slug, course.Namespace)
+		}
+		for _, owner := range course.Owners {
+			if !usernames[owner] {
+				fail("course %s: unknown owner %q", course.Slug, owner)
+			}
+		}
+		for _, username := range slices.Sorted(maps.Keys(course.Enrollments)) {
+			if !usernames[username] {
+				fail("course %s: unknown student %q", course.Slug, username)
+			}
+		}
+		for j, submission := range course.Submissions {
+			if _, ok := course.Enrollments[submission.Student]; !ok {
+				fail("course %s: submissions[%d]: %q is not enrolled", course.Slug, j, submission.Student)
+			}
+			if submission.Task == "" {
+				fail("course %s: submissions[%d]: task is required", course.Slug, j)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
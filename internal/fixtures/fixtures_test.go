@@ -0,0 +1,81 @@
+package fixtures
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDemo(t *testing.T) {
+	f := Demo()
+
+	if len(f.Users) != 3 || len(f.Namespaces) != 2 || len(f.Courses) != 6 {
+		t.Fatalf("unexpected demo data: %d users, %d namespaces, %d courses", len(f.Users), len(f.Namespaces), len(f.Courses))
+	}
+
+	algorithms := f.Courses[0]
+	if algorithms.Slug != "algorithms" || algorithms.Board == nil || len(algorithms.Board.Groups) != 2 {
+		t.Fatalf("algorithms board missing: %+v", algorithms)
+	}
+	if len(algorithms.Submissions) != 2 || algorithms.Submissions[1].SubmittedAt.Day() != 5 {
+		t.Errorf("submissions not parsed: %+v", algorithms.Submissions)
+	}
+}
+
+func TestParse_UnknownField(t *testing.T) {
+	_, err := Parse(strings.NewReader("users:\n  - {id: u-1, username: kate, nickname: k}\n"))
+	if err == nil || !strings.Contains(err.Error(), "nickname") {
+		t.Fatalf("expected unknown field error, got %v", err)
+	}
+}
+
+func TestParse_ReportsAllBrokenReferences(t *testing.T) {
+	data := `
+users:
+  - {id: u-1, username: kate}
+  - {id: u-1, username: kate}
+namespaces:
+  - {id: ns-01, slug: team, members: {bob: student}}
+courses:
+  - slug: os
+    name: OS
+    namespace: ns-02
+    owners: [kate, alex]
+    submissions:
+      - {student: kate, task: t1}
+`
+	_, err := Parse(strings.NewReader(data))
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	for _, want := range []string{
+		`duplicate id "u-1"`,
+		`unknown member "bob"`,
+		`unknown namespace "ns-02"`,
+		`unknown owner "alex"`,
+		`"kate" is not enrolled`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %s:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	if f, err := Load(""); err != nil || len(f.Courses) != len(Demo().Courses) {
+		t.Fatalf("empty path must load the demo data: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "fixtures.yaml")
+	os.WriteFile(path, []byte("users:\n  - {id: u-1, username: kate}\n"), 0o644)
+	f, err := Load(path)
+	if err != nil || len(f.Users) != 1 || f.Users[0].Username != "kate" {
+		t.Fatalf("load file: %+v, %v", f, err)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("expected error for a missing file")
+	}
+}
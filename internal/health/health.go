@@ -0,0 +1,123 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Статусы компонента и инстанса
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+// Checker - проверка зависимости, nil означает что зависимость работает
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc позволяет использовать функцию как Checker
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Component - состояние одной зависимости
+type Component struct {
+	Name   string    `json:"name"`
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	Since  time.Time `json:"since"` // с какого момента компонент в текущем статусе
+}
+
+// Report - результат проверки всех зарегистрированных зависимостей
+type Report struct {
+	Status     string      `json:"status"`
+	Components []Component `json:"components"`
+}
+
+// Registry хранит проверки и помнит, с какого момента каждая из них падает
+type Registry struct {
+	timeout time.Duration
+	now     func() time.Time
+
+	mu       sync.Mutex
+	names    []string
+	checkers map[string]Checker
+	last     map[string]Component
+}
+
+// NewRegistry создает реестр; timeout ограничивает каждую проверку
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{
+		timeout:  timeout,
+		now:      time.Now,
+		checkers: make(map[string]Checker),
+		last:     make(map[string]Component),
+	}
+}
+
+// Register добавляет проверку, повторная регистрация заменяет предыдущую
+func (r *Registry) Register(name string, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.checkers[name]; !exists {
+		r.names = append(r.names, name)
+	}
+	r.checkers[name] = checker
+}
+
+// Check выполняет все проверки параллельно. Один упавший компонент
+// переводит инстанс в degraded
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.Lock()
+	names := append([]string(nil), r.names...)
+	checkers := make([]Checker, len(names))
+	for i, name := range names {
+		checkers[i] = r.checkers[name]
+	}
+	r.mu.Unlock()
+
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+	for i, checker := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, r.timeout)
+			defer cancel()
+
+			errs[i] = checker.Check(checkCtx)
+		}()
+	}
+	wg.Wait()
+
+	now := r.now().UTC()
+	report := Report{Status: StatusOK, Components: make([]Component, len(names))}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, name := range names {
+		component := Component{Name: name, Status: StatusOK, Since: now}
+		if errs[i] != nil {
+			component.Status = StatusDegraded
+			component.Error = errs[i].Error()
+			report.Status = StatusDegraded
+		}
+
+		if prev, ok := r.last[name]; ok && prev.Status == component.Status {
+			component.Since = prev.Since
+		}
+		r.last[name] = component
+
+		report.Components[i] = component
+	}
+
+	return report
+}
@@ -0,0 +1,74 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Aggregates(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.Register("mailer", CheckerFunc(func(context.Context) error { return nil }))
+	r.Register("vcs", CheckerFunc(func(context.Context) error { return nil }))
+
+	report := r.Check(context.Background())
+	if report.Status != StatusOK || len(report.Components) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.Components[0].Name != "mailer" || report.Components[1].Name != "vcs" {
+		t.Errorf("components must keep registration order: %+v", report.Components)
+	}
+}
+
+func TestRegistry_FailingComponentSince(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	r := NewRegistry(time.Second)
+	r.now = func() time.Time { return now }
+
+	var vcsErr error
+	r.Register("mailer", CheckerFunc(func(context.Context) error { return nil }))
+	r.Register("vcs", CheckerFunc(func(context.Context) error { return vcsErr }))
+
+	r.Check(context.Background())
+
+	failedAt := now.Add(time.Minute)
+	now = failedAt
+	vcsErr = errors.New("gitlab responded with 502 Bad Gateway")
+	r.Check(context.Background())
+
+	now = now.Add(time.Minute)
+	report := r.Check(context.Background())
+
+	if report.Status != StatusDegraded {
+		t.Fatalf("expected degraded, got %s", report.Status)
+	}
+
+	vcs := report.Components[1]
+	if vcs.Status != StatusDegraded || vcs.Error != vcsErr.Error() || !vcs.Since.Equal(failedAt) {
+		t.Errorf("unexpected vcs component: %+v", vcs)
+	}
+	if mailer := report.Components[0]; mailer.Status != StatusOK || !mailer.Since.Equal(failedAt.Add(-time.Minute)) {
+		t.Errorf("unexpected mailer component: %+v", mailer)
+	}
+
+	now = now.Add(time.Minute)
+	vcsErr = nil
+	if report := r.Check(context.Background()); report.Status != StatusOK || !report.Components[1].Since.Equal(now) {
+		t.Errorf("recovered component should reset since: %+v", report)
+	}
+}
+
+func TestRegistry_Timeout(t *testing.T) {
+	r := NewRegistry(10 * time.Millisecond)
+	r.Register("smtp", CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	report := r.Check(context.Background())
+	if report.Status != StatusDegraded || report.Components[0].Error == "" {
+		t.Fatalf("hanging check should fail by timeout: %+v", report)
+	}
+}
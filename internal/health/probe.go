@@ -0,0 +1,56 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+)
+
+// Probe отдает liveness и readiness для оркестратора.
+// Готовность определяется проверками, которые регистрируют подсистемы при старте
+type Probe struct {
+	checks   *Registry
+	draining atomic.Bool
+}
+
+func NewProbe(checks *Registry) *Probe {
+	return &Probe{checks: checks}
+}
+
+// Drain переводит readiness в failing, чтобы балансировщик перестал слать трафик.
+// Вызывается в самом начале остановки, до закрытия HTTP-сервера
+func (p *Probe) Drain() {
+	p.draining.Store(true)
+}
+
+// Draining сообщает, началась ли остановка
+func (p *Probe) Draining() bool {
+	return p.draining.Load()
+}
+
+// Live - GET /healthz, процесс жив и отвечает
+func (p *Probe) Live(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+}
+
+// Ready - GET /readyz, 503 во время остановки или если падает хотя бы одна проверка
+func (p *Probe) Ready(w http.ResponseWriter, r *http.Request) {
+	if p.Draining() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "draining"})
+		return
+	}
+
+	report := p.checks.Check(r.Context())
+	if report.Status != StatusOK {
+		writeJSON(w, http.StatusServiceUnavailable, report)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
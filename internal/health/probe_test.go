@@ -0,0 +1,45 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProbe_Ready(t *testing.T) {
+	var queueErr error
+
+	checks := NewRegistry(time.Second)
+	checks.Register("queue", CheckerFunc(func(context.Context) error { return queueErr }))
+	probe := NewProbe(checks)
+
+	ready := func() int {
+		rec := httptest.NewRecorder()
+		probe.Ready(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rec.Code
+	}
+
+	if code := ready(); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	queueErr = errors.New("provisioning worker is not running")
+	if code := ready(); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 for failing check, got %d", code)
+	}
+
+	queueErr = nil
+	probe.Drain()
+	if code := ready(); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while draining, got %d", code)
+	}
+
+	rec := httptest.NewRecorder()
+	probe.Live(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("liveness must not depend on draining, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+type ctxKey struct{}
+
+// request - данные запроса, которые дополняются по ходу обработки
+type request struct {
+	logger *slog.Logger
+	user   string
+}
+
+// New создает логгер; format - json или text, level - debug, info, warn, error
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch strings.ToLower(format) {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// FromContext возвращает логгер запроса или slog.Default вне запроса
+func FromContext(ctx context.Context) *slog.Logger {
+	if req, ok := ctx.Value(ctxKey{}).(*request); ok {
+		return req.logger
+	}
+	return slog.Default()
+}
+
+// WithLogger кладет логгер в контекст
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &request{logger: logger})
+}
+
+// SetUser запоминает пользователя запроса для итоговой строки лога
+func SetUser(ctx context.Context, username string) {
+	if req, ok := ctx.Value(ctxKey{}).(*request); ok {
+		req.user = username
+	}
+}
+
+func userFrom(ctx context.Context) string {
+	if req, ok := ctx.Value(ctxKey{}).(*request); ok {
+		return req.user
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger, err := New(&buf, "json", "warn")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown")
+
+	if bytes.Contains(buf.Bytes(), []byte("hidden")) || !bytes.Contains(buf.Bytes(), []byte(`"msg":"shown"`)) {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+
+	if _, err := New(&buf, "xml", "info"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if _, err := New(&buf, "text", "loud"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
+
+func setupEcho(buf *bytes.Buffer) *echo.Echo {
+	logger, _ := New(buf, "json", "debug")
+
+	e := echo.New()
+	e.Use(Middleware(logger, func() string { return "generated-id" }))
+	e.GET("/api/courses/:courseId", func(c echo.Context) error {
+		SetUser(c.Request().Context(), "alex")
+		FromContext(c.Request().Context()).Debug("inside handler")
+		return c.NoContent(http.StatusOK)
+	})
+
+	return e
+}
+
+func TestMiddleware_RequestLine(t *testing.T) {
+	var buf bytes.Buffer
+	e := setupEcho(&buf)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/courses/algorithms", nil))
+
+	if id := rec.Header().Get(echo.HeaderXRequestID); id != "generated-id" {
+		t.Fatalf("unexpected request id %q", id)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected handler and request lines, got:\n%s", buf.String())
+	}
+
+	var inside, line map[string]any
+	json.Unmarshal(lines[0], &inside)
+	json.Unmarshal(lines[1], &line)
+
+	if inside["request_id"] != "generated-id" {
+		t.Errorf("handler logger must carry request id: %v", inside)
+	}
+	if line["route"] != "/api/courses/:courseId" || line["status"] != float64(200) || line["user"] != "alex" || line["method"] != "GET" {
+		t.Errorf("unexpected request line: %v", line)
+	}
+	if _, ok := line["latency"]; !ok {
+		t.Errorf("latency is missing: %v", line)
+	}
+}
+
+func TestMiddleware_PropagatesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	e := setupEcho(&buf)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/courses/algorithms", nil)
+	req.Header.Set(echo.HeaderXRequestID, "upstream-42")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if id := rec.Header().Get(echo.HeaderXRequestID); id != "upstream-42" {
+		t.Errorf("incoming request id must be kept, got %q", id)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/courses/algorithms", nil)
+	req.Header.Set(echo.HeaderXRequestID, "bad id\nwith newline")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if id := rec.Header().Get(echo.HeaderXRequestID); id != "generated-id" {
+		t.Errorf("malformed request id must be replaced, got %q", id)
+	}
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Входящий X-Request-ID принимаем, только если он похож на идентификатор
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Middleware присваивает запросу X-Request-ID (или берет присланный),
+// кладет логгер с request_id в контекст и пишет строку лога по завершении
+func Middleware(base *slog.Logger, newID func() string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			id := req.Header.Get(echo.HeaderXRequestID)
+			if !requestIDPattern.MatchString(id) {
+				id = newID()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+
+			logger := base.With("request_id", id)
+			if span := trace.SpanContextFromContext(req.Context()); span.IsValid() {
+				logger = logger.With("trace_id", span.TraceID().String())
+			}
+			ctx := WithLogger(req.Context(), logger)
+			c.SetRequest(req.WithContext(ctx))
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			level := slog.LevelInfo
+			switch {
+			case status >= 500:
+				level = slog.LevelError
+			case status >= 400:
+				level = slog.LevelWarn
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("route", c.Path()),
+				slog.String("path", req.URL.Path),
+				slog.Int("status", status),
+				slog.Duration("latency", time.Since(start)),
+			}
+			if user := userFrom(ctx); user != "" {
+				attrs = append(attrs, slog.String("user", user))
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+
+			logger.LogAttrs(ctx, level, "request", attrs...)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Message - письмо в виде простого текста
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer - способ доставки писем
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var errHeaderInjection = errors.New("mail header contains a line break")
+
+// render собирает RFC 5322 сообщение
+func render(from string, msg Message, now time.Time) ([]byte, error) {
+	for _, header := range []string{from, msg.To, msg.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, errHeaderInjection
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,80 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	data, err := render("fcstask <noreply@fcstask.local>", Message{
+		To:      "student@example.com",
+		Subject: "Подтверждение почты",
+		Body:    "line1\nline2",
+	}, time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	text := string(data)
+	for _, want := range []string{
+		"From: fcstask <noreply@fcstask.local>\r\n",
+		"To: student@example.com\r\n",
+		"Subject: =?utf-8?q?",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"\r\n\r\nline1\r\nline2",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("message does not contain %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestRender_RejectsHeaderInjection(t *testing.T) {
+	_, err := render("noreply@fcstask.local", Message{To: "a@b.c\r\nBcc: evil@x.y", Subject: "hi"}, time.Now())
+	if err == nil {
+		t.Fatal("expected error for header injection")
+	}
+}
+
+func TestOutbox_WritesMessages(t *testing.T) {
+	outbox, err := NewOutbox(t.TempDir()+"/outbox", "noreply@fcstask.local")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, to := range []string{"a@example.com", "b@example.com"} {
+		if err := outbox.Send(context.Background(), Message{To: to, Subject: "hi", Body: "hello"}); err != nil {
+			t.Fatalf("send failed: %v", err)
+		}
+	}
+
+	files, _ := outbox.Messages()
+	if len(files) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(files))
+	}
+
+	data, _ := os.ReadFile(files[1])
+	if !strings.Contains(string(data), "To: b@example.com") {
+		t.Errorf("unexpected message order or content:\n%s", data)
+	}
+}
+
+func TestOutbox_Check(t *testing.T) {
+	dir := t.TempDir() + "/outbox"
+	outbox, err := NewOutbox(dir, "noreply@fcstask.local")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := outbox.Check(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	os.RemoveAll(dir)
+	if err := outbox.Check(context.Background()); err == nil {
+		t.Fatal("expected error for removed outbox directory")
+	}
+}
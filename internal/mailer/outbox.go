@@ -0,0 +1,64 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Outbox складывает письма файлами .eml в каталог.
+// Для локальной разработки и тестов вместо настоящего SMTP
+type Outbox struct {
+	dir  string
+	from string
+
+	mu  sync.Mutex
+	seq int
+}
+
+func NewOutbox(dir, from string) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Outbox{dir: dir, from: from}, nil
+}
+
+func (o *Outbox) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	data, err := render(o.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	o.seq++
+	name := fmt.Sprintf("%s-%04d.eml", now.UTC().Format("20060102T150405"), o.seq)
+	o.mu.Unlock()
+
+	return os.WriteFile(filepath.Join(o.dir, name), data, 0o644)
+}
+
+// Check проверяет, что каталог для писем существует
+func (o *Outbox) Check(context.Context) error {
+	info, err := os.Stat(o.dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("outbox %s is not a directory", o.dir)
+	}
+	return nil
+}
+
+// Messages возвращает пути отправленных писем в порядке отправки
+func (o *Outbox) Messages() ([]string, error) {
+	return filepath.Glob(filepath.Join(o.dir, "*.eml"))
+}
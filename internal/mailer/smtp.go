@@ -0,0 +1,89 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"fcstask-backend/internal/tracing"
+)
+
+// tracerName - имя трейсера спанов пакета
+const tracerName = "fcstask-backend/internal/mailer"
+
+// SMTP - отправка через SMTP-релей
+type SMTP struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTP(host string, port int, username, password, from string) *SMTP {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTP{
+		addr: fmt.Sprintf("%s:%d", host, port),
+		from: from,
+		auth: auth,
+	}
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) (err error) {
+	_, span := tracing.Tracer(tracerName).Start(ctx, "smtp.send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("server.address", s.addr)),
+	)
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := render(s.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	from, err := mail.ParseAddress(s.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	return smtp.SendMail(s.addr, s.auth, from.Address, []string{msg.To}, data)
+}
+
+// Check подключается к релею и дожидается приветствия, письмо не отправляется
+func (s *SMTP) Check(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	host, _, _ := net.SplitHostPort(s.addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "fcstask"
+
+// Metrics - метрики HTTP и доменных событий
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+
+	ProvisioningFailures prometheus.Counter
+	SchedulerRuns        *prometheus.CounterVec // job, result
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route template, method and status code.",
+		}, []string{"method", "route", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route template.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "HTTP requests currently being served.",
+		}, []string{"method", "route"}),
+
+		ProvisioningFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "provisioning_failures_total",
+			Help:      "Repository provisioning jobs that failed after all attempts.",
+		}),
+		SchedulerRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "scheduler_runs_total",
+			Help:      "Scheduled job runs by job and result.",
+		}, []string{"job", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests, m.duration, m.inFlight,
+		m.ProvisioningFailures, m.SchedulerRuns,
+	)
+
+	return m
+}
+
+// SchedulerRun засчитывает запуск фоновой задачи job с результатом success или failure
+func (m *Metrics) SchedulerRun(job string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	m.SchedulerRuns.WithLabelValues(job, result).Inc()
+}
+
+// Handler отдает метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware считает запросы по шаблону маршрута (/api/courses/:courseId),
+// а не по сырому пути, чтобы не раздувать число рядов
+func (m *Metrics) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			method := c.Request().Method
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+
+			inFlight := m.inFlight.WithLabelValues(method, route)
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			m.duration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+			m.requests.WithLabelValues(method, route, strconv.Itoa(c.Response().Status)).Inc()
+
+			return nil
+		}
+	}
+}
+
+// CoursesByStatus публикует число курсов по статусам; count вызывается при каждом сборе метрик
+func (m *Metrics) CoursesByStatus(count func() map[string]int) {
+	m.registry.MustRegister(&statusCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "courses"),
+			"Courses by status.",
+			[]string{"status"}, nil,
+		),
+		count: count,
+	})
+}
+
+type statusCollector struct {
+	desc  *prometheus.Desc
+	count func() map[string]int
+}
+
+func (s *statusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.desc
+}
+
+func (s *statusCollector) Collect(ch chan<- prometheus.Metric) {
+	for status, n := range s.count() {
+		ch <- prometheus.MustNewConstMetric(s.desc, prometheus.GaugeValue, float64(n), status)
+	}
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware_UsesRouteTemplate(t *testing.T) {
+	m := New()
+
+	e := echo.New()
+	e.Use(m.Middleware())
+	e.GET("/api/courses/:courseId", func(c echo.Context) error {
+		if c.Param("courseId") == "missing" {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, path := range []string{"/api/courses/algorithms", "/api/courses/golang", "/api/courses/missing", "/nope"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if n := testutil.ToFloat64(m.requests.WithLabelValues("GET", "/api/courses/:courseId", "200")); n != 2 {
+		t.Errorf("expected 2 ok requests, got %v", n)
+	}
+	if n := testutil.ToFloat64(m.requests.WithLabelValues("GET", "/api/courses/:courseId", "404")); n != 1 {
+		t.Errorf("handler error status must be recorded, got %v", n)
+	}
+	if n := testutil.CollectAndCount(m.requests); n != 3 {
+		t.Errorf("raw paths must not become labels, got %d series", n)
+	}
+	if n := testutil.ToFloat64(m.inFlight.WithLabelValues("GET", "/api/courses/:courseId")); n != 0 {
+		t.Errorf("in-flight gauge should be back to zero, got %v", n)
+	}
+}
+
+func TestHandler_ExposesDomainMetrics(t *testing.T) {
+	m := New()
+	m.CoursesByStatus(func() map[string]int { return map[string]int{"created": 2, "finished": 1} })
+	m.ProvisioningFailures.Inc()
+	m.SchedulerRun("trash_purge", nil)
+	m.SchedulerRun("trash_purge", nil)
+	m.SchedulerRun("trash_purge", errors.New("database is locked"))
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	for _, line := range []string{
+		`fcstask_courses{status="created"} 2`,
+		`fcstask_courses{status="finished"} 1`,
+		`fcstask_provisioning_failures_total 1`,
+		`fcstask_scheduler_runs_total{job="trash_purge",result="success"} 2`,
+		`fcstask_scheduler_runs_total{job="trash_purge",result="failure"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), line) {
+			t.Errorf("missing %q", line)
+		}
+	}
+
+	// счетчики без источника событий не публикуются
+	if strings.Contains(rec.Body.String(), "fcstask_reports_") {
+		t.Error("report metrics must not be exposed until reports are ingested")
+	}
+}
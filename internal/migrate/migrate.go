@@ -0,0 +1,329 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var embedded embed.FS
+
+// Embedded возвращает миграции, встроенные в бинарник
+func Embedded() fs.FS {
+	sub, _ := fs.Sub(embedded, "migrations")
+	return sub
+}
+
+// ErrLocked - миграции сейчас выполняет другой процесс
+var ErrLocked = errors.New("migrations are locked by another process")
+
+// Migration - пара up/down-скриптов одной версии
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status - состояние миграции в базе
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+// Файлы миграций: 0001_create_courses.up.sql / 0001_create_courses.down.sql
+var filePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Load читает и проверяет миграции: у каждой версии должны быть up и down
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := filePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s must have both up and down scripts", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator применяет и откатывает миграции под блокировкой
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	owner      string
+
+	// Сколько ждать чужую блокировку и через сколько считать ее брошенной
+	LockTimeout time.Duration
+	LockTTL     time.Duration
+}
+
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _ := os.Hostname()
+
+	return &Migrator{
+		db:          db,
+		migrations:  migrations,
+		owner:       fmt.Sprintf("%s:%d:%d", host, os.Getpid(), time.Now().UnixNano()),
+		LockTimeout: 30 * time.Second,
+		LockTTL:     10 * time.Minute,
+	}, nil
+}
+
+func (m *Migrator) init(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS schema_lock (
+			id          INTEGER PRIMARY KEY CHECK (id = 1),
+			owner       TEXT NOT NULL,
+			acquired_at TEXT NOT NULL
+		);`)
+	return err
+}
+
+// withLock выполняет fn под блокировкой-строкой в schema_lock.
+// Так две реплики, стартующие одновременно, не применяют миграции параллельно
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	if err := m.init(ctx); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(m.LockTimeout)
+	for {
+		now := time.Now().UTC()
+
+		// Блокировку упавшего процесса забираем по истечении TTL
+		if _, err := m.db.ExecContext(ctx, `DELETE FROM schema_lock WHERE acquired_at < ?`,
+			now.Add(-m.LockTTL).Format(time.RFC3339Nano)); err != nil {
+			return err
+		}
+
+		res, err := m.db.ExecContext(ctx, `INSERT OR IGNORE INTO schema_lock (id, owner, acquired_at) VALUES (1, ?, ?)`,
+			m.owner, now.Format(time.RFC3339Nano))
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			return ErrLocked
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	defer func() {
+		_, _ = m.db.ExecContext(context.WithoutCancel(ctx), `DELETE FROM schema_lock WHERE owner = ?`, m.owner)
+	}()
+
+	return fn()
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	rows, err := m.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at string
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version], _ = time.Parse(time.RFC3339Nano, at)
+	}
+	return applied, rows.Err()
+}
+
+// Up применяет все неприменённые миграции по возрастанию версий
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+
+	err := m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			err := m.inTx(ctx, migration.Up, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+				migration.Version, migration.Name, time.Now().UTC().Format(time.RFC3339Nano))
+			if err != nil {
+				return fmt.Errorf("apply %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// Down откатывает steps последних применённых миграций
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+
+	err := m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			err := m.inTx(ctx, migration.Down, `DELETE FROM schema_migrations WHERE version = ?`, migration.Version)
+			if err != nil {
+				return fmt.Errorf("revert %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// Status возвращает все известные миграции с отметкой о применении
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.init(ctx); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if at, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// inTx выполняет скрипт миграции и запись в schema_migrations одной транзакцией
+func (m *Migrator) inTx(ctx context.Context, script, bookkeeping string, args ...any) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+var unsafeName = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create заводит пустую пару файлов со следующим номером версии в каталоге dir
+func Create(dir, name string) (up, down string, err error) {
+	slug := strings.Trim(unsafeName.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if slug == "" {
+		return "", "", fmt.Errorf("invalid migration name %q", name)
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+
+	version := 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s", version, slug))
+	up, down = base+".up.sql", base+".down.sql"
+
+	if err := os.WriteFile(up, []byte("-- "+name+"\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(down, []byte("-- revert "+name+"\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	return up, down, nil
+}
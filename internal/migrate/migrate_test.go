@@ -0,0 +1,230 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func openDB(t *testing.T) *sql.DB {
+	dsn := "file:" + filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func tables(t *testing.T, db *sql.DB) map[string]bool {
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	names := map[string]bool{}
+	for rows.Next() {
+		var name string
+		rows.Scan(&name)
+		names[name] = true
+	}
+	return names
+}
+
+func TestEmbeddedMigrations_UpAndDown(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+
+	m, err := New(db, Embedded())
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if len(applied) != len(m.migrations) {
+		t.Fatalf("expected all %d migrations applied, got %d", len(m.migrations), len(applied))
+	}
+
+	got := tables(t, db)
+	for _, table := range []string{"courses", "users", "namespaces", "enrollments", "submissions", "invites", "audit_log"} {
+		if !got[table] {
+			t.Errorf("table %s is missing after up", table)
+		}
+	}
+
+	// повторный up ничего не делает
+	if again, err := m.Up(ctx); err != nil || len(again) != 0 {
+		t.Fatalf("repeated up: %v, %d applied", err, len(again))
+	}
+
+	// схема рабочая: внешние ключи и CHECK на состояние записи
+	if _, err := db.Exec(`INSERT INTO enrollments (course_id, user_id, state, updated_at) VALUES ('missing', 'missing', 'active', '')`); err == nil {
+		t.Error("foreign keys must be enforced")
+	}
+
+	reverted, err := m.Down(ctx, len(m.migrations))
+	if err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if len(reverted) != len(m.migrations) || reverted[0].Version != m.migrations[len(m.migrations)-1].Version {
+		t.Fatalf("down must revert newest first: %+v", reverted)
+	}
+
+	got = tables(t, db)
+	delete(got, "schema_migrations")
+	delete(got, "schema_lock")
+	if len(got) != 0 {
+		t.Errorf("tables left after full down: %v", got)
+	}
+
+	// и обратно вперед
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("up after down: %v", err)
+	}
+}
+
+func TestStatusAndPartialDown(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+
+	m, _ := New(db, Embedded())
+	m.Up(ctx)
+
+	if _, err := m.Down(ctx, 1); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := statuses[len(statuses)-1]
+	if last.Applied || last.AppliedAt != nil {
+		t.Errorf("last migration should be reverted: %+v", last)
+	}
+	if !statuses[0].Applied || statuses[0].AppliedAt == nil {
+		t.Errorf("first migration should stay applied: %+v", statuses[0])
+	}
+}
+
+func TestFailedMigrationIsRolledBack(t *testing.T) {
+	db := openDB(t)
+
+	m, err := New(db, fstest.MapFS{
+		"0001_ok.up.sql":    {Data: []byte(`CREATE TABLE a (id INTEGER);`)},
+		"0001_ok.down.sql":  {Data: []byte(`DROP TABLE a;`)},
+		"0002_bad.up.sql":   {Data: []byte(`CREATE TABLE b (id INTEGER); INSERT INTO missing VALUES (1);`)},
+		"0002_bad.down.sql": {Data: []byte(`DROP TABLE b;`)},
+		"README.md":         {Data: []byte(`ignored`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Up(context.Background()); err == nil {
+		t.Fatal("expected error from broken migration")
+	}
+
+	got := tables(t, db)
+	if !got["a"] || got["b"] {
+		t.Errorf("broken migration must be rolled back entirely: %v", got)
+	}
+}
+
+func TestLoad_RequiresDownScript(t *testing.T) {
+	_, err := Load(fstest.MapFS{"0001_init.up.sql": {Data: []byte(`SELECT 1;`)}})
+	if err == nil {
+		t.Fatal("expected error for migration without down script")
+	}
+}
+
+func TestConcurrentUpUnderLock(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "shared.db") + "?_pragma=busy_timeout(5000)"
+
+	var wg sync.WaitGroup
+	results := make([]int, 2)
+	errs := make([]error, 2)
+
+	for i := range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			db, err := sql.Open("sqlite", dsn)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer db.Close()
+
+			m, _ := New(db, Embedded())
+			applied, err := m.Up(context.Background())
+			results[i], errs[i] = len(applied), err
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	all, _ := Load(Embedded())
+	if results[0]+results[1] != len(all) {
+		t.Fatalf("each migration must be applied exactly once, got %v", results)
+	}
+}
+
+func TestLockTimeout(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+
+	holder, _ := New(db, Embedded())
+	waiter, _ := New(db, Embedded())
+	waiter.LockTimeout = 100 * time.Millisecond
+
+	err := holder.withLock(ctx, func() error {
+		_, err := waiter.Up(ctx)
+		return err
+	})
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+
+	// брошенная блокировка забирается по TTL
+	db.Exec(`INSERT INTO schema_lock (id, owner, acquired_at) VALUES (1, 'crashed', ?)`, time.Now().Add(-time.Hour).UTC().Format(time.RFC3339Nano))
+	if _, err := waiter.Up(ctx); err != nil {
+		t.Fatalf("stale lock should be taken over: %v", err)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "0007_old.up.sql"), []byte("SELECT 1;"), 0o644)
+	os.WriteFile(filepath.Join(dir, "0007_old.down.sql"), []byte("SELECT 1;"), 0o644)
+
+	up, down, err := Create(dir, "Add course Tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(up) != "0008_add_course_tags.up.sql" || filepath.Base(down) != "0008_add_course_tags.down.sql" {
+		t.Errorf("unexpected files: %s, %s", up, down)
+	}
+
+	if _, _, err := Create(dir, "!!!"); err == nil {
+		t.Error("expected error for empty name")
+	}
+}
@@ -0,0 +1,6 @@
+DROP TABLE course_owners;
+DROP TABLE courses;
+DROP TABLE namespace_members;
+DROP INDEX users_email;
+DROP TABLE users;
+DROP TABLE namespaces;
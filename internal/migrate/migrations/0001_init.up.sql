@@ -0,0 +1,48 @@
+CREATE TABLE namespaces (
+    id              TEXT PRIMARY KEY,
+    name            TEXT NOT NULL,
+    slug            TEXT NOT NULL UNIQUE,
+    description     TEXT NOT NULL DEFAULT '',
+    gitlab_group_id TEXT NOT NULL DEFAULT '',
+    allowed_roles   TEXT NOT NULL DEFAULT '' -- через запятую
+);
+
+CREATE TABLE users (
+    id             TEXT PRIMARY KEY,
+    username       TEXT NOT NULL UNIQUE,
+    rms_id         TEXT NOT NULL DEFAULT '',
+    role           TEXT NOT NULL,
+    email          TEXT,
+    telegram       TEXT NOT NULL DEFAULT '',
+    grp            TEXT NOT NULL DEFAULT '',
+    token_hash     TEXT NOT NULL DEFAULT '',
+    email_verified INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX users_email ON users (email) WHERE email IS NOT NULL;
+
+CREATE TABLE namespace_members (
+    namespace_id TEXT NOT NULL REFERENCES namespaces (id),
+    user_id      TEXT NOT NULL REFERENCES users (id),
+    role         TEXT NOT NULL,
+    PRIMARY KEY (namespace_id, user_id)
+);
+
+CREATE TABLE courses (
+    id            TEXT PRIMARY KEY,
+    name          TEXT NOT NULL,
+    status        TEXT NOT NULL,
+    start_date    TEXT NOT NULL,
+    end_date      TEXT NOT NULL,
+    repo_template TEXT NOT NULL,
+    description   TEXT NOT NULL DEFAULT '',
+    url           TEXT NOT NULL,
+    namespace_id  TEXT REFERENCES namespaces (id),
+    gitlab_group  TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE course_owners (
+    course_id TEXT NOT NULL REFERENCES courses (id),
+    username  TEXT NOT NULL,
+    PRIMARY KEY (course_id, username)
+);
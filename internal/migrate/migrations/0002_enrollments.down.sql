@@ -0,0 +1,3 @@
+DROP INDEX submissions_course;
+DROP TABLE submissions;
+DROP TABLE enrollments;
@@ -0,0 +1,18 @@
+CREATE TABLE enrollments (
+    course_id  TEXT NOT NULL REFERENCES courses (id),
+    user_id    TEXT NOT NULL REFERENCES users (id),
+    state      TEXT NOT NULL CHECK (state IN ('pending', 'active', 'dropped', 'completed')),
+    updated_at TEXT NOT NULL,
+    PRIMARY KEY (course_id, user_id)
+);
+
+CREATE TABLE submissions (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    course_id    TEXT NOT NULL REFERENCES courses (id),
+    user_id      TEXT NOT NULL REFERENCES users (id),
+    task_id      TEXT NOT NULL,
+    score        INTEGER NOT NULL,
+    submitted_at TEXT NOT NULL
+);
+
+CREATE INDEX submissions_course ON submissions (course_id, user_id);
@@ -0,0 +1,3 @@
+DROP INDEX audit_log_target;
+DROP TABLE audit_log;
+DROP TABLE invites;
@@ -0,0 +1,23 @@
+CREATE TABLE invites (
+    code         TEXT PRIMARY KEY,
+    course_id    TEXT REFERENCES courses (id),
+    namespace_id TEXT NOT NULL REFERENCES namespaces (id),
+    role         TEXT NOT NULL,
+    expires_at   TEXT NOT NULL,
+    max_uses     INTEGER NOT NULL,
+    uses         INTEGER NOT NULL DEFAULT 0,
+    revoked      INTEGER NOT NULL DEFAULT 0,
+    created_by   TEXT NOT NULL,
+    created_at   TEXT NOT NULL
+);
+
+CREATE TABLE audit_log (
+    id      INTEGER PRIMARY KEY AUTOINCREMENT,
+    at      TEXT NOT NULL,
+    actor   TEXT NOT NULL,
+    action  TEXT NOT NULL,
+    target  TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX audit_log_target ON audit_log (target);
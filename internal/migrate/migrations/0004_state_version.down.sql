@@ -0,0 +1 @@
+DROP TABLE state_version;
@@ -0,0 +1,7 @@
+-- Версия содержимого: растет при каждой записи, по ней процессы замечают чужие изменения
+CREATE TABLE state_version (
+    id      INTEGER PRIMARY KEY CHECK (id = 1),
+    version INTEGER NOT NULL
+);
+
+INSERT INTO state_version (id, version) VALUES (1, 0);
@@ -0,0 +1,5 @@
+-- Доска заданий курса хранится целиком, в том виде, в котором ее отдает API
+CREATE TABLE course_boards (
+    course_id TEXT PRIMARY KEY REFERENCES courses (id),
+    data      TEXT NOT NULL -- JSON
+);
@@ -0,0 +1,2 @@
+-- Версия курса для ETag и If-Match, растет при каждом изменении курса
+ALTER TABLE courses ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
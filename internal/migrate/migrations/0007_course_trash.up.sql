@@ -0,0 +1,3 @@
+-- Время мягкого удаления курса, NULL - курс не удален.
+-- Удаленный курс со всеми записями и посылками хранится до окончательной очистки
+ALTER TABLE courses ADD COLUMN deleted_at TEXT;
@@ -0,0 +1,5 @@
+-- Старые slug'и переименованных курсов, запросы по ним перенаправляются на текущий
+CREATE TABLE course_aliases (
+    slug      TEXT PRIMARY KEY,
+    course_id TEXT NOT NULL REFERENCES courses (id)
+);
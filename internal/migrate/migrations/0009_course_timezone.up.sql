@@ -0,0 +1,3 @@
+-- Часовой пояс курса (IANA). По нему считаются начало и конец дней курса
+-- и вводится время дедлайнов; у существующих курсов даты считались в UTC
+ALTER TABLE courses ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
@@ -0,0 +1,3 @@
+-- Посылки по задачам, удаленным из структуры курса с force: хранятся,
+-- но в ведомость не входят
+ALTER TABLE submissions ADD COLUMN archived INTEGER NOT NULL DEFAULT 0;
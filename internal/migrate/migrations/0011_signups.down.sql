@@ -0,0 +1,2 @@
+DROP TABLE signups;
+ALTER TABLE users DROP COLUMN verification_nonce;
@@ -0,0 +1,15 @@
+-- Одноразовый nonce последней ссылки подтверждения почты: ссылки переживают рестарт
+ALTER TABLE users ADD COLUMN verification_nonce TEXT NOT NULL DEFAULT '';
+
+-- Регистрации по инвайтам. Репозиторий создается по ним после подтверждения почты
+CREATE TABLE signups (
+    id                    TEXT PRIMARY KEY,
+    user_id               TEXT NOT NULL REFERENCES users (id),
+    invite_code           TEXT NOT NULL,
+    course_id             TEXT NOT NULL DEFAULT '',
+    namespace_id          TEXT NOT NULL,
+    job_id                TEXT NOT NULL DEFAULT '',
+    job_error             TEXT NOT NULL DEFAULT '',
+    awaiting_verification INTEGER NOT NULL DEFAULT 0,
+    created_at            TEXT NOT NULL
+);
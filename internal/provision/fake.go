@@ -0,0 +1,33 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Fake - VCS для локальной разработки и тестов, ничего не создает по-настоящему
+type Fake struct {
+	BaseURL string
+	Err     error
+
+	mu      sync.Mutex
+	Created []string
+}
+
+func (f *Fake) CreateRepository(_ context.Context, group, name, _ string) (string, error) {
+	if f.Err != nil {
+		return "", f.Err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	url := fmt.Sprintf("%s/%s/%s.git", f.BaseURL, group, name)
+	f.Created = append(f.Created, url)
+	return url, nil
+}
+
+func (f *Fake) Check(context.Context) error {
+	return f.Err
+}
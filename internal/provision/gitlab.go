@@ -0,0 +1,149 @@
+package provision
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// GitLab - VCS поверх GitLab REST API v4
+type GitLab struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// gitlabTimeout ограничивает один запрос к GitLab, чтобы зависший сервер
+// не держал воркер провижининга и остановку сервера
+const gitlabTimeout = 30 * time.Second
+
+func NewGitLab(baseURL, token string, client *http.Client) *GitLab {
+	if client == nil {
+		client = &http.Client{}
+	}
+	if client.Timeout == 0 {
+		client.Timeout = gitlabTimeout
+	}
+	if client.Transport == nil {
+		client.Transport = http.DefaultTransport
+	}
+	// Клиентские спаны и traceparent в исходящих запросах
+	client.Transport = otelhttp.NewTransport(client.Transport)
+
+	return &GitLab{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		client:  client,
+	}
+}
+
+type gitlabGroup struct {
+	ID int `json:"id"`
+}
+
+type gitlabProject struct {
+	SSHURL string `json:"ssh_url_to_repo"`
+}
+
+type gitlabCreateProject struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	NamespaceID int    `json:"namespace_id"`
+	ImportURL   string `json:"import_url,omitempty"`
+}
+
+// CreateRepository создает проект name в группе group из шаблона template
+func (g *GitLab) CreateRepository(ctx context.Context, group, name, template string) (string, error) {
+	var grp gitlabGroup
+	if err := g.do(ctx, http.MethodGet, "/groups/"+url.PathEscape(group), nil, &grp); err != nil {
+		return "", fmt.Errorf("lookup group %q: %w", group, err)
+	}
+
+	importURL, err := templateImportURL(template)
+	if err != nil {
+		return "", err
+	}
+
+	var project gitlabProject
+	body := gitlabCreateProject{Name: name, Path: name, NamespaceID: grp.ID, ImportURL: importURL}
+	if err := g.do(ctx, http.MethodPost, "/projects", body, &project); err != nil {
+		return "", fmt.Errorf("create project %s/%s: %w", group, name, err)
+	}
+
+	return project.SSHURL, nil
+}
+
+// templateImportURL приводит адрес шаблона к https: GitLab импортирует
+// проекты только по http(s), а в курсах шаблоны обычно заданы ssh-адресом
+func templateImportURL(template string) (string, error) {
+	if template == "" {
+		return "", nil
+	}
+
+	// git@host:group/repo.git
+	if !strings.Contains(template, "://") {
+		at := strings.Index(template, "@")
+		host, path, ok := strings.Cut(template[at+1:], ":")
+		if !ok || host == "" || path == "" {
+			return "", fmt.Errorf("unsupported repository template %q", template)
+		}
+		return "https://" + host + "/" + strings.TrimLeft(path, "/"), nil
+	}
+
+	u, err := url.Parse(template)
+	if err != nil {
+		return "", fmt.Errorf("parse repository template: %w", err)
+	}
+	switch u.Scheme {
+	case "https", "http":
+		return template, nil
+	case "ssh":
+		// порт ssh к https не относится
+		return (&url.URL{Scheme: "https", Host: u.Hostname(), Path: u.Path}).String(), nil
+	default:
+		return "", fmt.Errorf("unsupported repository template %q", template)
+	}
+}
+
+// Check проверяет доступность GitLab и валидность токена
+func (g *GitLab) Check(ctx context.Context) error {
+	var version struct {
+		Version string `json:"version"`
+	}
+	return g.do(ctx, http.MethodGet, "/version", nil, &version)
+}
+
+func (g *GitLab) do(ctx context.Context, method, path string, in, out any) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.baseURL+"/api/v4"+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", g.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("gitlab responded with %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
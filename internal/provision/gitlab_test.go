@@ -0,0 +1,135 @@
+package provision
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestGitLab_CreateRepository(t *testing.T) {
+	var created gitlabCreateProject
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == "/api/v4/groups/courses%2Falgorithms":
+			json.NewEncoder(w).Encode(map[string]int{"id": 42})
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v4/projects":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]string{"ssh_url_to_repo": "git@gitlab.local:courses/algorithms/alex.git"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	gl := NewGitLab(srv.URL+"/", "secret", srv.Client())
+
+	url, err := gl.CreateRepository(context.Background(), "courses/algorithms", "alex", "https://gitlab.local/templates/algorithms.git")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if url != "git@gitlab.local:courses/algorithms/alex.git" {
+		t.Errorf("unexpected url %q", url)
+	}
+	if created.NamespaceID != 42 || created.Path != "alex" || created.ImportURL != "https://gitlab.local/templates/algorithms.git" {
+		t.Errorf("unexpected project request: %+v", created)
+	}
+}
+
+func TestTemplateImportURL(t *testing.T) {
+	cases := map[string]string{
+		"":                                     "",
+		"https://gitlab.local/templates/a.git": "https://gitlab.local/templates/a.git",
+		"git@gitlab.local:algorithms-template.git": "https://gitlab.local/algorithms-template.git",
+		"ssh://git@gitlab.local:2222/group/a.git":  "https://gitlab.local/group/a.git",
+	}
+	for template, want := range cases {
+		got, err := templateImportURL(template)
+		if err != nil || got != want {
+			t.Errorf("templateImportURL(%q) = %q, %v; want %q", template, got, err, want)
+		}
+	}
+
+	if _, err := templateImportURL("ftp://gitlab.local/a.git"); err == nil {
+		t.Error("expected error for unsupported scheme")
+	}
+}
+
+func TestGitLab_DefaultTimeout(t *testing.T) {
+	if gl := NewGitLab("https://gitlab.local", "secret", nil); gl.client.Timeout != gitlabTimeout {
+		t.Fatalf("expected default timeout, got %v", gl.client.Timeout)
+	}
+}
+
+func TestGitLab_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	gl := NewGitLab(srv.URL, "secret", srv.Client())
+
+	if _, err := gl.CreateRepository(context.Background(), "missing", "alex", ""); err == nil {
+		t.Fatal("expected error for missing group")
+	}
+}
+
+func TestGitLab_Check(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v4/version" || r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"version": "17.0.0"})
+	}))
+	defer srv.Close()
+
+	if err := NewGitLab(srv.URL, "secret", srv.Client()).Check(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := NewGitLab(srv.URL, "wrong", srv.Client()).Check(context.Background()); err == nil {
+		t.Fatal("expected error for invalid token")
+	}
+}
+
+func TestGitLab_PropagatesTraceContext(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(tracetest.NewInMemoryExporter()))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	traceparent := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent <- r.Header.Get("traceparent")
+		json.NewEncoder(w).Encode(map[string]string{"version": "17.0.0"})
+	}))
+	defer srv.Close()
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	if err := NewGitLab(srv.URL, "secret", nil).Check(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	header := <-traceparent
+	if !strings.Contains(header, span.SpanContext().TraceID().String()) {
+		t.Errorf("traceparent %q does not carry trace %s", header, span.SpanContext().TraceID())
+	}
+}
@@ -0,0 +1,201 @@
+package provision
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"fcstask-backend/internal/tracing"
+)
+
+// tracerName - имя трейсера спанов пакета
+const tracerName = "fcstask-backend/internal/provision"
+
+// Состояния задачи провижининга
+const (
+	StatePending = "pending"
+	StateRunning = "running"
+	StateDone    = "done"
+	StateFailed  = "failed"
+)
+
+// ErrQueueFull - очередь переполнена, задачу стоит повторить позже
+var ErrQueueFull = errors.New("provisioning queue is full")
+
+// VCS - провайдер, в котором создаются репозитории студентов
+type VCS interface {
+	CreateRepository(ctx context.Context, group, name, template string) (string, error)
+}
+
+// Job - задача на создание репозитория студента
+type Job struct {
+	Group    string // группа курса в VCS
+	Name     string // имя репозитория, обычно логин студента
+	Template string // репозиторий-шаблон курса
+}
+
+// Status - текущее состояние задачи
+type Status struct {
+	State     string    `json:"state"`
+	RepoURL   string    `json:"repoUrl,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Attempts  int       `json:"attempts"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type queuedJob struct {
+	id     string
+	job    Job
+	parent trace.SpanContext // спан запроса, поставившего задачу
+}
+
+// Queue - in-process очередь задач с ограниченным числом попыток
+type Queue struct {
+	vcs         VCS
+	jobs        chan queuedJob
+	maxAttempts int
+	retryDelay  time.Duration
+
+	running  atomic.Bool
+	onFailed func(job Job, err error)
+
+	mu       sync.RWMutex
+	run      string // префикс идентификаторов: сохраненные до рестарта не совпадут с новыми
+	seq      int
+	statuses map[string]Status
+}
+
+func NewQueue(vcs VCS, size, maxAttempts int, retryDelay time.Duration) *Queue {
+	run := make([]byte, 4)
+	rand.Read(run)
+
+	return &Queue{
+		run:         hex.EncodeToString(run),
+		vcs:         vcs,
+		jobs:        make(chan queuedJob, size),
+		maxAttempts: maxAttempts,
+		retryDelay:  retryDelay,
+		statuses:    make(map[string]Status),
+	}
+}
+
+// Enqueue ставит задачу в очередь и возвращает ее идентификатор.
+// Спан из ctx попадает в ссылку (link) спана обработки задачи.
+// Если очередь переполнена, задача не заводится и статуса у нее нет
+func (q *Queue) Enqueue(ctx context.Context, job Job) (string, error) {
+	// Отправка не блокирует, а mu не дает обработчику обновить статус раньше pending
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	id := fmt.Sprintf("job-%s-%d", q.run, q.seq+1)
+	select {
+	case q.jobs <- queuedJob{id: id, job: job, parent: trace.SpanContextFromContext(ctx)}:
+		q.seq++
+		q.statuses[id] = Status{State: StatePending, UpdatedAt: time.Now().UTC()}
+		return id, nil
+	default:
+		return "", ErrQueueFull
+	}
+}
+
+// Status возвращает состояние задачи
+func (q *Queue) Status(id string) (Status, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	status, ok := q.statuses[id]
+	return status, ok
+}
+
+// OnFailed задает обработчик задач, упавших после всех попыток. Вызывать до Run
+func (q *Queue) OnFailed(fn func(job Job, err error)) {
+	q.onFailed = fn
+}
+
+// Check сообщает о проблемах очереди: обработчик не запущен или очередь переполнена
+func (q *Queue) Check(context.Context) error {
+	if !q.running.Load() {
+		return errors.New("provisioning worker is not running")
+	}
+	if len(q.jobs) == cap(q.jobs) {
+		return ErrQueueFull
+	}
+	return nil
+}
+
+// Run обрабатывает задачи, пока не отменен ctx
+func (q *Queue) Run(ctx context.Context) {
+	q.running.Store(true)
+	defer q.running.Store(false)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item := <-q.jobs:
+			q.process(ctx, item)
+		}
+	}
+}
+
+func (q *Queue) process(ctx context.Context, item queuedJob) {
+	ctx, span := tracing.Tracer(tracerName).Start(ctx, "provision.job",
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.Link{SpanContext: item.parent}),
+		trace.WithAttributes(
+			attribute.String("job.id", item.id),
+			attribute.String("vcs.group", item.job.Group),
+			attribute.String("vcs.repository", item.job.Name),
+		),
+	)
+	defer span.End()
+
+	var err error
+
+	for attempt := 1; attempt <= q.maxAttempts; attempt++ {
+		q.setStatus(item.id, Status{State: StateRunning, Attempts: attempt})
+
+		var url string
+		url, err = q.vcs.CreateRepository(ctx, item.job.Group, item.job.Name, item.job.Template)
+		if err == nil {
+			q.setStatus(item.id, Status{State: StateDone, RepoURL: url, Attempts: attempt})
+			span.SetAttributes(attribute.Int("job.attempts", attempt))
+			return
+		}
+		span.AddEvent("attempt failed", trace.WithAttributes(attribute.Int("attempt", attempt), attribute.String("error", err.Error())))
+
+		if attempt == q.maxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			q.setStatus(item.id, Status{State: StateFailed, Error: ctx.Err().Error(), Attempts: attempt})
+			return
+		case <-time.After(q.retryDelay):
+		}
+	}
+
+	q.setStatus(item.id, Status{State: StateFailed, Error: err.Error(), Attempts: q.maxAttempts})
+	span.SetStatus(codes.Error, err.Error())
+	if q.onFailed != nil {
+		q.onFailed(item.job, err)
+	}
+}
+
+func (q *Queue) setStatus(id string, status Status) {
+	status.UpdatedAt = time.Now().UTC()
+
+	q.mu.Lock()
+	q.statuses[id] = status
+	q.mu.Unlock()
+}
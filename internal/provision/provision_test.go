@@ -0,0 +1,158 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type flakyVCS struct {
+	failures int
+	calls    int
+}
+
+func (f *flakyVCS) CreateRepository(_ context.Context, group, name, _ string) (string, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return "", errors.New("gitlab is down")
+	}
+	return "git@test:" + group + "/" + name + ".git", nil
+}
+
+func waitState(t *testing.T, q *Queue, id string, state string) Status {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if status, ok := q.Status(id); ok && status.State == state {
+			return status
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	status, _ := q.Status(id)
+	t.Fatalf("job %s did not reach %q, last status %+v", id, state, status)
+	return Status{}
+}
+
+func TestQueue_Success(t *testing.T) {
+	vcs := &Fake{BaseURL: "git@gitlab.local:"}
+	q := NewQueue(vcs, 10, 3, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	id, err := q.Enqueue(context.Background(), Job{Group: "algorithms", Name: "alex"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	status := waitState(t, q, id, StateDone)
+	if status.RepoURL != "git@gitlab.local:/algorithms/alex.git" || status.Attempts != 1 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+}
+
+func TestQueue_RetriesThenSucceeds(t *testing.T) {
+	vcs := &flakyVCS{failures: 2}
+	q := NewQueue(vcs, 10, 3, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	id, _ := q.Enqueue(context.Background(), Job{Group: "g", Name: "n"})
+
+	status := waitState(t, q, id, StateDone)
+	if status.Attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", status.Attempts)
+	}
+}
+
+func TestQueue_FailsAfterMaxAttempts(t *testing.T) {
+	vcs := &flakyVCS{failures: 10}
+	q := NewQueue(vcs, 10, 2, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	id, _ := q.Enqueue(context.Background(), Job{Group: "g", Name: "n"})
+
+	status := waitState(t, q, id, StateFailed)
+	if status.Error != "gitlab is down" || vcs.calls != 2 {
+		t.Fatalf("unexpected status: %+v, calls %d", status, vcs.calls)
+	}
+}
+
+func TestQueue_Full(t *testing.T) {
+	q := NewQueue(&Fake{}, 1, 1, time.Millisecond)
+
+	if _, err := q.Enqueue(context.Background(), Job{Name: "a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := q.Enqueue(context.Background(), Job{Name: "b"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	// отклоненная задача не оставляет статуса
+	if _, ok := q.Status("job-" + q.run + "-2"); ok || len(q.statuses) != 1 {
+		t.Fatalf("rejected job must not have a status: %v", q.statuses)
+	}
+
+	<-q.jobs
+	if id, err := q.Enqueue(context.Background(), Job{Name: "b"}); err != nil || id != "job-"+q.run+"-2" {
+		t.Fatalf("expected the second job, got %q, %v", id, err)
+	}
+
+	// после рестарта идентификаторы не повторяются
+	if other := NewQueue(&Fake{}, 1, 1, time.Millisecond); other.run == q.run {
+		t.Error("queues must not share job IDs")
+	}
+}
+
+func TestQueue_Check(t *testing.T) {
+	q := NewQueue(&Fake{}, 1, 1, time.Millisecond)
+
+	if err := q.Check(context.Background()); err == nil {
+		t.Fatal("queue without worker must be unhealthy")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	deadline := time.Now().Add(time.Second)
+	for q.Check(context.Background()) != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("queue is still unhealthy: %v", q.Check(context.Background()))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestQueue_OnFailed(t *testing.T) {
+	q := NewQueue(&Fake{Err: errors.New("gitlab is down")}, 1, 1, time.Millisecond)
+
+	failed := make(chan Job, 1)
+	q.OnFailed(func(job Job, err error) { failed <- job })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	if _, err := q.Enqueue(context.Background(), Job{Name: "alex"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case job := <-failed:
+		if job.Name != "alex" {
+			t.Errorf("unexpected job: %+v", job)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnFailed was not called")
+	}
+}
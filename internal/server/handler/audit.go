@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AuditEntry - запись журнала административных действий
+type AuditEntry struct {
+	At      time.Time         `json:"at"`
+	Actor   string            `json:"actor"`
+	Action  string            `json:"action"`
+	Target  string            `json:"target"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// In-memory storage
+var (
+	auditLog []AuditEntry
+	auditMu  sync.RWMutex
+)
+
+func recordAudit(actor User, action, target string, details map[string]string) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	auditLog = append(auditLog, AuditEntry{
+		At:      time.Now().UTC(),
+		Actor:   actor.Username,
+		Action:  action,
+		Target:  target,
+		Details: details,
+	})
+}
+
+// GET /api/audit?target=...
+func GetAuditHandler(c echo.Context) error {
+	actor, ok := currentUser(c)
+	if !ok {
+		return writeError(c, errUnauthorized)
+	}
+	if actor.Role != RoleInstanceAdmin {
+		return writeError(c, errForbidden)
+	}
+
+	target := c.QueryParam("target")
+
+	auditMu.RLock()
+	defer auditMu.RUnlock()
+
+	entries := make([]AuditEntry, 0, len(auditLog))
+	for _, entry := range auditLog {
+		if target == "" || entry.Target == target {
+			entries = append(entries, entry)
+		}
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"encoding/json"
+	"io"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,97 +14,87 @@ import (
 
 // Course - модель курса
 type Course struct {
-	ID           string `json:"id"`
-	Name         string `json:"name"`
-	Status       string `json:"status"` // Просто string, без кастомного типа
-	StartDate    string `json:"startDate"`
-	EndDate      string `json:"endDate"`
-	RepoTemplate string `json:"repoTemplate"`
-	Description  string `json:"description"`
-	URL          string `json:"url"`
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Status       string   `json:"status"` // Просто string, без кастомного типа
+	StartDate    Date     `json:"startDate"`
+	EndDate      Date     `json:"endDate"`
+	Timezone     string   `json:"timezone"` // IANA, по нему считаются начало и конец дней курса
+	RepoTemplate string   `json:"repoTemplate"`
+	Description  string   `json:"description"`
+	URL          string   `json:"url"`
+	NamespaceID  string   `json:"namespaceId,omitempty"`
+	GitlabGroup  string   `json:"gitlabGroup,omitempty"`
+	Owners       []string `json:"owners"`
+
+	// Растет при каждом изменении, отдается как ETag
+	Version int `json:"version"`
+
+	// Время удаления, только у курсов в корзине
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// courseView - курс в ответе API: к датам добавлены моменты начала первого
+// и конца последнего дня курса в UTC и по местному времени курса
+type courseView struct {
+	plainCourse
+
+	StartsAt      time.Time `json:"startsAt,omitzero"`
+	StartsAtLocal string    `json:"startsAtLocal,omitempty"`
+	EndsAt        time.Time `json:"endsAt,omitzero"`
+	EndsAtLocal   string    `json:"endsAtLocal,omitempty"`
+}
+
+// plainCourse - Course без MarshalJSON
+type plainCourse Course
+
+func (course Course) view() courseView {
+	view := courseView{plainCourse: plainCourse(course)}
+	if !course.StartDate.IsZero() && !course.EndDate.IsZero() {
+		loc := courseLocation(course)
+		view.StartsAt = course.StartDate.Start(loc).UTC()
+		view.StartsAtLocal = formatLocal(view.StartsAt, loc)
+		view.EndsAt = course.EndDate.End(loc).UTC()
+		view.EndsAtLocal = formatLocal(view.EndsAt, loc)
+	}
+	return view
+}
+
+func (course Course) MarshalJSON() ([]byte, error) {
+	return json.Marshal(course.view())
 }
 
 // PostCourseRequest - тело запроса на создание курса
 type PostCourseRequest struct {
-	Name         string `json:"name"`
-	Slug         string `json:"slug"`
-	Status       string `json:"status"`
-	StartDate    string `json:"startDate"`
-	EndDate      string `json:"endDate"`
-	RepoTemplate string `json:"repoTemplate"`
-	Description  string `json:"description"`
+	Name         string   `json:"name"`
+	Slug         string   `json:"slug"`
+	Status       string   `json:"status"`
+	StartDate    string   `json:"startDate"`
+	EndDate      string   `json:"endDate"`
+	Timezone     string   `json:"timezone"` // пусто - UTC
+	RepoTemplate string   `json:"repoTemplate"`
+	Description  string   `json:"description"`
+	NamespaceID  string   `json:"namespaceId"`
+	Owners       []string `json:"owners"`
 }
 
-// ValidationError - ошибка валидации
+// MoveCourseRequest - тело запроса на перенос курса в другой namespace
+type MoveCourseRequest struct {
+	NamespaceID string `json:"namespaceId"`
+}
+
+// ValidationError - ошибка валидации. Code и Params - для перевода сообщения
+// на клиенте, у ошибок без кода есть только Message
 type ValidationError struct {
-	Field   string `json:"field"`
-	Message string `json:"message"`
+	Field   string            `json:"field"`
+	Code    string            `json:"code,omitempty"`
+	Message string            `json:"message"`
+	Params  map[string]string `json:"params,omitempty"`
 }
 
 // In-memory storage
 var (
-	courseDB = map[string]Course{
-		"algorithms": {
-			ID:           "algorithms",
-			Name:         "Algorithms 101",
-			Status:       "in_progress",
-			StartDate:    "2024-10-01",
-			EndDate:      "2024-12-20",
-			RepoTemplate: "git@gitlab.local/algorithms-template.git",
-			Description:  "Основы алгоритмов и структур данных",
-			URL:          "/course/algorithms",
-		},
-		"mlops": {
-			ID:           "mlops",
-			Name:         "MLOps Studio",
-			Status:       "all_tasks_issued",
-			StartDate:    "2024-09-01",
-			EndDate:      "2024-11-30",
-			RepoTemplate: "git@gitlab.local/mlops-template.git",
-			Description:  "Продвинутые практики MLOps",
-			URL:          "/course/mlops",
-		},
-		"rust": {
-			ID:           "rust",
-			Name:         "Rust Core",
-			Status:       "created",
-			StartDate:    "2024-10-15",
-			EndDate:      "2025-01-15",
-			RepoTemplate: "git@gitlab.local/rust-template.git",
-			Description:  "Основы системного программирования на Rust",
-			URL:          "/course/rust",
-		},
-		"golang": {
-			ID:           "golang",
-			Name:         "Go Lab",
-			Status:       "finished",
-			StartDate:    "2024-08-01",
-			EndDate:      "2024-10-31",
-			RepoTemplate: "git@gitlab.local/golang-template.git",
-			Description:  "Практикум по языку Go",
-			URL:          "/course/golang",
-		},
-		"advanced-cpp": {
-			ID:           "advanced-cpp",
-			Name:         "Advanced C++",
-			Status:       "in_progress",
-			StartDate:    "2024-10-01",
-			EndDate:      "2024-12-20",
-			RepoTemplate: "git@gitlab.local/advanced-cpp-template.git",
-			Description:  "Продвинутые концепции C++",
-			URL:          "/course/advanced-cpp",
-		},
-		"advanced-python": {
-			ID:           "advanced-python",
-			Name:         "Advanced Python",
-			Status:       "created",
-			StartDate:    "2024-11-01",
-			EndDate:      "2025-02-28",
-			RepoTemplate: "git@gitlab.local/advanced-python-template.git",
-			Description:  "Продвинутый анализ данных на Python",
-			URL:          "/course/advanced-python",
-		},
-	}
+	courseDB = map[string]Course{}
 
 	courseMu sync.RWMutex
 )
@@ -108,196 +102,534 @@ var (
 // Вспомогательные функции валидации
 
 func isValidCourseStatus(status string) bool {
-	valid := map[string]bool{
-		"created":          true,
-		"hidden":           true,
-		"in_progress":      true,
-		"all_tasks_issued": true,
-		"doreshka":         true,
-		"finished":         true,
-	}
-	return valid[status]
+	return slices.Contains(courseStatusOrder, status)
 }
 
 func isValidDate(date string) bool {
-	_, err := time.Parse("2006-01-02", date)
+	_, err := ParseDate(date)
 	return err == nil
 }
 
 func isValidDateRange(start, end string) bool {
-	startDate, _ := time.Parse("2006-01-02", start)
-	endDate, _ := time.Parse("2006-01-02", end)
-	return endDate.After(startDate)
+	startDate, _ := ParseDate(start)
+	endDate, _ := ParseDate(end)
+	return endDate.Compare(startDate) > 0
 }
 
-// Validate проверяет корректность запроса
+// Validate проверяет запрос и возвращает ошибки всех полей сразу. Те же правила
+// действуют при создании, замене, патче, импорте структуры, загрузке фикстур и в CLI
 func (req *PostCourseRequest) Validate() []ValidationError {
-	var errs []ValidationError
+	var v validator
+	req.checkFields(&v)
 
-	if req.Name == "" {
-		errs = append(errs, ValidationError{"name", "name is required"})
+	for _, owner := range req.Owners {
+		v.check("owners", owner, required, isKnownUser)
 	}
 
-	if req.Slug == "" {
-		errs = append(errs, ValidationError{"slug", "slug is required"})
+	return v.errs
+}
+
+// checkFields - правила полей курса без обращения к пользователям инстанса
+func (req *PostCourseRequest) checkFields(v *validator) {
+	v.check("name", req.Name, required, maxLength(maxCourseNameLength))
+	v.check("slug", req.Slug, slugRules...)
+	v.check("status", req.Status, required, oneOf(courseStatusOrder))
+
+	startValid := v.check("startDate", req.StartDate, required, isDate)
+	endValid := v.check("endDate", req.EndDate, required, isDate)
+	if startValid && endValid && !isValidDateRange(req.StartDate, req.EndDate) {
+		v.add("dateRange", CodeDateRange, nil)
 	}
 
-	if req.Status == "" {
-		errs = append(errs, ValidationError{"status", "status is required"})
-	} else if !isValidCourseStatus(req.Status) {
-		errs = append(errs, ValidationError{"status", "invalid status value"})
+	v.check("timezone", req.Timezone, isTimezone)
+	v.check("repoTemplate", req.RepoTemplate, required, maxLength(maxRepoTemplateLength), isRepoURL)
+	v.check("description", req.Description, maxLength(maxCourseDescriptionLength))
+	v.check("namespaceId", req.NamespaceID, required)
+}
+
+// canAdministerNamespace - создавать курсы в namespace могут его админы и инстанс-админ
+func canAdministerNamespace(actor User, namespaceID string) bool {
+	return actor.Role == RoleInstanceAdmin || namespaceRole(namespaceID, actor.ID) == RoleNamespaceAdmin
+}
+
+// canEditCourse - менять курс могут его владельцы и админы namespace
+func canEditCourse(actor User, course Course) bool {
+	return isCourseOwner(course, actor) || canAdministerNamespace(actor, course.NamespaceID)
+}
+
+// canSeeCourse - правила видимости курса: владельцы, админы и program manager'ы
+// его namespace и инстанс-админ видят курс в любом статусе, студент - только
+// нескрытый курс, на который записан (отчисленный курс больше не видит)
+func canSeeCourse(actor User, course Course) bool {
+	if canTeachCourse(actor, course) {
+		return true
 	}
+	if course.Status == "hidden" {
+		return false
+	}
+
+	switch enrollmentState(course.ID, actor.ID) {
+	case EnrollmentActive, EnrollmentPending, EnrollmentCompleted:
+		return true
+	default:
+		return false
+	}
+}
+
+// visibleCourse возвращает курс, если actor может его видеть. Невидимый курс
+// неотличим от несуществующего, чтобы прямая ссылка не раскрывала скрытые курсы
+func visibleCourse(actor User, courseID string) (Course, error) {
+	course, err := getCourse(courseID)
+	if err != nil {
+		return Course{}, err
+	}
+	if !canSeeCourse(actor, course) {
+		return Course{}, newAPIError(http.StatusNotFound, "course not found")
+	}
+	return course, nil
+}
+
+// ListCourses возвращает все курсы по фильтрам статуса и namespace, пустой фильтр не ограничивает
+func ListCourses(statusFilter, namespaceFilter string) []Course {
+	q := CourseQuery{NamespaceID: namespaceFilter}
+	if statusFilter != "" {
+		q.Statuses = []string{statusFilter}
+	}
+
+	page, err := QueryCourses(q)
+	if err != nil {
+		return []Course{}
+	}
+	return page.Courses
+}
+
+// CreateCourse создает курс; если владельцы не указаны, владельцем становится автор
+func CreateCourse(actor User, req PostCourseRequest) (Course, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return Course{}, validationFailed(errs)
+	}
+
+	if _, err := getNamespace(req.NamespaceID); err != nil {
+		return Course{}, err
+	}
+
+	if !canAdministerNamespace(actor, req.NamespaceID) {
+		return Course{}, newAPIError(http.StatusForbidden, "only namespace admins can create courses")
+	}
+
+	owners := req.Owners
+	if len(owners) == 0 {
+		owners = []string{actor.Username}
+	}
+
+	startDate, _ := ParseDate(req.StartDate)
+	endDate, _ := ParseDate(req.EndDate)
 
-	if req.StartDate == "" {
-		errs = append(errs, ValidationError{"startDate", "startDate is required"})
-	} else if !isValidDate(req.StartDate) {
-		errs = append(errs, ValidationError{"startDate", "startDate must be in format YYYY-MM-DD"})
+	course := Course{
+		ID:           req.Slug,
+		Name:         req.Name,
+		Status:       req.Status,
+		StartDate:    startDate,
+		EndDate:      endDate,
+		Timezone:     timezoneName(req.Timezone),
+		RepoTemplate: req.RepoTemplate,
+		Description:  req.Description,
+		URL:          "/course/" + req.Slug,
+		NamespaceID:  req.NamespaceID,
+		GitlabGroup:  req.Slug,
+		Owners:       owners,
+		Version:      1,
 	}
 
-	if req.EndDate == "" {
-		errs = append(errs, ValidationError{"endDate", "endDate is required"})
-	} else if !isValidDate(req.EndDate) {
-		errs = append(errs, ValidationError{"endDate", "endDate must be in format YYYY-MM-DD"})
+	courseMu.Lock()
+	defer courseMu.Unlock()
+
+	if err := slugConflict(req.Slug, ""); err != nil {
+		return Course{}, err
 	}
+	courseDB[req.Slug] = course
+
+	return course, nil
+}
 
-	if req.StartDate != "" && req.EndDate != "" && !isValidDateRange(req.StartDate, req.EndDate) {
-		errs = append(errs, ValidationError{"dateRange", "endDate must be after startDate"})
+// SetCourseStatus меняет статус курса. Доступно владельцам курса и админам namespace,
+// смена попадает в аудит. version - ожидаемая версия курса, 0 - без проверки
+func SetCourseStatus(actor User, courseID string, version int, status string) (Course, error) {
+	var v validator
+	if !v.check("status", status, required, oneOf(courseStatusOrder)) {
+		return Course{}, validationFailed(v.errs)
 	}
 
-	if req.RepoTemplate == "" {
-		errs = append(errs, ValidationError{"repoTemplate", "repoTemplate is required"})
+	if _, err := editableCourse(actor, courseID); err != nil {
+		return Course{}, err
 	}
 
-	if req.Description == "" {
-		errs = append(errs, ValidationError{"description", "description is required"})
+	courseMu.Lock()
+	course, exists := courseDB[courseID]
+	if !exists {
+		courseMu.Unlock()
+		return Course{}, newAPIError(http.StatusNotFound, "course not found")
+	}
+	if version != 0 && course.Version != version {
+		courseMu.Unlock()
+		return Course{}, errPreconditionFailed
 	}
+	from := course.Status
+	course.Status = status
+	course.Version++
+	courseDB[courseID] = course
+	courseMu.Unlock()
 
-	return errs
+	recordAudit(actor, "course.status", courseID, map[string]string{"from": from, "to": status})
+
+	return course, nil
 }
 
-// Хендлеры
+// MoveCourse переносит курс в другой namespace вместе с его инвайтами. Доступно только инстанс-админам,
+// перенос попадает в аудит. version - ожидаемая версия курса, 0 - без проверки
+func MoveCourse(actor User, courseID string, version int, namespaceID string) (Course, error) {
+	if actor.Role != RoleInstanceAdmin {
+		return Course{}, newAPIError(http.StatusForbidden, "only instance admins can move courses")
+	}
 
-func GetCoursesHandler(c echo.Context) error {
-	statusFilter := c.QueryParam("status")
+	if namespaceID == "" {
+		return Course{}, validationFailed([]ValidationError{newValidationError("namespaceId", CodeRequired, nil)})
+	}
 
-	courseMu.RLock()
-	defer courseMu.RUnlock()
+	if _, err := getNamespace(namespaceID); err != nil {
+		return Course{}, err
+	}
 
-	courses := make([]Course, 0, len(courseDB))
-	for _, course := range courseDB {
-		if statusFilter == "" || course.Status == statusFilter {
-			courses = append(courses, course)
+	courseMu.Lock()
+	course, exists := courseDB[courseID]
+	if !exists {
+		courseMu.Unlock()
+		return Course{}, newAPIError(http.StatusNotFound, "course not found")
+	}
+	if version != 0 && course.Version != version {
+		courseMu.Unlock()
+		return Course{}, errPreconditionFailed
+	}
+	from := course.NamespaceID
+	course.NamespaceID = namespaceID
+	course.Version++
+	courseDB[courseID] = course
+
+	// Инвайт на курс выдает роль в namespace курса, поэтому переезжает вместе с ним
+	inviteMu.Lock()
+	for code, invite := range inviteDB {
+		if invite.CourseID == courseID {
+			invite.NamespaceID = namespaceID
+			inviteDB[code] = invite
 		}
 	}
+	inviteMu.Unlock()
+	courseMu.Unlock()
+
+	recordAudit(actor, "course.move", courseID, map[string]string{"from": from, "to": namespaceID})
 
-	return c.JSON(http.StatusOK, courses)
+	return course, nil
 }
 
-func GetCourseHandler(c echo.Context) error {
-	courseID := c.Param("courseId")
+// courseDocument - курс в виде тела PUT, к нему применяется PATCH
+func courseDocument(course Course) PostCourseRequest {
+	return PostCourseRequest{
+		Name:         course.Name,
+		Slug:         course.ID,
+		Status:       course.Status,
+		StartDate:    course.StartDate.String(),
+		EndDate:      course.EndDate.String(),
+		Timezone:     course.Timezone,
+		RepoTemplate: course.RepoTemplate,
+		Description:  course.Description,
+		NamespaceID:  course.NamespaceID,
+		Owners:       course.Owners,
+	}
+}
 
-	courseMu.RLock()
-	course, exists := courseDB[courseID]
-	courseMu.RUnlock()
+// ReplaceCourse заменяет все редактируемые поля курса телом req: незаполненное
+// поле очищается, а не остается прежним. namespaceId игнорируется - для переноса
+// есть MoveCourseHandler, для смены slug'а - RenameCourse. Доступно владельцам
+// курса и админам namespace, смена владельцев и статуса попадает в аудит.
+// version - ожидаемая версия курса, 0 - без проверки
+func ReplaceCourse(actor User, courseID string, version int, req PostCourseRequest) (Course, error) {
+	course, err := editableCourse(actor, courseID)
+	if err != nil {
+		return Course{}, err
+	}
+
+	req.NamespaceID = course.NamespaceID
+	errs := req.Validate()
+	if course.NamespaceID == "" {
+		// курс из фикстур может быть без namespace, его тоже можно редактировать
+		errs = slices.DeleteFunc(errs, func(e ValidationError) bool { return e.Field == "namespaceId" })
+	}
+	if req.Slug == courseID {
+		// slug, выданный до появления правил формата, остается рабочим
+		errs = slices.DeleteFunc(errs, func(e ValidationError) bool { return e.Field == "slug" })
+	} else if req.Slug != "" {
+		errs = append(errs, newValidationError("slug", CodeSlugImmutable, nil))
+	}
+	if req.RepoTemplate == course.RepoTemplate {
+		// как и со slug'ом: шаблон, сохраненный до проверки адресов, можно не менять
+		errs = slices.DeleteFunc(errs, func(e ValidationError) bool { return e.Code == CodeRepoURL })
+	}
+	if len(errs) > 0 {
+		return Course{}, validationFailed(errs)
+	}
 
+	owners := req.Owners
+	if owners == nil {
+		owners = []string{}
+	}
+	startDate, _ := ParseDate(req.StartDate)
+	endDate, _ := ParseDate(req.EndDate)
+
+	courseMu.Lock()
+	course, exists := courseDB[courseID]
 	if !exists {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "course not found"})
+		courseMu.Unlock()
+		return Course{}, newAPIError(http.StatusNotFound, "course not found")
+	}
+	if version != 0 && course.Version != version {
+		courseMu.Unlock()
+		return Course{}, errPreconditionFailed
 	}
+	before := course
+	course.Name = req.Name
+	course.Status = req.Status
+	course.StartDate = startDate
+	course.EndDate = endDate
+	course.Timezone = timezoneName(req.Timezone)
+	course.RepoTemplate = req.RepoTemplate
+	course.Description = req.Description
+	course.Owners = owners
+	course.Version++
+	courseDB[courseID] = course
+	courseMu.Unlock()
 
-	return c.JSON(http.StatusOK, course)
+	if before.Status != course.Status {
+		recordAudit(actor, "course.status", courseID, map[string]string{"from": before.Status, "to": course.Status})
+	}
+	if !slices.Equal(before.Owners, course.Owners) {
+		recordAudit(actor, "course.owners", courseID, map[string]string{
+			"from": strings.Join(before.Owners, ","),
+			"to":   strings.Join(course.Owners, ","),
+		})
+	}
+
+	return course, nil
 }
 
-func CreateCourseHandler(c echo.Context) error {
+// editableCourse - курс, который actor может менять. Курс, который actor не видит,
+// неотличим от несуществующего, как в visibleCourse
+func editableCourse(actor User, courseID string) (Course, error) {
+	course, err := visibleCourse(actor, courseID)
+	if err != nil {
+		return Course{}, err
+	}
+	if !canEditCourse(actor, course) {
+		return Course{}, errForbidden
+	}
+	return course, nil
+}
+
+// PatchCourse применяет merge patch к текущему телу курса и сохраняет
+// результат по правилам ReplaceCourse. null очищает поле
+func PatchCourse(actor User, courseID string, version int, patch []byte) (Course, error) {
+	// права проверяются до разбора тела, чтобы ошибки не рассказывали о курсе
+	course, err := editableCourse(actor, courseID)
+	if err != nil {
+		return Course{}, err
+	}
+
+	var patchValue any
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return Course{}, newAPIError(http.StatusBadRequest, "invalid JSON payload")
+	}
+	if _, ok := patchValue.(map[string]any); !ok {
+		return Course{}, newAPIError(http.StatusBadRequest, "merge patch must be a JSON object")
+	}
+
+	if version != 0 && course.Version != version {
+		return Course{}, errPreconditionFailed
+	}
+	// Патч применен к этой версии: если курс изменят до записи, ReplaceCourse ответит 412
+	version = course.Version
+
+	// Текущее состояние переводится в map, чтобы null в патче удалял ключ
+	var document any
+	current, _ := json.Marshal(courseDocument(course))
+	json.Unmarshal(current, &document)
+
+	merged, _ := json.Marshal(mergePatch(document, patchValue))
+
 	var req PostCourseRequest
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON payload"})
+	if err := decodeJSONBody(merged, &req); err != nil {
+		return Course{}, err
 	}
 
-	if errs := req.Validate(); len(errs) > 0 {
-		return c.JSON(http.StatusBadRequest, map[string]interface{}{"error": "validation failed", "details": errs})
+	return ReplaceCourse(actor, courseID, version, req)
+}
+
+// Хендлеры
+
+// GET /api/courses?status=&namespaceId=&q=&startFrom=&startTo=&endFrom=&endTo=&sort=&limit=&cursor=
+// Следующая страница - в заголовках X-Next-Cursor и Link
+func GetCoursesHandler(c echo.Context) error {
+	actor, ok := currentUser(c)
+	if !ok {
+		return writeError(c, errUnauthorized)
 	}
 
-	courseMu.RLock()
-	_, exists := courseDB[req.Slug]
-	courseMu.RUnlock()
+	params := c.QueryParams()
 
-	if exists {
-		return c.JSON(http.StatusConflict, map[string]string{"error": "course with this slug already exists"})
+	q, err := courseQueryFromRequest(params)
+	if err != nil {
+		return writeError(c, err)
 	}
+	q.Viewer = &actor
 
-	course := Course{
-		ID:           req.Slug,
-		Name:         req.Name,
-		Status:       req.Status,
-		StartDate:    req.StartDate,
-		EndDate:      req.EndDate,
-		RepoTemplate: req.RepoTemplate,
-		Description:  req.Description,
-		URL:          "/course/" + req.Slug,
+	page, err := QueryCourses(q)
+	if err != nil {
+		return writeError(c, err)
 	}
 
-	courseMu.Lock()
-	courseDB[req.Slug] = course
-	courseMu.Unlock()
+	if page.Next != "" {
+		c.Response().Header().Set("X-Next-Cursor", page.Next)
+		c.Response().Header().Set("Link", "<"+nextPageLink(c.Request().URL.Path, params, page.Next)+`>; rel="next"`)
+	}
+	return c.JSON(http.StatusOK, page.Courses)
+}
+
+func GetCourseHandler(c echo.Context) error {
+	actor, ok := currentUser(c)
+	if !ok {
+		return writeError(c, errUnauthorized)
+	}
+
+	course, err := visibleCourse(actor, c.Param("courseId"))
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	if notModified(c, versionETag(course.Version)) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSON(http.StatusOK, course)
+}
+
+func CreateCourseHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var req PostCourseRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON payload"})
+	}
+
+	course, err := CreateCourse(actor, req)
+	if err != nil {
+		return writeError(c, err)
+	}
 
 	return c.JSON(http.StatusCreated, course)
 }
 
+// PUT /api/courses/:courseId
+// Требует If-Match с ETag курса, при устаревшей версии - 412
 func UpdateCourseHandler(c echo.Context) error {
-	courseID := c.Param("courseId")
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
+	}
 
-	courseMu.RLock()
-	course, exists := courseDB[courseID]
-	courseMu.RUnlock()
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return writeError(c, err)
+	}
 
-	if !exists {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "course not found"})
+	// права проверяются до разбора тела, как в PatchCourse
+	if _, err := editableCourse(actor, c.Param("courseId")); err != nil {
+		return writeError(c, err)
 	}
 
-	var req PostCourseRequest
-	if err := c.Bind(&req); err != nil {
+	data, err := io.ReadAll(c.Request().Body)
+	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON payload"})
 	}
 
-	if req.Status != "" && !isValidCourseStatus(req.Status) {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid status value"})
+	var req PostCourseRequest
+	if err := decodeJSONBody(data, &req); err != nil {
+		return writeError(c, err)
 	}
 
-	if req.StartDate != "" && !isValidDate(req.StartDate) {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "startDate must be in format YYYY-MM-DD"})
+	course, err := ReplaceCourse(actor, c.Param("courseId"), version, req)
+	if err != nil {
+		return writeError(c, err)
 	}
 
-	if req.EndDate != "" && !isValidDate(req.EndDate) {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "endDate must be in format YYYY-MM-DD"})
+	c.Response().Header().Set("ETag", versionETag(course.Version))
+	return c.JSON(http.StatusOK, course)
+}
+
+// PATCH /api/courses/:courseId
+// Тело - JSON Merge Patch (RFC 7396) к телу PUT, If-Match как у PUT
+func PatchCourseHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
 	}
 
-	updated := course
-	if req.Name != "" {
-		updated.Name = req.Name
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return writeError(c, err)
 	}
-	if req.Status != "" {
-		updated.Status = req.Status
+
+	if !isMergePatchContentType(c.Request().Header.Get(echo.HeaderContentType)) {
+		return c.JSON(http.StatusUnsupportedMediaType, map[string]string{"error": "content type must be application/merge-patch+json"})
 	}
-	if req.StartDate != "" {
-		updated.StartDate = req.StartDate
+
+	patch, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON payload"})
 	}
-	if req.EndDate != "" {
-		updated.EndDate = req.EndDate
+
+	course, err := PatchCourse(actor, c.Param("courseId"), version, patch)
+	if err != nil {
+		return writeError(c, err)
 	}
-	if req.RepoTemplate != "" {
-		updated.RepoTemplate = req.RepoTemplate
+
+	c.Response().Header().Set("ETag", versionETag(course.Version))
+	return c.JSON(http.StatusOK, course)
+}
+
+// POST /api/courses/:courseId/move
+// Перенос курса между namespace - отдельная админская операция, попадает в аудит.
+// Требует If-Match, как и другие изменения курса
+func MoveCourseHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
 	}
-	if req.Description != "" {
-		updated.Description = req.Description
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return writeError(c, err)
 	}
 
-	if !isValidDateRange(updated.StartDate, updated.EndDate) {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "endDate must be after startDate"})
+	var req MoveCourseRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON payload"})
 	}
 
-	courseMu.Lock()
-	courseDB[courseID] = updated
-	courseMu.Unlock()
+	course, err := MoveCourse(actor, c.Param("courseId"), version, req.NamespaceID)
+	if err != nil {
+		return writeError(c, err)
+	}
 
-	return c.JSON(http.StatusOK, updated)
-}
\ No newline at end of file
+	c.Response().Header().Set("ETag", versionETag(course.Version))
+	return c.JSON(http.StatusOK, course)
+}
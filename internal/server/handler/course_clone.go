@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CloneCourseRequest - тело запроса на клонирование курса в новый семестр
+type CloneCourseRequest struct {
+	Slug      string `json:"slug"`
+	StartDate string `json:"startDate"`
+}
+
+// Validate проверяет корректность запроса
+func (req *CloneCourseRequest) Validate() []ValidationError {
+	var v validator
+	v.check("slug", req.Slug, slugRules...)
+	v.check("startDate", req.StartDate, required, isDate)
+	return v.errs
+}
+
+// cloneBoard копирует группы, задания и дедлайны доски, сдвигая время на days
+// календарных дней по местному времени курса. Баллы и статистика студентов
+// не переносятся, дедлайны снова активны
+func cloneBoard(board TaskBoardSummary, course Course, days int) TaskBoardSummary {
+	loc := courseLocation(course)
+
+	clone := TaskBoardSummary{
+		CourseName:   course.Name,
+		CourseStatus: course.Status,
+		MaxScore:     board.MaxScore,
+		Groups:       make([]BoardGroup, 0, len(board.Groups)),
+	}
+
+	for _, group := range board.Groups {
+		group.Version = 1
+		group.StartedAt = shiftLocal(group.StartedAt, days, loc)
+		group.EndsAt = shiftLocal(group.EndsAt, days, loc)
+
+		deadlines := make([]BoardDeadline, 0, len(group.Deadlines))
+		for _, deadline := range group.Deadlines {
+			deadline.DueAt = shiftLocal(deadline.DueAt, days, loc)
+			deadline.Status = "active"
+			deadlines = append(deadlines, deadline)
+		}
+		group.Deadlines = deadlines
+
+		tasks := make([]BoardTask, 0, len(group.Tasks))
+		for _, task := range group.Tasks {
+			task.ScoreEarned = 0
+			task.Stats = 0
+			tasks = append(tasks, task)
+		}
+		group.Tasks = tasks
+
+		clone.Groups = append(clone.Groups, group)
+	}
+
+	return clone
+}
+
+// CloneCourse создает курс нового семестра по образцу существующего: метаданные,
+// владельцы, группы доски, задания с баллами и дедлайны с процентами копируются,
+// все даты сдвигаются на разницу в днях между старой и новой датой начала, местное
+// время дедлайнов остается прежним. Новый курс создается в статусе created, без
+// студентов, посылок и инвайтов. Доступно админам namespace исходного курса
+func CloneCourse(actor User, courseID string, req CloneCourseRequest) (Course, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return Course{}, validationFailed(errs)
+	}
+
+	source, err := visibleCourse(actor, courseID)
+	if err != nil {
+		return Course{}, err
+	}
+
+	if !canAdministerNamespace(actor, source.NamespaceID) {
+		return Course{}, newAPIError(http.StatusForbidden, "only namespace admins can clone courses")
+	}
+
+	if source.StartDate.IsZero() {
+		return Course{}, newAPIError(http.StatusConflict, "course has no startDate to shift from")
+	}
+	startDate, _ := ParseDate(req.StartDate)
+	days := source.StartDate.DaysUntil(startDate)
+
+	course := Course{
+		ID:           req.Slug,
+		Name:         source.Name,
+		Status:       "created",
+		StartDate:    startDate,
+		EndDate:      source.EndDate.AddDays(days),
+		Timezone:     source.Timezone,
+		RepoTemplate: source.RepoTemplate,
+		Description:  source.Description,
+		URL:          "/course/" + req.Slug,
+		NamespaceID:  source.NamespaceID,
+		GitlabGroup:  req.Slug,
+		Owners:       slices.Clone(source.Owners),
+		Version:      1,
+	}
+
+	courseMu.Lock()
+	if err := slugConflict(req.Slug, ""); err != nil {
+		courseMu.Unlock()
+		return Course{}, err
+	}
+	courseDB[req.Slug] = course
+	courseMu.Unlock()
+
+	boardMu.Lock()
+	if board, exists := boardData[courseID]; exists {
+		boardData[req.Slug] = cloneBoard(board, course, days)
+	}
+	boardMu.Unlock()
+
+	recordAudit(actor, "course.clone", req.Slug, map[string]string{"from": courseID, "startDate": req.StartDate})
+
+	return course, nil
+}
+
+// POST /api/courses/:courseId/clone
+func CloneCourseHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var req CloneCourseRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON payload"})
+	}
+
+	course, err := CloneCourse(actor, c.Param("courseId"), req)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	c.Response().Header().Set("ETag", versionETag(course.Version))
+	c.Response().Header().Set(echo.HeaderLocation, "/api/courses/"+course.ID)
+	return c.JSON(http.StatusCreated, course)
+}
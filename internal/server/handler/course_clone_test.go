@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func setupEchoClone() *echo.Echo {
+	e := echo.New()
+	e.POST("/api/courses/:courseId/clone", CloneCourseHandler)
+	e.GET("/api/courses/:courseId/board", GetCourseBoardHandler)
+	return e
+}
+
+func TestCloneCourse(t *testing.T) {
+	resetTrashDB()
+	e := setupEchoClone()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/courses/algorithms/clone", "nsadmin-token",
+		[]byte(`{"slug":"algorithms-2025","startDate":"2025-01-06"}`)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if location := rec.Header().Get("Location"); location != "/api/courses/algorithms-2025" {
+		t.Errorf("unexpected Location %q", location)
+	}
+
+	var course Course
+	json.Unmarshal(rec.Body.Bytes(), &course)
+
+	source, _ := getCourse("algorithms")
+	// исходный курс идет с 2024-01-01 по 2024-02-01, новый начинается на 53 недели позже
+	if course.ID != "algorithms-2025" || course.Status != "created" || course.Version != 1 ||
+		course.StartDate != mustDate("2025-01-06") || course.EndDate != mustDate("2025-02-06") ||
+		course.Name != source.Name || course.RepoTemplate != source.RepoTemplate ||
+		course.NamespaceID != source.NamespaceID || course.GitlabGroup != "algorithms-2025" ||
+		len(course.Owners) != len(source.Owners) {
+		t.Fatalf("unexpected clone: %+v", course)
+	}
+
+	boardMu.RLock()
+	board := boardData["algorithms-2025"]
+	boardMu.RUnlock()
+
+	if board.CourseStatus != "created" || board.SolvedScore != 0 || board.SolvedPercent != 0 || board.MaxScore != 200 || len(board.Groups) != 1 {
+		t.Fatalf("unexpected board: %+v", board)
+	}
+	group := board.Groups[0]
+	if group.ID != "week-1" || group.Version != 1 || !group.StartedAt.Equal(mustTime("2025-10-07T09:00:00Z")) || !group.EndsAt.Equal(mustTime("2025-10-20T18:00:00Z")) {
+		t.Errorf("group not shifted: %+v", group)
+	}
+	if deadline := group.Deadlines[0]; !deadline.DueAt.Equal(mustTime("2025-09-26T18:00:00Z")) || deadline.Percent != 0.6 || deadline.Status != "active" {
+		t.Errorf("deadline not shifted: %+v", deadline)
+	}
+	if task := group.Tasks[0]; task.Score != 20 || task.ScoreEarned != 0 || task.Stats != 0 {
+		t.Errorf("task scores must not be copied: %+v", task)
+	}
+
+	// исходная доска не меняется
+	boardMu.RLock()
+	original := boardData["algorithms"].Groups[0]
+	boardMu.RUnlock()
+	if !original.StartedAt.Equal(mustTime("2024-10-01T09:00:00Z")) || original.Tasks[0].ScoreEarned != 20 || original.Deadlines[0].Status != "expired" {
+		t.Errorf("source board changed: %+v", original)
+	}
+
+	if enrollmentState("algorithms-2025", "u-4") != "" {
+		t.Error("students must not be copied")
+	}
+	for _, submission := range submissionDB {
+		if submission.CourseID == "algorithms-2025" {
+			t.Errorf("submission copied: %+v", submission)
+		}
+	}
+	if last := auditLog[len(auditLog)-1]; last.Action != "course.clone" || last.Details["from"] != "algorithms" {
+		t.Errorf("unexpected audit entry: %+v", last)
+	}
+}
+
+func TestCloneCourse_Errors(t *testing.T) {
+	cases := []struct {
+		name     string
+		courseID string
+		token    string
+		body     string
+		want     int
+	}{
+		{"student", "algorithms", "student-token", `{"slug":"algo","startDate":"2025-09-01"}`, http.StatusForbidden},
+		{"program manager", "algorithms", "pm-token", `{"slug":"algo","startDate":"2025-09-01"}`, http.StatusForbidden},
+		{"unknown course", "unknown", "nsadmin-token", `{"slug":"algo","startDate":"2025-09-01"}`, http.StatusNotFound},
+		{"taken slug", "algorithms", "nsadmin-token", `{"slug":"hidden","startDate":"2025-09-01"}`, http.StatusConflict},
+		{"invalid slug", "algorithms", "nsadmin-token", `{"slug":"Algo","startDate":"2025-09-01"}`, http.StatusBadRequest},
+		{"no start date", "algorithms", "nsadmin-token", `{"slug":"algo"}`, http.StatusBadRequest},
+		{"invalid start date", "algorithms", "nsadmin-token", `{"slug":"algo","startDate":"01.09.2025"}`, http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resetTrashDB()
+			e := setupEchoClone()
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, authReq(http.MethodPost, "/api/courses/"+tc.courseID+"/clone", tc.token, []byte(tc.body)))
+
+			if rec.Code != tc.want {
+				t.Fatalf("expected %d, got %d: %s", tc.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
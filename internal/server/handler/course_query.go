@@ -0,0 +1,289 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Размер страницы GET /api/courses
+const (
+	defaultCoursePageSize = 50
+	maxCoursePageSize     = 200
+)
+
+var coursePageSizeRange = map[string]string{"range": "[1, " + strconv.Itoa(maxCoursePageSize) + "]"}
+
+// Статусы в порядке жизненного цикла курса, так же сортирует sort=status
+var courseStatusOrder = []string{"created", "hidden", "in_progress", "all_tasks_issued", "doreshka", "finished"}
+
+// CourseQuery - фильтры, сортировка и страница списка курсов. Пустые поля не ограничивают
+type CourseQuery struct {
+	Statuses    []string
+	NamespaceID string
+
+	// Подстрока названия или описания без учета регистра
+	Search string
+
+	// Границы дат начала и окончания курса включительно, YYYY-MM-DD
+	StartFrom string
+	StartTo   string
+	EndFrom   string
+	EndTo     string
+
+	// name, startDate или status, с "-" - по убыванию. По умолчанию по ID
+	Sort string
+
+	// 0 - без ограничения
+	Limit  int
+	Cursor string
+
+	// Только курсы, которые видит этот пользователь; nil - все курсы
+	Viewer *User
+}
+
+// CoursePage - страница списка; Next пустой на последней странице
+type CoursePage struct {
+	Courses []Course
+	Next    string
+}
+
+// courseCursor - позиция последнего курса страницы в выбранной сортировке
+type courseCursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   string `json:"id"`
+}
+
+type courseSort struct {
+	field string
+	desc  bool
+}
+
+func parseCourseSort(value string) (courseSort, bool) {
+	field, desc := strings.CutPrefix(value, "-")
+	switch field {
+	case "", "id", "name", "startDate", "status":
+		return courseSort{field: field, desc: desc}, true
+	default:
+		return courseSort{}, false
+	}
+}
+
+// key - значение поля сортировки в виде, который сравнивается как строка
+func (s courseSort) key(course Course) string {
+	switch s.field {
+	case "name":
+		return foldText(course.Name)
+	case "startDate":
+		return course.StartDate.String()
+	case "status":
+		return strconv.Itoa(slices.Index(courseStatusOrder, course.Status))
+	default:
+		return course.ID
+	}
+}
+
+// before - порядок по ключу, при равных ключах по ID, чтобы порядок был полным
+func (s courseSort) before(keyA, idA, keyB, idB string) bool {
+	if keyA != keyB {
+		return (keyA < keyB) != s.desc
+	}
+	return idA != idB && (idA < idB) != s.desc
+}
+
+// foldText приводит текст к виду для поиска и сортировки без учета регистра:
+// strings.ToLower понимает кириллицу, а ё приравнивается к е, иначе
+// "Ёлка" не находится по "елка" и уезжает в конец после "я"
+func foldText(text string) string {
+	return strings.ReplaceAll(strings.ToLower(text), "ё", "е")
+}
+
+func encodeCourseCursor(cursor courseCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCourseCursor(value string) (courseCursor, bool) {
+	var cursor courseCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || json.Unmarshal(data, &cursor) != nil || cursor.ID == "" {
+		return courseCursor{}, false
+	}
+	return cursor, true
+}
+
+// Validate проверяет значения фильтров, сортировки и курсора
+func (q *CourseQuery) Validate() []ValidationError {
+	var v validator
+
+	for _, status := range q.Statuses {
+		v.check("status", status, oneOf(courseStatusOrder))
+	}
+
+	v.check("startFrom", q.StartFrom, isDate)
+	v.check("startTo", q.StartTo, isDate)
+	v.check("endFrom", q.EndFrom, isDate)
+	v.check("endTo", q.EndTo, isDate)
+
+	_, ok := parseCourseSort(q.Sort)
+	if !ok {
+		v.add("sort", CodeInvalidValue, map[string]string{"value": q.Sort, "allowed": "name, startDate, status, optionally prefixed with -"})
+	}
+
+	if q.Limit < 0 || q.Limit > maxCoursePageSize {
+		v.add("limit", CodeOutOfRange, coursePageSizeRange)
+	}
+
+	if q.Cursor != "" {
+		cursor, valid := decodeCourseCursor(q.Cursor)
+		if !valid {
+			v.errs = append(v.errs, ValidationError{Field: "cursor", Message: "invalid cursor"})
+		} else if ok && cursor.Sort != q.Sort {
+			v.errs = append(v.errs, ValidationError{Field: "cursor", Message: "cursor belongs to a different sort order"})
+		}
+	}
+
+	return v.errs
+}
+
+func (q *CourseQuery) matches(course Course) bool {
+	if len(q.Statuses) > 0 && !slices.Contains(q.Statuses, course.Status) {
+		return false
+	}
+	if q.NamespaceID != "" && course.NamespaceID != q.NamespaceID {
+		return false
+	}
+
+	// Границы - календарные даты курса, часовой пояс на них не влияет
+	if !inDateRange(course.StartDate, q.StartFrom, q.StartTo) || !inDateRange(course.EndDate, q.EndFrom, q.EndTo) {
+		return false
+	}
+
+	if q.Search != "" {
+		search := foldText(q.Search)
+		if !strings.Contains(foldText(course.Name), search) && !strings.Contains(foldText(course.Description), search) {
+			return false
+		}
+	}
+	return true
+}
+
+// inDateRange - дата в границах включительно; пустая граница не ограничивает
+func inDateRange(date Date, from, to string) bool {
+	if from != "" {
+		if bound, _ := ParseDate(from); date.Compare(bound) < 0 {
+			return false
+		}
+	}
+	if to != "" {
+		if bound, _ := ParseDate(to); date.Compare(bound) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// QueryCourses возвращает страницу курсов по фильтрам в стабильном порядке.
+// Курсор указывает на последний курс предыдущей страницы, поэтому курсы,
+// добавленные или удаленные между запросами, не сдвигают следующую страницу
+func QueryCourses(q CourseQuery) (CoursePage, error) {
+	if errs := q.Validate(); len(errs) > 0 {
+		return CoursePage{}, validationFailed(errs)
+	}
+	order, _ := parseCourseSort(q.Sort)
+
+	courseMu.RLock()
+	courses := make([]Course, 0, len(courseDB))
+	for _, course := range courseDB {
+		if q.matches(course) {
+			courses = append(courses, course)
+		}
+	}
+	courseMu.RUnlock()
+
+	// Видимость проверяется вне courseMu: она читает записи и роли в namespace
+	if q.Viewer != nil {
+		courses = slices.DeleteFunc(courses, func(course Course) bool { return !canSeeCourse(*q.Viewer, course) })
+	}
+
+	slices.SortFunc(courses, func(a, b Course) int {
+		switch keyA, keyB := order.key(a), order.key(b); {
+		case order.before(keyA, a.ID, keyB, b.ID):
+			return -1
+		case order.before(keyB, b.ID, keyA, a.ID):
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	if q.Cursor != "" {
+		cursor, _ := decodeCourseCursor(q.Cursor)
+		start := len(courses)
+		for i, course := range courses {
+			if order.before(cursor.Key, cursor.ID, order.key(course), course.ID) {
+				start = i
+				break
+			}
+		}
+		courses = courses[start:]
+	}
+
+	page := CoursePage{Courses: courses}
+	if q.Limit > 0 && len(courses) > q.Limit {
+		page.Courses = courses[:q.Limit]
+		last := page.Courses[q.Limit-1]
+		page.Next = encodeCourseCursor(courseCursor{Sort: q.Sort, Key: order.key(last), ID: last.ID})
+	}
+	return page, nil
+}
+
+// courseQueryFromRequest разбирает параметры GET /api/courses. status можно
+// повторять или перечислять через запятую
+func courseQueryFromRequest(params url.Values) (CourseQuery, error) {
+	get := params.Get
+
+	q := CourseQuery{
+		NamespaceID: get("namespaceId"),
+		Search:      strings.TrimSpace(get("q")),
+		StartFrom:   get("startFrom"),
+		StartTo:     get("startTo"),
+		EndFrom:     get("endFrom"),
+		EndTo:       get("endTo"),
+		Sort:        get("sort"),
+		Limit:       defaultCoursePageSize,
+		Cursor:      get("cursor"),
+	}
+
+	for _, value := range params["status"] {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				q.Statuses = append(q.Statuses, status)
+			}
+		}
+	}
+
+	if value := get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return q, validationFailed([]ValidationError{newValidationError("limit", CodeOutOfRange, coursePageSizeRange)})
+		}
+		q.Limit = limit
+	}
+
+	return q, nil
+}
+
+// nextPageLink - ссылка на следующую страницу с теми же параметрами
+func nextPageLink(path string, params url.Values, cursor string) string {
+	query := url.Values{}
+	for name, values := range params {
+		query[name] = values
+	}
+	query.Set("cursor", cursor)
+	return path + "?" + query.Encode()
+}
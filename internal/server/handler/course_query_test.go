@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// resetCatalogDB - курсы с разными датами, статусами и кириллицей в названиях
+func resetCatalogDB() {
+	resetUsers()
+
+	courseMu.Lock()
+	defer courseMu.Unlock()
+
+	course := func(id, name, status, start, end, description string) Course {
+		return Course{ID: id, Name: name, Status: status, StartDate: mustDate(start), EndDate: mustDate(end), Description: description, URL: "/course/" + id, Owners: []string{}}
+	}
+
+	courseDB = map[string]Course{
+		"algo":   course("algo", "Алгоритмы", "in_progress", "2024-09-01", "2024-12-20", "Графы и динамика"),
+		"yolka":  course("yolka", "Ёлка задач", "created", "2024-12-01", "2025-01-10", "Новогодний контест"),
+		"python": course("python", "Python", "finished", "2024-02-01", "2024-05-30", "Основы ЯЗЫКА"),
+		"go":     course("go", "Go Lab", "in_progress", "2024-09-01", "2024-11-30", "Практикум"),
+		"ml":     course("ml", "ML", "hidden", "2025-02-01", "2025-05-30", "Машинное обучение"),
+	}
+}
+
+func courseIDs(courses []Course) string {
+	ids := make([]string, 0, len(courses))
+	for _, course := range courses {
+		ids = append(ids, course.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestQueryCourses_Sort(t *testing.T) {
+	resetCatalogDB()
+
+	for _, tt := range []struct {
+		sort string
+		want string
+	}{
+		{"", "algo,go,ml,python,yolka"},
+		{"name", "go,ml,python,algo,yolka"},
+		{"-name", "yolka,algo,python,ml,go"},
+		{"startDate", "python,algo,go,yolka,ml"},
+		{"status", "yolka,ml,algo,go,python"},
+		{"-status", "python,go,algo,ml,yolka"},
+	} {
+		page, err := QueryCourses(CourseQuery{Sort: tt.sort})
+		if err != nil {
+			t.Fatalf("sort %q: %v", tt.sort, err)
+		}
+		if got := courseIDs(page.Courses); got != tt.want {
+			t.Errorf("sort %q: expected %s, got %s", tt.sort, tt.want, got)
+		}
+	}
+}
+
+func TestQueryCourses_Filters(t *testing.T) {
+	resetCatalogDB()
+
+	for _, tt := range []struct {
+		name  string
+		query CourseQuery
+		want  string
+	}{
+		{"several statuses", CourseQuery{Statuses: []string{"created", "finished"}}, "python,yolka"},
+		{"start range", CourseQuery{StartFrom: "2024-09-01", StartTo: "2024-12-01"}, "algo,go,yolka"},
+		{"ends before", CourseQuery{EndTo: "2024-12-01"}, "go,python"},
+		{"cyrillic case", CourseQuery{Search: "АЛГОРИТМ"}, "algo"},
+		{"description", CourseQuery{Search: "язык"}, "python"},
+		{"yo as ye", CourseQuery{Search: "елка"}, "yolka"},
+		{"combined", CourseQuery{Statuses: []string{"in_progress"}, Search: "lab"}, "go"},
+	} {
+		page, err := QueryCourses(tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := courseIDs(page.Courses); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestQueryCourses_Pagination(t *testing.T) {
+	resetCatalogDB()
+
+	q := CourseQuery{Sort: "startDate", Limit: 2}
+	var seen []string
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination does not end")
+		}
+		page, err := QueryCourses(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen = append(seen, courseIDs(page.Courses))
+
+		if page.Next == "" {
+			break
+		}
+		q.Cursor = page.Next
+
+		// новый курс в начале списка не сдвигает следующую страницу
+		if pages == 0 {
+			courseMu.Lock()
+			courseDB["early"] = Course{ID: "early", Name: "Early", Status: "created", StartDate: mustDate("2023-01-01"), EndDate: mustDate("2023-02-01")}
+			courseMu.Unlock()
+		}
+	}
+
+	if got := strings.Join(seen, "|"); got != "python,algo|go,yolka|ml" {
+		t.Fatalf("unexpected pages: %s", got)
+	}
+}
+
+func TestQueryCourses_Invalid(t *testing.T) {
+	resetCatalogDB()
+
+	page, _ := QueryCourses(CourseQuery{Sort: "name", Limit: 1})
+
+	for _, tt := range []struct {
+		name  string
+		query CourseQuery
+		field string
+	}{
+		{"status", CourseQuery{Statuses: []string{"bogus"}}, "status"},
+		{"date", CourseQuery{StartFrom: "01.09.2024"}, "startFrom"},
+		{"sort", CourseQuery{Sort: "owners"}, "sort"},
+		{"limit", CourseQuery{Limit: maxCoursePageSize + 1}, "limit"},
+		{"cursor", CourseQuery{Cursor: "not-a-cursor"}, "cursor"},
+		{"cursor of another sort", CourseQuery{Sort: "startDate", Cursor: page.Next}, "cursor"},
+	} {
+		_, err := QueryCourses(tt.query)
+		if apiStatus(err) != http.StatusBadRequest || !strings.Contains(err.Error(), tt.field+":") {
+			t.Errorf("%s: expected validation error for %s, got %v", tt.name, tt.field, err)
+		}
+	}
+}
+
+func TestGetCourses_Pagination(t *testing.T) {
+	resetCatalogDB()
+	e := setupEcho()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses?status=in_progress,created&status=finished&sort=-name&limit=2", "admin-token", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var courses []Course
+	json.Unmarshal(rec.Body.Bytes(), &courses)
+	if got := courseIDs(courses); got != "yolka,algo" {
+		t.Fatalf("unexpected first page: %s", got)
+	}
+
+	next := rec.Header().Get("X-Next-Cursor")
+	link := rec.Header().Get("Link")
+	if next == "" || !strings.HasSuffix(link, `>; rel="next"`) {
+		t.Fatalf("next page headers missing: %q, %q", next, link)
+	}
+
+	// ссылка сохраняет фильтры и сортировку
+	target, _ := url.Parse(strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`))
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, target.String(), "admin-token", nil))
+
+	courses = nil
+	json.Unmarshal(rec.Body.Bytes(), &courses)
+	if got := courseIDs(courses); got != "python,go" || rec.Header().Get("X-Next-Cursor") != "" {
+		t.Fatalf("unexpected last page: %s, next %q", got, rec.Header().Get("X-Next-Cursor"))
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses?limit=0", "admin-token", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for limit=0, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const maxSlugLength = 64
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Slug'и, которые совпадают с путями API и страницами фронтенда
+var reservedSlugs = []string{"admin", "api", "create", "edit", "me", "new", "rename", "restore", "settings", "trash"}
+
+// Старые slug'и переименованных курсов -> текущий ID курса, под courseMu.
+// Псевдоним занимает slug, пока курс не очищен из корзины
+var courseAliases = map[string]string{}
+
+// RenameCourseRequest - тело запроса на смену slug'а
+type RenameCourseRequest struct {
+	Slug string `json:"slug"`
+}
+
+// slugRules - строчные латинские буквы, цифры и одиночные дефисы между ними
+var slugRules = []rule{
+	required,
+	maxLength(maxSlugLength),
+	{code: CodeSlugFormat, valid: slugPattern.MatchString},
+	{code: CodeSlugReserved, withValue: true, valid: func(slug string) bool { return !slices.Contains(reservedSlugs, slug) }},
+}
+
+func validateSlug(slug string) []ValidationError {
+	var v validator
+	v.check("slug", slug, slugRules...)
+	return v.errs
+}
+
+// slugConflict - занят ли slug другим курсом, удаленным курсом или чужим псевдонимом.
+// Вызывается под courseMu
+func slugConflict(slug, courseID string) error {
+	if _, exists := courseDB[slug]; exists {
+		return newAPIError(http.StatusConflict, "course with this slug already exists")
+	}
+	if _, deleted := trashDB[slug]; deleted {
+		return newAPIError(http.StatusConflict, "slug is reserved by a deleted course until it is purged")
+	}
+	if target, exists := courseAliases[slug]; exists && target != courseID {
+		return newAPIError(http.StatusConflict, "slug is an old name of course "+target)
+	}
+	return nil
+}
+
+// resolveCourseAlias - текущий ID курса по старому slug'у
+func resolveCourseAlias(slug string) (string, bool) {
+	courseMu.RLock()
+	defer courseMu.RUnlock()
+	target, exists := courseAliases[slug]
+	return target, exists
+}
+
+// RenameCourse меняет slug курса. Старый slug остается псевдонимом, запросы по нему
+// перенаправляются, группа в GitLab не меняется. Записи, посылки, доска и инвайты
+// переезжают на новый slug. Доступно владельцам курса и админам namespace.
+// version - ожидаемая версия курса, 0 - без проверки
+func RenameCourse(actor User, courseID string, version int, slug string) (Course, error) {
+	if errs := validateSlug(slug); len(errs) > 0 {
+		return Course{}, validationFailed(errs)
+	}
+
+	course, err := editableCourse(actor, courseID)
+	if err != nil {
+		return Course{}, err
+	}
+
+	if slug == courseID {
+		return course, nil
+	}
+
+	courseMu.Lock()
+	course, exists := courseDB[courseID]
+	if !exists {
+		courseMu.Unlock()
+		return Course{}, newAPIError(http.StatusNotFound, "course not found")
+	}
+	if version != 0 && course.Version != version {
+		courseMu.Unlock()
+		return Course{}, errPreconditionFailed
+	}
+	if err := slugConflict(slug, courseID); err != nil {
+		courseMu.Unlock()
+		return Course{}, err
+	}
+
+	if course.GitlabGroup == "" {
+		course.GitlabGroup = courseID
+	}
+	course.ID = slug
+	course.URL = "/course/" + slug
+	course.Version++
+	delete(courseDB, courseID)
+	courseDB[slug] = course
+
+	// Цепочка переименований схлопывается: все старые slug'и ведут сразу на новый.
+	// Возврат к прежнему slug'у снимает его псевдоним
+	for alias, target := range courseAliases {
+		if target == courseID {
+			courseAliases[alias] = slug
+		}
+	}
+	delete(courseAliases, slug)
+	courseAliases[courseID] = slug
+
+	// Данные переезжают под тем же courseMu, чтобы никто не увидел курс
+	// под новым slug'ом без записей и доски
+	moveCourseData(courseID, slug)
+	courseMu.Unlock()
+
+	recordAudit(actor, "course.rename", slug, map[string]string{"from": courseID, "to": slug})
+
+	return course, nil
+}
+
+// moveCourseData переносит данные курса на новый ID. Вызывается под courseMu.
+// Порядок локов: courseMu, затем локи данных курса; под ними courseMu не берется
+func moveCourseData(from, to string) {
+	enrollmentMu.Lock()
+	if enrollments, exists := enrollmentDB[from]; exists {
+		for userID, enrollment := range enrollments {
+			enrollment.CourseID = to
+			enrollments[userID] = enrollment
+		}
+		enrollmentDB[to] = enrollments
+		delete(enrollmentDB, from)
+	}
+	enrollmentMu.Unlock()
+
+	submissionMu.Lock()
+	for i := range submissionDB {
+		if submissionDB[i].CourseID == from {
+			submissionDB[i].CourseID = to
+		}
+	}
+	submissionMu.Unlock()
+
+	boardMu.Lock()
+	if board, exists := boardData[from]; exists {
+		boardData[to] = board
+		delete(boardData, from)
+	}
+	boardMu.Unlock()
+
+	inviteMu.Lock()
+	for code, invite := range inviteDB {
+		if invite.CourseID == from {
+			invite.CourseID = to
+			inviteDB[code] = invite
+		}
+	}
+	inviteMu.Unlock()
+
+	signupMu.Lock()
+	for id, signup := range signupDB {
+		if signup.CourseID == from {
+			signup.CourseID = to
+			signupDB[id] = signup
+		}
+	}
+	signupMu.Unlock()
+}
+
+// ResolveCourseAlias перенаправляет запросы по старому slug'у курса на текущий
+// с 308: метод и тело сохраняются, поэтому старые ссылки и CI продолжают работать.
+// Псевдоним раскрывается только тем, кто видит курс, остальным - 401 или 404
+func ResolveCourseAlias(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		courseID := c.Param("courseId")
+		target, ok := resolveCourseAlias(courseID)
+		if courseID == "" || !ok {
+			return next(c)
+		}
+
+		actor, ok := currentUser(c)
+		if !ok {
+			return writeError(c, errUnauthorized)
+		}
+		if _, err := visibleCourse(actor, target); err != nil {
+			return writeError(c, err)
+		}
+
+		u := *c.Request().URL
+		u.Path = strings.Replace(u.Path, "/courses/"+courseID, "/courses/"+target, 1)
+		u.RawPath = ""
+		return c.Redirect(http.StatusPermanentRedirect, u.RequestURI())
+	}
+}
+
+// POST /api/courses/:courseId/rename
+// Требует If-Match, как и другие изменения курса
+func RenameCourseHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var req RenameCourseRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON payload"})
+	}
+
+	course, err := RenameCourse(actor, c.Param("courseId"), version, req.Slug)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	c.Response().Header().Set("ETag", versionETag(course.Version))
+	c.Response().Header().Set(echo.HeaderLocation, strings.Replace(c.Request().URL.Path, "/"+c.Param("courseId")+"/rename", "/"+course.ID, 1))
+	return c.JSON(http.StatusOK, course)
+}
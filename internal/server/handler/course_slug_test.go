@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func setupEchoSlugs() *echo.Echo {
+	e := echo.New()
+	api := e.Group("/api")
+
+	api.POST("/courses", CreateCourseHandler)
+	api.GET("/courses/:courseId", GetCourseHandler, ResolveCourseAlias)
+	api.PATCH("/courses/:courseId", PatchCourseHandler, ResolveCourseAlias)
+	api.POST("/courses/:courseId/rename", RenameCourseHandler, ResolveCourseAlias)
+	api.GET("/courses/:courseId/board", GetCourseBoardHandler, ResolveCourseAlias)
+
+	return e
+}
+
+func renameReq(courseID, token, ifMatch, slug string) *http.Request {
+	body, _ := json.Marshal(RenameCourseRequest{Slug: slug})
+	req := authReq(http.MethodPost, "/api/courses/"+courseID+"/rename", token, body)
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	return req
+}
+
+func TestValidateSlug(t *testing.T) {
+	cases := map[string]bool{
+		"algorithms":            true,
+		"go-2025":               true,
+		"a":                     true,
+		"2025":                  true,
+		"":                      false,
+		"Algorithms":            false,
+		"algo_rithms":           false,
+		"-algo":                 false,
+		"algo-":                 false,
+		"al--go":                false,
+		"алгоритмы":             false,
+		"algo rithms":           false,
+		strings.Repeat("a", 64): true,
+		strings.Repeat("a", 65): false,
+		"create":                false,
+		"trash":                 false,
+	}
+
+	for slug, valid := range cases {
+		if got := len(validateSlug(slug)) == 0; got != valid {
+			t.Errorf("validateSlug(%q): valid=%v, want %v", slug, got, valid)
+		}
+	}
+}
+
+func TestCreateCourse_SlugFormat(t *testing.T) {
+	resetNamespaceDB()
+	e := setupEcho()
+
+	for _, slug := range []string{"Go Course", "create", "go_course"} {
+		body := []byte(`{"name":"Go","slug":"` + slug + `","namespaceId":"ns-01","status":"created","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a:repo.git"}`)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, authReq(http.MethodPost, "/api/courses", "admin-token", body))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%q: expected 400, got %d", slug, rec.Code)
+		}
+		if fields := errorFields(t, rec); len(fields) != 1 || fields[0] != "slug" {
+			t.Errorf("%q: expected slug error, got %v", slug, fields)
+		}
+	}
+}
+
+func TestRenameCourse_MovesDataAndKeepsGitlabGroup(t *testing.T) {
+	resetTrashDB()
+	e := setupEchoSlugs()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, renameReq("algorithms", "nsadmin-token", `"1"`, "algorithms-2025"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if location := rec.Header().Get("Location"); location != "/api/courses/algorithms-2025" {
+		t.Errorf("unexpected Location %q", location)
+	}
+
+	var course Course
+	json.Unmarshal(rec.Body.Bytes(), &course)
+	if course.ID != "algorithms-2025" || course.URL != "/course/algorithms-2025" || course.GitlabGroup != "algorithms" || course.Version != 2 {
+		t.Fatalf("unexpected renamed course: %+v", course)
+	}
+
+	enrollmentMu.RLock()
+	enrollment, enrolled := enrollmentDB["algorithms-2025"]["u-4"]
+	_, left := enrollmentDB["algorithms"]
+	enrollmentMu.RUnlock()
+	if !enrolled || enrollment.CourseID != "algorithms-2025" || left {
+		t.Errorf("enrollments not moved: %+v", enrollmentDB)
+	}
+	for _, submission := range submissionDB {
+		if submission.CourseID != "algorithms-2025" {
+			t.Errorf("submission not moved: %+v", submission)
+		}
+	}
+	if _, ok := boardData["algorithms-2025"]; !ok {
+		t.Error("board not moved")
+	}
+	if inviteDB["course-invite"].CourseID != "algorithms-2025" {
+		t.Errorf("invite not moved: %+v", inviteDB["course-invite"])
+	}
+	if last := auditLog[len(auditLog)-1]; last.Action != "course.rename" || last.Details["from"] != "algorithms" {
+		t.Errorf("unexpected audit entry: %+v", last)
+	}
+}
+
+func TestRenameCourse_OldSlugRedirects(t *testing.T) {
+	resetTrashDB()
+	e := setupEchoSlugs()
+
+	e.ServeHTTP(httptest.NewRecorder(), renameReq("algorithms", "nsadmin-token", `"1"`, "algorithms-2025"))
+
+	cases := []struct {
+		method, path, location string
+	}{
+		{http.MethodGet, "/api/courses/algorithms?fields=name", "/api/courses/algorithms-2025?fields=name"},
+		{http.MethodGet, "/api/courses/algorithms/board", "/api/courses/algorithms-2025/board"},
+		{http.MethodPatch, "/api/courses/algorithms", "/api/courses/algorithms-2025"},
+	}
+
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, authReq(tc.method, tc.path, "student-token", []byte(`{}`)))
+
+		if rec.Code != http.StatusPermanentRedirect || rec.Header().Get("Location") != tc.location {
+			t.Errorf("%s %s: expected 308 to %s, got %d %q", tc.method, tc.path, tc.location, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses/algorithms-2025", "student-token", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("new slug: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// новый slug не раскрывается тем, кто курс не видит
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses/algorithms", "outsider-token", nil))
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Location") != "" {
+		t.Errorf("outsider: expected 404 without Location, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodGet, "/api/courses/algorithms", nil))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("Location") != "" {
+		t.Errorf("anonymous: expected 401 without Location, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/courses", "admin-token", []byte(`{"name":"Again","slug":"algorithms","namespaceId":"ns-01","status":"created","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a:repo.git"}`)))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("old slug must stay reserved, got %d", rec.Code)
+	}
+}
+
+func TestRenameCourse_Errors(t *testing.T) {
+	cases := []struct {
+		name     string
+		courseID string
+		token    string
+		ifMatch  string
+		slug     string
+		want     int
+	}{
+		{"student", "algorithms", "student-token", `"1"`, "algo", http.StatusForbidden},
+		{"without If-Match", "algorithms", "nsadmin-token", "", "algo", http.StatusPreconditionRequired},
+		{"stale version", "algorithms", "nsadmin-token", `"3"`, "algo", http.StatusPreconditionFailed},
+		{"invalid slug", "algorithms", "nsadmin-token", `"1"`, "Algo", http.StatusBadRequest},
+		{"reserved slug", "algorithms", "nsadmin-token", `"1"`, "create", http.StatusBadRequest},
+		{"taken by a course", "algorithms", "nsadmin-token", `"1"`, "hidden", http.StatusConflict},
+		{"alias of another course", "hidden", "nsadmin-token", `"1"`, "old-algorithms", http.StatusConflict},
+		{"unknown course", "unknown", "nsadmin-token", `"1"`, "algo", http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resetTrashDB()
+			courseMu.Lock()
+			courseAliases = map[string]string{"old-algorithms": "algorithms"}
+			courseMu.Unlock()
+			e := setupEchoSlugs()
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, renameReq(tc.courseID, tc.token, tc.ifMatch, tc.slug))
+
+			if rec.Code != tc.want {
+				t.Fatalf("expected %d, got %d: %s", tc.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRenameCourse_Chain(t *testing.T) {
+	resetTrashDB()
+	admin, _ := findUserByUsername("admin")
+
+	if _, err := RenameCourse(admin, "algorithms", 0, "algorithms-2025"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RenameCourse(admin, "algorithms-2025", 0, "algo"); err != nil {
+		t.Fatal(err)
+	}
+
+	courseMu.RLock()
+	if courseAliases["algorithms"] != "algo" || courseAliases["algorithms-2025"] != "algo" {
+		t.Errorf("aliases must point to the current slug: %v", courseAliases)
+	}
+	courseMu.RUnlock()
+
+	// Возврат к первому slug'у снимает его псевдоним
+	if _, err := RenameCourse(admin, "algo", 0, "algorithms"); err != nil {
+		t.Fatalf("rename back: %v", err)
+	}
+	courseMu.RLock()
+	defer courseMu.RUnlock()
+	if _, aliased := courseAliases["algorithms"]; aliased || courseAliases["algo"] != "algorithms" || courseAliases["algorithms-2025"] != "algorithms" {
+		t.Errorf("unexpected aliases after renaming back: %v", courseAliases)
+	}
+}
+
+func TestPurge_FreesAliases(t *testing.T) {
+	resetTrashDB()
+	admin, _ := findUserByUsername("admin")
+
+	if _, err := RenameCourse(admin, "algorithms", 0, "algo"); err != nil {
+		t.Fatal(err)
+	}
+	deletedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := DeleteCourse(admin, "algo", 0, deletedAt); err != nil {
+		t.Fatal(err)
+	}
+	PurgeDeletedCourses(deletedAt.Add(31 * 24 * time.Hour))
+
+	if _, ok := resolveCourseAlias("algorithms"); ok {
+		t.Error("alias of a purged course must be removed")
+	}
+}
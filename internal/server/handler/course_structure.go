@@ -0,0 +1,655 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// CourseStructure - структура курса в YAML, которую можно хранить в репозитории
+// курса: метаданные, политики оценивания, группы с заданиями и дедлайнами.
+// Студенты, баллы и статусы дедлайнов в структуру не входят
+type CourseStructure struct {
+	Course   StructureCourse   `yaml:"course"`
+	Policies StructurePolicies `yaml:"policies"`
+	Groups   []StructureGroup  `yaml:"groups"`
+}
+
+type StructureCourse struct {
+	Name         string `yaml:"name"`
+	Description  string `yaml:"description,omitempty"`
+	StartDate    string `yaml:"startDate"`
+	EndDate      string `yaml:"endDate"`
+	Timezone     string `yaml:"timezone,omitempty"`
+	RepoTemplate string `yaml:"repoTemplate"`
+}
+
+// StructurePolicies - политики оценивания. Штраф за дедлайн задается
+// процентом балла в самом дедлайне. Шкалы оценок и других штрафов в модели
+// курса пока нет, поэтому нет и в структуре
+type StructurePolicies struct {
+	// Балл, от которого считается процент решенного
+	MaxScore int `yaml:"maxScore"`
+}
+
+// Время групп и дедлайнов - как в parseCourseTime: с явным смещением, местное
+// время курса без смещения или дата (endsAt и dueAt - конец дня). При экспорте
+// время выводится по часовому поясу курса со смещением
+type StructureGroup struct {
+	ID        string              `yaml:"id"`
+	Name      string              `yaml:"name"`
+	IsSpecial bool                `yaml:"isSpecial,omitempty"`
+	StartedAt string              `yaml:"startedAt,omitempty"`
+	EndsAt    string              `yaml:"endsAt,omitempty"`
+	Deadlines []StructureDeadline `yaml:"deadlines"`
+	Tasks     []StructureTask     `yaml:"tasks"`
+}
+
+type StructureDeadline struct {
+	ID      string  `yaml:"id"`
+	Label   string  `yaml:"label"`
+	Percent float64 `yaml:"percent"`
+	DueAt   string  `yaml:"dueAt"`
+}
+
+type StructureTask struct {
+	ID        string `yaml:"id"`
+	Name      string `yaml:"name"`
+	Score     int    `yaml:"score"`
+	IsBonus   bool   `yaml:"isBonus,omitempty"`
+	IsSpecial bool   `yaml:"isSpecial,omitempty"`
+	URL       string `yaml:"url,omitempty"`
+}
+
+// StructureChanges - ID добавленных, удаленных и измененных элементов
+type StructureChanges struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+// StructureDiff - что меняет импорт структуры
+type StructureDiff struct {
+	DryRun bool `json:"dryRun"`
+
+	// Измененные поля курса и политик
+	Course []string         `json:"course"`
+	Groups StructureChanges `json:"groups"`
+	Tasks  StructureChanges `json:"tasks"`
+
+	// Удаляемые задачи, по которым уже есть посылки или набранные баллы: без force
+	// импорт отклоняется, с force их посылки архивируются
+	ScoredTasks []string `json:"scoredTasks"`
+}
+
+func (d StructureDiff) empty() bool {
+	return len(d.Course) == 0 && len(d.Groups.Added)+len(d.Groups.Removed)+len(d.Groups.Changed) == 0 &&
+		len(d.Tasks.Added)+len(d.Tasks.Removed)+len(d.Tasks.Changed) == 0
+}
+
+// StructureImportOptions - параметры PUT /api/courses/:courseId/structure
+type StructureImportOptions struct {
+	// Только посчитать изменения, ничего не меняя
+	DryRun bool
+	// Разрешить удаление задач, по которым уже есть посылки
+	Force bool
+	// Ожидаемый ETag структуры, пусто - без проверки
+	ETag string
+}
+
+// courseStructure собирает структуру из курса и его доски
+func courseStructure(course Course, board TaskBoardSummary) CourseStructure {
+	loc := courseLocation(course)
+
+	s := CourseStructure{
+		Course: StructureCourse{
+			Name:         course.Name,
+			Description:  course.Description,
+			StartDate:    course.StartDate.String(),
+			EndDate:      course.EndDate.String(),
+			Timezone:     timezoneName(course.Timezone),
+			RepoTemplate: course.RepoTemplate,
+		},
+		Policies: StructurePolicies{MaxScore: board.MaxScore},
+		Groups:   []StructureGroup{},
+	}
+
+	for _, group := range board.Groups {
+		sg := StructureGroup{
+			ID:        group.ID,
+			Name:      group.Name,
+			IsSpecial: group.IsSpecial,
+			StartedAt: formatLocal(group.StartedAt, loc),
+			EndsAt:    formatLocal(group.EndsAt, loc),
+			Deadlines: []StructureDeadline{},
+			Tasks:     []StructureTask{},
+		}
+		for _, deadline := range group.Deadlines {
+			sg.Deadlines = append(sg.Deadlines, StructureDeadline{
+				ID: deadline.ID, Label: deadline.Label, Percent: deadline.Percent, DueAt: formatLocal(deadline.DueAt, loc),
+			})
+		}
+		for _, task := range group.Tasks {
+			sg.Tasks = append(sg.Tasks, StructureTask{
+				ID: task.ID, Name: task.Name, Score: task.Score, IsBonus: task.IsBonus, IsSpecial: task.IsSpecial, URL: task.URL,
+			})
+		}
+		s.Groups = append(s.Groups, sg)
+	}
+
+	return s
+}
+
+// parseStructure разбирает YAML; неизвестные поля - ошибка, чтобы опечатка
+// в ключе не терялась молча
+func parseStructure(data []byte) (CourseStructure, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var s CourseStructure
+	if err := dec.Decode(&s); err != nil {
+		if errors.Is(err, io.EOF) {
+			return CourseStructure{}, newAPIError(http.StatusBadRequest, "structure is empty")
+		}
+		return CourseStructure{}, newAPIError(http.StatusBadRequest, "invalid YAML: "+err.Error())
+	}
+	return s, nil
+}
+
+// location - часовой пояс структуры, неверный проверяет Validate
+func (s *CourseStructure) location() *time.Location {
+	loc, err := loadTimezone(s.Course.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Validate проверяет структуру: курс - по правилам PUT, ID групп, дедлайнов
+// и задач уникальны в пределах курса, время разбирается в часовом поясе курса
+func (s *CourseStructure) Validate() []ValidationError {
+	// slug, статус и namespace в структуру не входят, проверяются только ее поля
+	req := PostCourseRequest{
+		Name:         s.Course.Name,
+		Slug:         "structure",
+		Status:       "created",
+		StartDate:    s.Course.StartDate,
+		EndDate:      s.Course.EndDate,
+		Timezone:     s.Course.Timezone,
+		RepoTemplate: s.Course.RepoTemplate,
+		Description:  s.Course.Description,
+		NamespaceID:  "structure",
+	}
+	var course validator
+	req.checkFields(&course)
+
+	v := validator{errs: prefixErrors("course.", course.errs)}
+
+	if s.Policies.MaxScore < 0 {
+		v.add("policies.maxScore", CodeNegative, nil)
+	}
+
+	loc := s.location()
+	groups := map[string]bool{}
+	deadlines := map[string]bool{}
+	tasks := map[string]bool{}
+	for i, group := range s.Groups {
+		field := fmt.Sprintf("groups[%d]", i)
+
+		if v.check(field+".id", group.ID, required) {
+			v.checkUnique(field+".id", group.ID, groups)
+		}
+		v.check(field+".name", group.Name, required, maxLength(maxCourseNameLength))
+		if group.StartedAt != "" {
+			v.checkTime(field+".startedAt", group.StartedAt, loc, false)
+		}
+		if group.EndsAt != "" {
+			v.checkTime(field+".endsAt", group.EndsAt, loc, true)
+		}
+
+		for j, deadline := range group.Deadlines {
+			field := fmt.Sprintf("%s.deadlines[%d]", field, j)
+
+			if v.check(field+".id", deadline.ID, required) {
+				v.checkUnique(field+".id", deadline.ID, deadlines)
+			}
+			v.check(field+".label", deadline.Label, maxLength(maxCourseNameLength))
+			if deadline.Percent <= 0 || deadline.Percent > 1 {
+				v.add(field+".percent", CodeOutOfRange, map[string]string{"range": "(0, 1]"})
+			}
+			if v.check(field+".dueAt", deadline.DueAt, required) {
+				v.checkTime(field+".dueAt", deadline.DueAt, loc, true)
+			}
+		}
+
+		for j, task := range group.Tasks {
+			field := fmt.Sprintf("%s.tasks[%d]", field, j)
+
+			if v.check(field+".id", task.ID, required) {
+				v.checkUnique(field+".id", task.ID, tasks)
+			}
+			v.check(field+".name", task.Name, required, maxLength(maxCourseNameLength))
+			if task.Score < 0 {
+				v.add(field+".score", CodeNegative, nil)
+			}
+		}
+	}
+
+	return v.errs
+}
+
+// normalize приводит проверенную структуру к виду экспорта: время - по часовому
+// поясу курса со смещением. Иначе одна и та же структура, записанная по-разному,
+// давала бы изменения в diff
+func (s *CourseStructure) normalize() {
+	loc := s.location()
+	normalizeTime := func(value string, endOfDay bool) string {
+		if value == "" {
+			return ""
+		}
+		t, _ := parseCourseTime(value, loc, endOfDay)
+		return formatLocal(t, loc)
+	}
+
+	s.Course.Timezone = timezoneName(s.Course.Timezone)
+	for i := range s.Groups {
+		group := &s.Groups[i]
+		group.StartedAt = normalizeTime(group.StartedAt, false)
+		group.EndsAt = normalizeTime(group.EndsAt, true)
+		for j := range group.Deadlines {
+			group.Deadlines[j].DueAt = normalizeTime(group.Deadlines[j].DueAt, true)
+		}
+		if group.Deadlines == nil {
+			group.Deadlines = []StructureDeadline{}
+		}
+		if group.Tasks == nil {
+			group.Tasks = []StructureTask{}
+		}
+	}
+}
+
+// structureTime - время нормализованной структуры, пустое - нулевое
+func structureTime(value string) time.Time {
+	t, _ := time.Parse(time.RFC3339, value)
+	return t.UTC()
+}
+
+// diffStructure сравнивает текущую структуру с новой. Задача, перенесенная
+// в другую группу, считается измененной
+func diffStructure(current, next CourseStructure) StructureDiff {
+	diff := StructureDiff{
+		Course:      []string{},
+		Groups:      StructureChanges{Added: []string{}, Removed: []string{}, Changed: []string{}},
+		Tasks:       StructureChanges{Added: []string{}, Removed: []string{}, Changed: []string{}},
+		ScoredTasks: []string{},
+	}
+
+	if current.Course.Name != next.Course.Name {
+		diff.Course = append(diff.Course, "name")
+	}
+	if current.Course.Description != next.Course.Description {
+		diff.Course = append(diff.Course, "description")
+	}
+	if current.Course.StartDate != next.Course.StartDate {
+		diff.Course = append(diff.Course, "startDate")
+	}
+	if current.Course.EndDate != next.Course.EndDate {
+		diff.Course = append(diff.Course, "endDate")
+	}
+	if current.Course.Timezone != next.Course.Timezone {
+		diff.Course = append(diff.Course, "timezone")
+	}
+	if current.Course.RepoTemplate != next.Course.RepoTemplate {
+		diff.Course = append(diff.Course, "repoTemplate")
+	}
+	if current.Policies.MaxScore != next.Policies.MaxScore {
+		diff.Course = append(diff.Course, "policies.maxScore")
+	}
+
+	diffByID(current.Groups, next.Groups, func(g StructureGroup) string { return g.ID }, &diff.Groups)
+
+	type placedTask struct {
+		StructureTask
+		Group string
+	}
+	collect := func(s CourseStructure) []placedTask {
+		var tasks []placedTask
+		for _, group := range s.Groups {
+			for _, task := range group.Tasks {
+				tasks = append(tasks, placedTask{task, group.ID})
+			}
+		}
+		return tasks
+	}
+	diffByID(collect(current), collect(next), func(t placedTask) string { return t.ID }, &diff.Tasks)
+
+	return diff
+}
+
+// diffByID раскладывает элементы по ID на добавленные, удаленные и измененные
+func diffByID[T any](current, next []T, id func(T) string, changes *StructureChanges) {
+	old := map[string]T{}
+	for _, item := range current {
+		old[id(item)] = item
+	}
+
+	seen := map[string]bool{}
+	for _, item := range next {
+		key := id(item)
+		seen[key] = true
+
+		prev, exists := old[key]
+		switch {
+		case !exists:
+			changes.Added = append(changes.Added, key)
+		case !reflect.DeepEqual(prev, item):
+			changes.Changed = append(changes.Changed, key)
+		}
+	}
+
+	for _, item := range current {
+		if key := id(item); !seen[key] {
+			changes.Removed = append(changes.Removed, key)
+		}
+	}
+}
+
+// applyStructure строит новую доску: баллы и статистика задач и статусы
+// дедлайнов сохраняются по ID, версия измененной группы растет
+func applyStructure(board TaskBoardSummary, course Course, s CourseStructure, changed []string) TaskBoardSummary {
+	groups := map[string]BoardGroup{}
+	tasks := map[string]BoardTask{}
+	deadlines := map[string]BoardDeadline{}
+	for _, group := range board.Groups {
+		groups[group.ID] = group
+		for _, task := range group.Tasks {
+			tasks[task.ID] = task
+		}
+		for _, deadline := range group.Deadlines {
+			deadlines[deadline.ID] = deadline
+		}
+	}
+
+	next := TaskBoardSummary{
+		CourseName:    course.Name,
+		CourseStatus:  course.Status,
+		SolvedScore:   board.SolvedScore,
+		MaxScore:      s.Policies.MaxScore,
+		SolvedPercent: board.SolvedPercent,
+		Groups:        make([]BoardGroup, 0, len(s.Groups)),
+	}
+
+	for _, sg := range s.Groups {
+		group := BoardGroup{
+			ID:        sg.ID,
+			Version:   1,
+			Name:      sg.Name,
+			IsSpecial: sg.IsSpecial,
+			StartedAt: structureTime(sg.StartedAt),
+			EndsAt:    structureTime(sg.EndsAt),
+			Deadlines: make([]BoardDeadline, 0, len(sg.Deadlines)),
+			Tasks:     make([]BoardTask, 0, len(sg.Tasks)),
+		}
+		if prev, exists := groups[sg.ID]; exists {
+			group.Version = prev.Version
+			if slices.Contains(changed, sg.ID) {
+				group.Version++
+			}
+		}
+
+		for _, sd := range sg.Deadlines {
+			status := "active"
+			if prev, exists := deadlines[sd.ID]; exists {
+				status = prev.Status
+			}
+			group.Deadlines = append(group.Deadlines, BoardDeadline{
+				ID: sd.ID, Label: sd.Label, Percent: sd.Percent, DueAt: structureTime(sd.DueAt), Status: status,
+			})
+		}
+
+		for _, st := range sg.Tasks {
+			prev := tasks[st.ID]
+			group.Tasks = append(group.Tasks, BoardTask{
+				ID: st.ID, Name: st.Name, Score: st.Score, ScoreEarned: prev.ScoreEarned, Stats: prev.Stats,
+				IsBonus: st.IsBonus, IsSpecial: st.IsSpecial, URL: st.URL,
+			})
+		}
+
+		next.Groups = append(next.Groups, group)
+	}
+
+	return next
+}
+
+// scoredTasks - задачи курса из списка, по которым есть неархивные посылки
+// или баллы на доске
+func scoredTasks(courseID string, board TaskBoardSummary, taskIDs []string) []string {
+	scored := []string{}
+	add := func(taskID string) {
+		if slices.Contains(taskIDs, taskID) && !slices.Contains(scored, taskID) {
+			scored = append(scored, taskID)
+		}
+	}
+
+	for _, group := range board.Groups {
+		for _, task := range group.Tasks {
+			if task.ScoreEarned > 0 {
+				add(task.ID)
+			}
+		}
+	}
+
+	submissionMu.RLock()
+	for _, submission := range submissionDB {
+		if submission.CourseID == courseID && !submission.Archived {
+			add(submission.TaskID)
+		}
+	}
+	submissionMu.RUnlock()
+
+	slices.Sort(scored)
+	return scored
+}
+
+// ExportCourseStructure отдает структуру курса и ее ETag. Доступно
+// преподавателям курса
+func ExportCourseStructure(actor User, courseID string) (CourseStructure, string, error) {
+	course, err := visibleCourse(actor, courseID)
+	if err != nil {
+		return CourseStructure{}, "", err
+	}
+	if !canTeachCourse(actor, course) {
+		return CourseStructure{}, "", errForbidden
+	}
+
+	boardMu.RLock()
+	board := boardData[courseID]
+	boardMu.RUnlock()
+
+	s := courseStructure(course, board)
+	return s, contentETag(s), nil
+}
+
+// ImportCourseStructure заменяет структуру курса присланной и возвращает
+// изменения. С DryRun только считает их. Удаление задачи с посылками или баллами
+// требует Force, посылки по ней при этом архивируются. Доступно владельцам курса и
+// админам namespace
+func ImportCourseStructure(actor User, courseID string, data []byte, opts StructureImportOptions) (StructureDiff, error) {
+	next, err := parseStructure(data)
+	if err != nil {
+		return StructureDiff{}, err
+	}
+
+	course, err := editableCourse(actor, courseID)
+	if err != nil {
+		return StructureDiff{}, err
+	}
+
+	errs := next.Validate()
+	if next.Course.RepoTemplate == course.RepoTemplate {
+		// шаблон, сохраненный до проверки адресов, можно не менять, как и в PUT
+		errs = slices.DeleteFunc(errs, func(e ValidationError) bool { return e.Code == CodeRepoURL })
+	}
+	if len(errs) > 0 {
+		return StructureDiff{}, validationFailed(errs)
+	}
+	next.normalize()
+
+	courseMu.Lock()
+	defer courseMu.Unlock()
+	boardMu.Lock()
+	defer boardMu.Unlock()
+
+	course, exists := courseDB[courseID]
+	if !exists {
+		return StructureDiff{}, newAPIError(http.StatusNotFound, "course not found")
+	}
+	board, hasBoard := boardData[courseID]
+	current := courseStructure(course, board)
+	if opts.ETag != "" && opts.ETag != contentETag(current) {
+		return StructureDiff{}, errPreconditionFailed
+	}
+
+	diff := diffStructure(current, next)
+	diff.DryRun = opts.DryRun
+	diff.ScoredTasks = scoredTasks(courseID, board, diff.Tasks.Removed)
+
+	if opts.DryRun || diff.empty() {
+		return diff, nil
+	}
+	if len(diff.ScoredTasks) > 0 && !opts.Force {
+		return StructureDiff{}, &apiError{
+			Status:  http.StatusConflict,
+			Message: "removed tasks already have scores, retry with force=true to archive their submissions",
+			Details: []ValidationError{{Field: "tasks", Message: strings.Join(diff.ScoredTasks, ", ")}},
+		}
+	}
+
+	if len(diff.Course) > 0 {
+		course.Name = next.Course.Name
+		course.Description = next.Course.Description
+		course.StartDate, _ = ParseDate(next.Course.StartDate)
+		course.EndDate, _ = ParseDate(next.Course.EndDate)
+		course.Timezone = next.Course.Timezone
+		course.RepoTemplate = next.Course.RepoTemplate
+		course.Version++
+		courseDB[courseID] = course
+	}
+	if hasBoard || len(next.Groups) > 0 {
+		boardData[courseID] = applyStructure(board, course, next, diff.Groups.Changed)
+	}
+
+	// Посылки не удаляются: если задачу с тем же ID вернуть в структуру,
+	// ее посылки снова попадут в ведомость
+	submissionMu.Lock()
+	for i, submission := range submissionDB {
+		if submission.CourseID != courseID {
+			continue
+		}
+		switch {
+		case slices.Contains(diff.ScoredTasks, submission.TaskID):
+			submissionDB[i].Archived = true
+		case slices.Contains(diff.Tasks.Added, submission.TaskID):
+			submissionDB[i].Archived = false
+		}
+	}
+	submissionMu.Unlock()
+
+	details := map[string]string{}
+	for key, ids := range map[string][]string{
+		"tasksAdded":     diff.Tasks.Added,
+		"tasksRemoved":   diff.Tasks.Removed,
+		"tasksChanged":   diff.Tasks.Changed,
+		"scoresArchived": diff.ScoredTasks,
+	} {
+		if len(ids) > 0 {
+			details[key] = strings.Join(ids, ",")
+		}
+	}
+	recordAudit(actor, "course.structure", courseID, details)
+
+	return diff, nil
+}
+
+// Хендлеры
+
+// GET /api/courses/:courseId/structure
+// Отдает YAML с ETag, If-None-Match поддерживается
+func GetCourseStructureHandler(c echo.Context) error {
+	actor, ok := currentUser(c)
+	if !ok {
+		return writeError(c, errUnauthorized)
+	}
+
+	s, etag, err := ExportCourseStructure(actor, c.Param("courseId"))
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	if notModified(c, etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(s); err != nil {
+		return writeError(c, err)
+	}
+	return c.Blob(http.StatusOK, "application/yaml", buf.Bytes())
+}
+
+// PUT /api/courses/:courseId/structure?dryRun=true&force=true
+// Тело - YAML из GET. Без dryRun требует If-Match с ETag структуры
+func PutCourseStructureHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var opts StructureImportOptions
+	for name, flag := range map[string]*bool{"dryRun": &opts.DryRun, "force": &opts.Force} {
+		if value := c.QueryParam(name); value != "" {
+			if *flag, err = strconv.ParseBool(value); err != nil {
+				return writeError(c, validationFailed([]ValidationError{newValidationError(name, CodeInvalidType, map[string]string{"type": "boolean"})}))
+			}
+		}
+	}
+
+	if !opts.DryRun {
+		switch value := strings.TrimSpace(c.Request().Header.Get("If-Match")); value {
+		case "":
+			return writeError(c, errPreconditionRequired)
+		case "*":
+		default:
+			opts.ETag = value
+		}
+	}
+
+	data, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+
+	diff, err := ImportCourseStructure(actor, c.Param("courseId"), data, opts)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	if !opts.DryRun {
+		if _, etag, err := ExportCourseStructure(actor, c.Param("courseId")); err == nil {
+			c.Response().Header().Set("ETag", etag)
+		}
+	}
+	return c.JSON(http.StatusOK, diff)
+}
@@ -0,0 +1,373 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func setupEchoStructure() *echo.Echo {
+	e := echo.New()
+	e.GET("/api/courses/:courseId/structure", GetCourseStructureHandler)
+	e.PUT("/api/courses/:courseId/structure", PutCourseStructureHandler)
+	return e
+}
+
+// exportStructure - YAML и ETag структуры курса algorithms
+func exportStructure(t *testing.T, e *echo.Echo) (string, string) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses/algorithms/structure", "nsadmin-token", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	return rec.Body.String(), rec.Header().Get("ETag")
+}
+
+func putStructure(e *echo.Echo, query, ifMatch, body string) *httptest.ResponseRecorder {
+	req := authReq(http.MethodPut, "/api/courses/algorithms/structure"+query, "nsadmin-token", []byte(body))
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func structureDiff(t *testing.T, rec *httptest.ResponseRecorder) StructureDiff {
+	t.Helper()
+
+	var diff StructureDiff
+	if err := json.Unmarshal(rec.Body.Bytes(), &diff); err != nil {
+		t.Fatal(err)
+	}
+	return diff
+}
+
+const algorithmsStructure = `course:
+  name: Algorithms
+  description: test
+  startDate: "2024-01-01"
+  endDate: "2024-02-01"
+  timezone: UTC
+  repoTemplate: git@test:repo.git
+policies:
+  maxScore: 200
+groups:
+  - id: week-1
+    name: 'Week 1: Warmup'
+    startedAt: "2024-10-01T09:00:00Z"
+    endsAt: "2024-10-14T18:00:00Z"
+    deadlines:
+      - id: d1
+        label: Checkpoint
+        percent: 0.6
+        dueAt: "2024-09-20T18:00:00Z"
+    tasks:
+      - id: t1
+        name: Arrays Sprint
+        score: 20
+`
+
+func TestExportCourseStructure(t *testing.T) {
+	resetTrashDB()
+	e := setupEchoStructure()
+
+	body, etag := exportStructure(t, e)
+	if body != algorithmsStructure {
+		t.Errorf("unexpected structure:\n%s", body)
+	}
+	if etag == "" {
+		t.Fatal("ETag is missing")
+	}
+
+	req := authReq(http.MethodGet, "/api/courses/algorithms/structure", "nsadmin-token", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected 304, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses/algorithms/structure", "student-token", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("student: expected 403, got %d", rec.Code)
+	}
+}
+
+func TestImportCourseStructure_RoundTripIsNoop(t *testing.T) {
+	resetTrashDB()
+	e := setupEchoStructure()
+
+	body, etag := exportStructure(t, e)
+	rec := putStructure(e, "", etag, body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if diff := structureDiff(t, rec); !diff.empty() {
+		t.Errorf("expected empty diff, got %+v", diff)
+	}
+	if course, _ := getCourse("algorithms"); course.Version != 1 {
+		t.Errorf("unchanged course must keep its version, got %d", course.Version)
+	}
+}
+
+func TestImportCourseStructure_DryRunAndApply(t *testing.T) {
+	resetTrashDB()
+	e := setupEchoStructure()
+	_, etag := exportStructure(t, e)
+
+	next := strings.NewReplacer(
+		"name: Algorithms\n", "name: Algorithms 2\n",
+		"score: 20\n", "score: 25\n      - id: t3\n        name: Graphs\n        score: 30\n        isBonus: true\n",
+	).Replace(algorithmsStructure) + `  - id: week-2
+    name: Week 2
+    deadlines: []
+    tasks:
+      - id: t4
+        name: Trees
+        score: 10
+`
+
+	rec := putStructure(e, "?dryRun=true", "", next)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("dry run: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	diff := structureDiff(t, rec)
+	if !diff.DryRun || strings.Join(diff.Course, ",") != "name" ||
+		strings.Join(diff.Groups.Added, ",") != "week-2" || strings.Join(diff.Groups.Changed, ",") != "week-1" ||
+		strings.Join(diff.Tasks.Added, ",") != "t3,t4" || strings.Join(diff.Tasks.Changed, ",") != "t1" || len(diff.Tasks.Removed) != 0 {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+	if course, _ := getCourse("algorithms"); course.Name != "Algorithms" {
+		t.Fatal("dry run must not change the course")
+	}
+
+	rec = putStructure(e, "", etag, next)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("apply: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("ETag") == etag {
+		t.Error("ETag must change")
+	}
+
+	course, _ := getCourse("algorithms")
+	if course.Name != "Algorithms 2" || course.Version != 2 {
+		t.Errorf("course not updated: %+v", course)
+	}
+	boardMu.RLock()
+	board := boardData["algorithms"]
+	boardMu.RUnlock()
+	if len(board.Groups) != 2 || board.CourseName != "Algorithms 2" || board.Groups[0].Version != 2 || board.Groups[1].Version != 1 {
+		t.Fatalf("board not updated: %+v", board)
+	}
+	tasks := board.Groups[0].Tasks
+	if tasks[0].Score != 25 || tasks[0].ScoreEarned != 20 || !tasks[1].IsBonus || tasks[1].ScoreEarned != 0 {
+		t.Errorf("tasks not merged: %+v", tasks)
+	}
+	if board.Groups[0].Deadlines[0].Status != "expired" {
+		t.Errorf("deadline status must be kept: %+v", board.Groups[0].Deadlines[0])
+	}
+
+	// старый ETag больше не подходит
+	if rec := putStructure(e, "", etag, algorithmsStructure); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("stale ETag: expected 412, got %d", rec.Code)
+	}
+	if rec := putStructure(e, "", "", algorithmsStructure); rec.Code != http.StatusPreconditionRequired {
+		t.Errorf("no If-Match: expected 428, got %d", rec.Code)
+	}
+}
+
+func TestImportCourseStructure_LocalTimes(t *testing.T) {
+	resetTrashDB()
+	e := setupEchoStructure()
+	_, etag := exportStructure(t, e)
+
+	next := strings.NewReplacer(
+		"timezone: UTC", "timezone: Europe/Moscow",
+		`endsAt: "2024-10-14T18:00:00Z"`, `endsAt: "2024-10-14"`,
+		`dueAt: "2024-09-20T18:00:00Z"`, `dueAt: "2024-09-20T21:00"`,
+	).Replace(algorithmsStructure)
+
+	rec := putStructure(e, "", etag, next)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	course, _ := getCourse("algorithms")
+	group := boardData["algorithms"].Groups[0]
+	if course.Timezone != "Europe/Moscow" {
+		t.Errorf("timezone = %q", course.Timezone)
+	}
+	// дата без времени - конец дня по Москве, местное время - московское
+	if !group.EndsAt.Equal(mustTime("2024-10-14T20:59:59Z")) || !group.Deadlines[0].DueAt.Equal(mustTime("2024-09-20T18:00:00Z")) {
+		t.Errorf("unexpected times: endsAt %s, dueAt %s", group.EndsAt, group.Deadlines[0].DueAt)
+	}
+
+	body, _ := exportStructure(t, e)
+	for _, line := range []string{`endsAt: "2024-10-14T23:59:59+03:00"`, `dueAt: "2024-09-20T21:00:00+03:00"`} {
+		if !strings.Contains(body, line) {
+			t.Errorf("export must show local time %s:\n%s", line, body)
+		}
+	}
+}
+
+func TestImportCourseStructure_RemovingScoredTaskNeedsForce(t *testing.T) {
+	resetTrashDB()
+	e := setupEchoStructure()
+
+	// у t1 есть посылки студента
+	without := strings.Replace(algorithmsStructure, `    tasks:
+      - id: t1
+        name: Arrays Sprint
+        score: 20
+`, "    tasks: []\n", 1)
+
+	rec := putStructure(e, "?dryRun=1", "", without)
+	if diff := structureDiff(t, rec); strings.Join(diff.Tasks.Removed, ",") != "t1" || strings.Join(diff.ScoredTasks, ",") != "t1" {
+		t.Fatalf("unexpected dry run diff: %+v", diff)
+	}
+
+	rec = putStructure(e, "", "*", without)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rows := gradebook(t.Context(), "algorithms"); rows[0].Score != 11 {
+		t.Fatalf("scores must be kept without force: %+v", rows)
+	}
+
+	rec = putStructure(e, "?force=true", "*", without)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rows := gradebook(t.Context(), "algorithms"); rows[0].Score != 3 {
+		t.Errorf("scores of the removed task must leave the gradebook: %+v", rows)
+	}
+	if last := auditLog[len(auditLog)-1]; last.Action != "course.structure" || last.Details["scoresArchived"] != "t1" {
+		t.Errorf("unexpected audit entry: %+v", last)
+	}
+
+	// посылки не удаляются, а архивируются
+	archived := 0
+	for _, submission := range submissionDB {
+		if submission.CourseID == "algorithms" && submission.TaskID == "t1" {
+			if !submission.Archived {
+				t.Errorf("submission must be archived: %+v", submission)
+			}
+			archived++
+		}
+	}
+	if archived == 0 {
+		t.Fatal("submissions of the removed task must be kept")
+	}
+
+	// задача вернулась - ее посылки снова в ведомости
+	rec = putStructure(e, "", "*", algorithmsStructure)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rows := gradebook(t.Context(), "algorithms"); rows[0].Score != 11 {
+		t.Errorf("restored task must count again: %+v", rows)
+	}
+}
+
+func TestImportCourseStructure_BoardScoresCountAsScored(t *testing.T) {
+	resetTrashDB()
+	e := setupEchoStructure()
+
+	// посылок нет, но на доске по t1 уже набраны баллы
+	submissionMu.Lock()
+	submissionDB = []Submission{}
+	submissionMu.Unlock()
+
+	without := strings.Replace(algorithmsStructure, `    tasks:
+      - id: t1
+        name: Arrays Sprint
+        score: 20
+`, "    tasks: []\n", 1)
+
+	rec := putStructure(e, "?dryRun=1", "", without)
+	if diff := structureDiff(t, rec); strings.Join(diff.ScoredTasks, ",") != "t1" {
+		t.Fatalf("task with earned score must be reported: %+v", diff)
+	}
+	if rec := putStructure(e, "", "*", without); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	boardMu.Lock()
+	board := boardData["algorithms"]
+	board.Groups[0].Tasks[0].ScoreEarned = 0
+	boardData["algorithms"] = board
+	boardMu.Unlock()
+
+	if diff := structureDiff(t, putStructure(e, "?dryRun=1", "", without)); len(diff.ScoredTasks) != 0 {
+		t.Errorf("task without scores must not be reported: %+v", diff)
+	}
+}
+
+func TestImportCourseStructure_Invalid(t *testing.T) {
+	cases := []struct {
+		name   string
+		body   string
+		want   int
+		fields []string
+	}{
+		{"not YAML", "course: [", http.StatusBadRequest, nil},
+		{"unknown field", strings.Replace(algorithmsStructure, "maxScore", "maxScores", 1), http.StatusBadRequest, nil},
+		{"empty", "", http.StatusBadRequest, nil},
+		{
+			"invalid values",
+			strings.NewReplacer(
+				`endDate: "2024-02-01"`, `endDate: "2023-02-01"`,
+				"percent: 0.6", "percent: 60",
+				`dueAt: "2024-09-20T18:00:00Z"`, `dueAt: "20.09.2024"`,
+				"id: t1", "id: ''",
+			).Replace(algorithmsStructure),
+			http.StatusBadRequest,
+			[]string{"course.dateRange", "groups[0].deadlines[0].percent", "groups[0].deadlines[0].dueAt", "groups[0].tasks[0].id"},
+		},
+		{
+			"duplicate task",
+			algorithmsStructure + "  - id: week-2\n    name: Week 2\n    tasks:\n      - {id: t1, name: Copy, score: 1}\n",
+			http.StatusBadRequest,
+			[]string{"groups[1].tasks[0].id"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resetTrashDB()
+			e := setupEchoStructure()
+
+			rec := putStructure(e, "?dryRun=true", "", tc.body)
+			if rec.Code != tc.want {
+				t.Fatalf("expected %d, got %d: %s", tc.want, rec.Code, rec.Body.String())
+			}
+			if tc.fields != nil && strings.Join(errorFields(t, rec), ",") != strings.Join(tc.fields, ",") {
+				t.Errorf("expected fields %v, got %v", tc.fields, errorFields(t, rec))
+			}
+		})
+	}
+}
+
+func TestImportCourseStructure_Permissions(t *testing.T) {
+	resetTrashDB()
+	e := setupEchoStructure()
+
+	for _, token := range []string{"student-token", "pm-token"} {
+		req := authReq(http.MethodPut, "/api/courses/algorithms/structure", token, []byte(algorithmsStructure))
+		req.Header.Set("If-Match", "*")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d", token, rec.Code)
+		}
+	}
+}
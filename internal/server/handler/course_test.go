@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/labstack/echo/v4"
@@ -19,6 +21,7 @@ func setupEcho() *echo.Echo {
 	api.GET("/courses/:courseId", GetCourseHandler)
 	api.POST("/courses", CreateCourseHandler)
 	api.PUT("/courses/:courseId", UpdateCourseHandler)
+	api.PATCH("/courses/:courseId", PatchCourseHandler)
 
 	return e
 }
@@ -30,30 +33,43 @@ func plainReq(method, path string, body []byte) *http.Request {
 	return req
 }
 
+// editReq - изменяющий запрос инстанс-админа с If-Match первой версии курса
+func editReq(method, path string, body []byte) *http.Request {
+	req := authReq(method, path, "admin-token", body)
+	req.Header.Set("If-Match", `"1"`)
+	return req
+}
+
 func resetDB() {
+	resetUsers()
+
 	courseMu.Lock()
 	defer courseMu.Unlock()
 
+	trashDB = map[string]Course{}
+	courseAliases = map[string]string{}
 	courseDB = map[string]Course{
 		"algorithms": {
 			ID:           "algorithms",
 			Name:         "Algorithms",
 			Status:       "created",
-			StartDate:    "2024-01-01",
-			EndDate:      "2024-02-01",
-			RepoTemplate: "git@test/repo.git",
+			StartDate:    mustDate("2024-01-01"),
+			EndDate:      mustDate("2024-02-01"),
+			RepoTemplate: "git@test:repo.git",
 			Description:  "test",
 			URL:          "/course/algorithms",
+			Version:      1,
 		},
 		"hidden": {
 			ID:           "hidden",
 			Name:         "Hidden",
 			Status:       "hidden",
-			StartDate:    "2024-01-01",
-			EndDate:      "2024-02-01",
-			RepoTemplate: "git@test/repo.git",
+			StartDate:    mustDate("2024-01-01"),
+			EndDate:      mustDate("2024-02-01"),
+			RepoTemplate: "git@test:repo.git",
 			Description:  "hidden",
 			URL:          "/course/hidden",
+			Version:      1,
 		},
 	}
 }
@@ -87,7 +103,7 @@ func TestGetCourses_EmptyFilterResult(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
-	req := plainReq(http.MethodGet, "/api/courses?status=finished", nil)
+	req := authReq(http.MethodGet, "/api/courses?status=finished", "admin-token", nil)
 	rec := httptest.NewRecorder()
 	e.ServeHTTP(rec, req)
 
@@ -103,7 +119,7 @@ func TestGetCourses(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
-	req := plainReq(http.MethodGet, "/api/courses", nil)
+	req := authReq(http.MethodGet, "/api/courses", "admin-token", nil)
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
@@ -116,7 +132,7 @@ func TestGetCourses_Filter(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
-	req := plainReq(http.MethodGet, "/api/courses?status=hidden", nil)
+	req := authReq(http.MethodGet, "/api/courses?status=hidden", "admin-token", nil)
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
@@ -133,7 +149,7 @@ func TestGetCourses_NoFilterAllVisible(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
-	req := plainReq(http.MethodGet, "/api/courses", nil)
+	req := authReq(http.MethodGet, "/api/courses", "admin-token", nil)
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
@@ -150,7 +166,7 @@ func TestGetCourse_OK(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
-	req := plainReq(http.MethodGet, "/api/courses/algorithms", nil)
+	req := authReq(http.MethodGet, "/api/courses/algorithms", "admin-token", nil)
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
@@ -163,7 +179,7 @@ func TestGetCourse_NotFound(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
-	req := plainReq(http.MethodGet, "/api/courses/unknown", nil)
+	req := authReq(http.MethodGet, "/api/courses/unknown", "admin-token", nil)
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
@@ -173,19 +189,20 @@ func TestGetCourse_NotFound(t *testing.T) {
 }
 
 func TestCreateCourse_EmptyRepoTemplate(t *testing.T) {
-	resetDB()
+	resetNamespaceDB()
 	e := setupEcho()
 
 	body := []byte(`{
 		"name":"Test",
 		"slug":"test",
+		"namespaceId":"ns-01",
 		"status":"created",
 		"startDate":"2025-01-01",
 		"endDate":"2025-02-01",
 		"description":"x"
 	}`)
 
-	req := plainReq(http.MethodPost, "/api/courses", body)
+	req := authReq(http.MethodPost, "/api/courses", "admin-token", body)
 	rec := httptest.NewRecorder()
 	e.ServeHTTP(rec, req)
 
@@ -195,20 +212,21 @@ func TestCreateCourse_EmptyRepoTemplate(t *testing.T) {
 }
 
 func TestCreateCourse_Success(t *testing.T) {
-	resetDB()
+	resetNamespaceDB()
 	e := setupEcho()
 
 	body := []byte(`{
 		"name":"Go Course",
 		"slug":"go-course",
+		"namespaceId":"ns-01",
 		"status":"created",
 		"startDate":"2024-03-01",
 		"endDate":"2024-04-01",
-		"repoTemplate":"git@test/go.git",
+		"repoTemplate":"git@test:go.git",
 		"description":"Go basics"
 	}`)
 
-	req := plainReq(http.MethodPost, "/api/courses", body)
+	req := authReq(http.MethodPost, "/api/courses", "admin-token", body)
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
@@ -217,11 +235,24 @@ func TestCreateCourse_Success(t *testing.T) {
 	}
 }
 
+func TestCreateCourse_DescriptionOptional(t *testing.T) {
+	resetNamespaceDB()
+	e := setupEcho()
+
+	body := []byte(`{"name":"Test","slug":"test","namespaceId":"ns-01","status":"created","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a:repo.git"}`)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/courses", "admin-token", body))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
 func TestCreateCourse_ValidationError(t *testing.T) {
-	resetDB()
+	resetNamespaceDB()
 	e := setupEcho()
 
-	req := plainReq(http.MethodPost, "/api/courses", []byte(`{"slug":"a"}`))
+	req := authReq(http.MethodPost, "/api/courses", "admin-token", []byte(`{"slug":"a"}`))
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
@@ -231,20 +262,21 @@ func TestCreateCourse_ValidationError(t *testing.T) {
 }
 
 func TestCreateCourse_Conflict(t *testing.T) {
-	resetDB()
+	resetNamespaceDB()
 	e := setupEcho()
 
 	body := []byte(`{
 		"name":"Algorithms",
 		"slug":"algorithms",
+		"namespaceId":"ns-01",
 		"status":"created",
 		"startDate":"2024-01-01",
 		"endDate":"2024-02-01",
-		"repoTemplate":"git@test",
+		"repoTemplate":"git@test:repo.git",
 		"description":"dup"
 	}`)
 
-	req := plainReq(http.MethodPost, "/api/courses", body)
+	req := authReq(http.MethodPost, "/api/courses", "admin-token", body)
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
@@ -254,20 +286,21 @@ func TestCreateCourse_Conflict(t *testing.T) {
 }
 
 func TestCreateCourse_InvalidDateRange(t *testing.T) {
-	resetDB()
+	resetNamespaceDB()
 	e := setupEcho()
 
 	body := []byte(`{
 		"name":"Bad",
 		"slug":"bad-course",
+		"namespaceId":"ns-01",
 		"status":"created",
 		"startDate":"2024-02-01",
 		"endDate":"2024-01-01",
-		"repoTemplate":"git@test",
+		"repoTemplate":"git@test:repo.git",
 		"description":"x"
 	}`)
 
-	req := plainReq(http.MethodPost, "/api/courses", body)
+	req := authReq(http.MethodPost, "/api/courses", "admin-token", body)
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
@@ -277,7 +310,7 @@ func TestCreateCourse_InvalidDateRange(t *testing.T) {
 }
 
 func TestCreateCourse_MissingRequiredFields(t *testing.T) {
-	resetDB()
+	resetNamespaceDB()
 	e := setupEcho()
 
 	cases := []struct {
@@ -285,18 +318,19 @@ func TestCreateCourse_MissingRequiredFields(t *testing.T) {
 		body         string
 		wantErrField string
 	}{
-		{"no name", `{"slug":"test","status":"created","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a","description":"x"}`, "name"},
-		{"no slug", `{"name":"Test","status":"created","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a","description":"x"}`, "slug"},
-		{"no status", `{"name":"Test","slug":"test","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a","description":"x"}`, "status"},
-		{"no repoTemplate", `{"name":"Test","slug":"test","status":"created","startDate":"2025-01-01","endDate":"2025-02-01","description":"x"}`, "repoTemplate"},
-		{"no description", `{"name":"Test","slug":"test","status":"created","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a"}`, "description"},
-		{"no startDate", `{"name":"Test","slug":"test","status":"created","endDate":"2025-02-01","repoTemplate":"git@a","description":"x"}`, "startDate"},
-		{"no endDate", `{"name":"Test","slug":"test","status":"created","startDate":"2025-01-01","repoTemplate":"git@a","description":"x"}`, "endDate"},
+		{"no name", `{"slug":"test","namespaceId":"ns-01","status":"created","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a:repo.git","description":"x"}`, "name"},
+		{"no slug", `{"name":"Test","status":"created","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a:repo.git","description":"x"}`, "slug"},
+		{"no status", `{"name":"Test","slug":"test","namespaceId":"ns-01","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a:repo.git","description":"x"}`, "status"},
+		{"no repoTemplate", `{"name":"Test","slug":"test","namespaceId":"ns-01","status":"created","startDate":"2025-01-01","endDate":"2025-02-01","description":"x"}`, "repoTemplate"},
+		{"no startDate", `{"name":"Test","slug":"test","namespaceId":"ns-01","status":"created","endDate":"2025-02-01","repoTemplate":"git@a:repo.git","description":"x"}`, "startDate"},
+		{"no endDate", `{"name":"Test","slug":"test","namespaceId":"ns-01","status":"created","startDate":"2025-01-01","repoTemplate":"git@a:repo.git","description":"x"}`, "endDate"},
+		{"no namespaceId", `{"name":"Test","slug":"test","status":"created","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a:repo.git","description":"x"}`, "namespaceId"},
+		{"unknown owner", `{"name":"Test","slug":"test","namespaceId":"ns-01","status":"created","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a:repo.git","description":"x","owners":["ghost"]}`, "owners"},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			req := plainReq(http.MethodPost, "/api/courses", []byte(tc.body))
+			req := authReq(http.MethodPost, "/api/courses", "admin-token", []byte(tc.body))
 			rec := httptest.NewRecorder()
 			e.ServeHTTP(rec, req)
 
@@ -326,7 +360,7 @@ func TestCreateCourse_MissingRequiredFields(t *testing.T) {
 }
 
 func TestCreateCourse_InvalidDates(t *testing.T) {
-	resetDB()
+	resetNamespaceDB()
 	e := setupEcho()
 
 	badDates := []struct {
@@ -341,8 +375,8 @@ func TestCreateCourse_InvalidDates(t *testing.T) {
 
 	for _, tc := range badDates {
 		t.Run(tc.name, func(t *testing.T) {
-			body := fmt.Sprintf(`{"name":"Test","slug":"test","status":"created","startDate":"%s","endDate":"%s","repoTemplate":"git@a","description":"x"}`, tc.startDate, tc.endDate)
-			req := plainReq(http.MethodPost, "/api/courses", []byte(body))
+			body := fmt.Sprintf(`{"name":"Test","slug":"test","namespaceId":"ns-01","status":"created","startDate":"%s","endDate":"%s","repoTemplate":"git@a:repo.git","description":"x"}`, tc.startDate, tc.endDate)
+			req := authReq(http.MethodPost, "/api/courses", "admin-token", []byte(body))
 			rec := httptest.NewRecorder()
 			e.ServeHTTP(rec, req)
 
@@ -354,11 +388,11 @@ func TestCreateCourse_InvalidDates(t *testing.T) {
 }
 
 func TestCreateCourse_InvalidStatus(t *testing.T) {
-	resetDB()
+	resetNamespaceDB()
 	e := setupEcho()
 
-	body := []byte(`{"name":"Test","slug":"test","status":"invalid","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a","description":"x"}`)
-	req := plainReq(http.MethodPost, "/api/courses", body)
+	body := []byte(`{"name":"Test","slug":"test","namespaceId":"ns-01","status":"invalid","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a:repo.git","description":"x"}`)
+	req := authReq(http.MethodPost, "/api/courses", "admin-token", body)
 	rec := httptest.NewRecorder()
 	e.ServeHTTP(rec, req)
 
@@ -368,11 +402,11 @@ func TestCreateCourse_InvalidStatus(t *testing.T) {
 }
 
 func TestCreateCourse_InvalidJSON(t *testing.T) {
-	resetDB()
+	resetNamespaceDB()
 	e := setupEcho()
 
 	body := []byte(`{ "name": "test"`) // malformed
-	req := plainReq(http.MethodPost, "/api/courses", body)
+	req := authReq(http.MethodPost, "/api/courses", "admin-token", body)
 	rec := httptest.NewRecorder()
 	e.ServeHTTP(rec, req)
 
@@ -382,22 +416,23 @@ func TestCreateCourse_InvalidJSON(t *testing.T) {
 }
 
 func TestCreateCourse_ExtraFieldsIgnored(t *testing.T) {
-	resetDB()
+	resetNamespaceDB()
 	e := setupEcho()
 
 	body := []byte(`{
 		"name":"Extra",
 		"slug":"extra",
+		"namespaceId":"ns-01",
 		"status":"created",
 		"startDate":"2024-03-01",
 		"endDate":"2024-04-01",
-		"repoTemplate":"git@test",
+		"repoTemplate":"git@test:repo.git",
 		"description":"test",
 		"id":"should-ignore",
 		"url":"should-ignore"
 	}`)
 
-	req := plainReq(http.MethodPost, "/api/courses", body)
+	req := authReq(http.MethodPost, "/api/courses", "admin-token", body)
 	rec := httptest.NewRecorder()
 	e.ServeHTTP(rec, req)
 
@@ -415,56 +450,126 @@ func TestCreateCourse_ExtraFieldsIgnored(t *testing.T) {
 	}
 }
 
-func TestUpdateCourse_UpdateRepoTemplate(t *testing.T) {
+// algorithmsBody - полное тело PUT для курса algorithms из resetDB
+const algorithmsBody = `{"name":"Algorithms","slug":"algorithms","status":"created","startDate":"2024-01-01","endDate":"2024-02-01","repoTemplate":"git@test:repo.git","description":"test"}`
+
+// withFields - algorithmsBody с замененными полями
+func withFields(t *testing.T, fields map[string]any) []byte {
+	t.Helper()
+	var body map[string]any
+	json.Unmarshal([]byte(algorithmsBody), &body)
+	for key, value := range fields {
+		body[key] = value
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// errorFields - поля из details ответа с ошибкой валидации
+func errorFields(t *testing.T, rec *httptest.ResponseRecorder) []string {
+	t.Helper()
+	var resp struct {
+		Details []ValidationError `json:"details"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	fields := make([]string, 0, len(resp.Details))
+	for _, detail := range resp.Details {
+		fields = append(fields, detail.Field)
+	}
+	return fields
+}
+
+func TestUpdateCourse_AllFields(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
-	body := []byte(`{"repoTemplate":"git@updated"}`)
-	req := plainReq(http.MethodPut, "/api/courses/algorithms", body)
+	body := []byte(`{
+		"name":"Updated",
+		"slug":"algorithms",
+		"status":"finished",
+		"startDate":"2024-01-10",
+		"endDate":"2024-02-10",
+		"repoTemplate":"git@new:repo.git",
+		"description":"updated",
+		"owners":["pm"]
+	}`)
+
+	req := editReq(http.MethodPut, "/api/courses/algorithms", body)
 	rec := httptest.NewRecorder()
+
 	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
 
-	var updated Course
-	json.Unmarshal(rec.Body.Bytes(), &updated)
+	courseMu.RLock()
+	course := courseDB["algorithms"]
+	courseMu.RUnlock()
 
-	if updated.RepoTemplate != "git@updated" {
-		t.Fatalf("repoTemplate not updated")
+	if course.Name != "Updated" || course.Status != "finished" || course.StartDate != mustDate("2024-01-10") ||
+		course.EndDate != mustDate("2024-02-10") || course.RepoTemplate != "git@new:repo.git" || course.Description != "updated" ||
+		len(course.Owners) != 1 || course.Owners[0] != "pm" {
+		t.Fatalf("course not replaced: %+v", course)
+	}
+	if course.URL != "/course/algorithms" {
+		t.Errorf("url should not change, got %q", course.URL)
 	}
 }
 
-func TestUpdateCourse_DateRangeValidAfterPartial(t *testing.T) {
+func TestUpdateCourse_OmittedFieldsCleared(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
-	body := []byte(`{"endDate":"2024-03-01"}`)
-	req := plainReq(http.MethodPut, "/api/courses/algorithms", body)
+	courseMu.Lock()
+	course := courseDB["algorithms"]
+	course.Owners = []string{"pm"}
+	courseDB["algorithms"] = course
+	courseMu.Unlock()
+
+	body := []byte(`{"name":"Algorithms","slug":"algorithms","status":"created","startDate":"2024-01-01","endDate":"2024-02-01","repoTemplate":"git@test:repo.git"}`)
 	rec := httptest.NewRecorder()
-	e.ServeHTTP(rec, req)
+	e.ServeHTTP(rec, editReq(http.MethodPut, "/api/courses/algorithms", body))
 
 	if rec.Code != http.StatusOK {
-		t.Fatalf("expected 200, got %d", rec.Code)
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var updated Course
+	json.Unmarshal(rec.Body.Bytes(), &updated)
+
+	if updated.Description != "" {
+		t.Errorf("description should be cleared, got %q", updated.Description)
+	}
+	if updated.Owners == nil || len(updated.Owners) != 0 {
+		t.Errorf("owners should be cleared, got %v", updated.Owners)
 	}
 }
 
-func TestUpdateCourse_AllFields(t *testing.T) {
+func TestUpdateCourse_RequiresFullBody(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
-	body := []byte(`{
-		"name":"Updated",
-		"status":"finished",
-		"startDate":"2024-01-10",
-		"endDate":"2024-02-10",
-		"repoTemplate":"git@new",
-		"description":"updated"
-	}`)
-
-	req := plainReq(http.MethodPut, "/api/courses/algorithms", body)
 	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, editReq(http.MethodPut, "/api/courses/algorithms", []byte(`{"repoTemplate":"git@updated:repo.git"}`)))
 
-	e.ServeHTTP(rec, req)
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected 200")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+
+	fields := strings.Join(errorFields(t, rec), ",")
+	if fields != "name,slug,status,startDate,endDate" {
+		t.Errorf("unexpected error fields: %s", fields)
+	}
+
+	courseMu.RLock()
+	defer courseMu.RUnlock()
+	if courseDB["algorithms"].RepoTemplate != "git@test:repo.git" {
+		t.Error("course must not change on validation error")
 	}
 }
 
@@ -472,12 +577,15 @@ func TestUpdateCourse_InvalidStatus(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
-	req := plainReq(http.MethodPut, "/api/courses/algorithms", []byte(`{"status":"bad"}`))
+	req := editReq(http.MethodPut, "/api/courses/algorithms", withFields(t, map[string]any{"status": "bad"}))
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
 	if rec.Code != http.StatusBadRequest {
-		t.Fatalf("expected 400")
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	if fields := errorFields(t, rec); len(fields) != 1 || fields[0] != "status" {
+		t.Errorf("expected status error, got %v", fields)
 	}
 }
 
@@ -485,16 +593,128 @@ func TestUpdateCourse_NotFound(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
-	req := plainReq(http.MethodPut, "/api/courses/unknown", []byte(`{"name":"x"}`))
+	req := editReq(http.MethodPut, "/api/courses/unknown", withFields(t, map[string]any{"slug": "unknown"}))
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
 	if rec.Code != http.StatusNotFound {
-		t.Fatalf("expected 404")
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestUpdateCourse_InvalidDateRange(t *testing.T) {
+	resetDB()
+	e := setupEcho()
+
+	body := withFields(t, map[string]any{"startDate": "2025-03-01", "endDate": "2025-02-01"})
+	req := editReq(http.MethodPut, "/api/courses/algorithms", body)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	if fields := errorFields(t, rec); len(fields) != 1 || fields[0] != "dateRange" {
+		t.Errorf("expected dateRange error, got %v", fields)
+	}
+}
+
+func TestUpdateCourse_InvalidDateFormat(t *testing.T) {
+	resetDB()
+	e := setupEcho()
+
+	cases := []map[string]any{
+		{"startDate": "01-03-2025"},
+		{"endDate": "01-04-2025"},
+	}
+
+	for _, fields := range cases {
+		req := editReq(http.MethodPut, "/api/courses/algorithms", withFields(t, fields))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400, got %d", rec.Code)
+		}
+	}
+}
+
+func TestUpdateCourse_SlugChangeRejected(t *testing.T) {
+	resetDB()
+	e := setupEcho()
+
+	req := editReq(http.MethodPut, "/api/courses/algorithms", withFields(t, map[string]any{"slug": "new-slug"}))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	if fields := errorFields(t, rec); len(fields) != 1 || fields[0] != "slug" {
+		t.Errorf("expected slug error, got %v", fields)
+	}
+
+	courseMu.RLock()
+	defer courseMu.RUnlock()
+	if _, exists := courseDB["algorithms"]; !exists {
+		t.Error("course should stay under its slug")
+	}
+	if _, exists := courseDB["new-slug"]; exists {
+		t.Error("course must not be renamed")
+	}
+}
+
+func TestUpdateCourse_WrongTypeIsFieldError(t *testing.T) {
+	resetDB()
+	e := setupEcho()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, editReq(http.MethodPut, "/api/courses/algorithms", withFields(t, map[string]any{"name": 5})))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	if fields := errorFields(t, rec); len(fields) != 1 || fields[0] != "name" {
+		t.Errorf("expected name error, got %v", fields)
 	}
 }
 
-func TestUpdateCourse_PartialUpdate(t *testing.T) {
+func TestUpdateCourse_InvalidJSON(t *testing.T) {
+	resetDB()
+	e := setupEcho()
+
+	body := []byte(`{ "name": "test"`)
+	req := editReq(http.MethodPut, "/api/courses/algorithms", body)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestPatchCourse_UpdateRepoTemplate(t *testing.T) {
+	resetDB()
+	e := setupEcho()
+
+	body := []byte(`{"repoTemplate":"git@updated:repo.git"}`)
+	req := editReq(http.MethodPatch, "/api/courses/algorithms", body)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var updated Course
+	json.Unmarshal(rec.Body.Bytes(), &updated)
+
+	if updated.RepoTemplate != "git@updated:repo.git" {
+		t.Fatalf("repoTemplate not updated")
+	}
+}
+
+func TestPatchCourse_PartialUpdate(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
@@ -507,7 +727,8 @@ func TestUpdateCourse_PartialUpdate(t *testing.T) {
         "description": "New desc only"
     }`)
 
-	req := plainReq(http.MethodPut, "/api/courses/algorithms", body)
+	req := editReq(http.MethodPatch, "/api/courses/algorithms", body)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
 	rec := httptest.NewRecorder()
 	e.ServeHTTP(rec, req)
 
@@ -532,115 +753,695 @@ func TestUpdateCourse_PartialUpdate(t *testing.T) {
 	}
 }
 
-func TestUpdateCourse_EmptyFieldsIgnored(t *testing.T) {
+func TestPatchCourse_DateRangeValidAfterPartial(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
-	body := []byte(`{
-		"name":"",
-		"status":"",
-		"description":""
-	}`)
-
-	req := plainReq(http.MethodPut, "/api/courses/algorithms", body)
 	rec := httptest.NewRecorder()
-	e.ServeHTTP(rec, req)
+	e.ServeHTTP(rec, editReq(http.MethodPatch, "/api/courses/algorithms", []byte(`{"endDate":"2024-03-01"}`)))
 
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", rec.Code)
 	}
+}
 
-	var updated Course
-	json.Unmarshal(rec.Body.Bytes(), &updated)
+func TestPatchCourse_InvalidDateRange(t *testing.T) {
+	resetDB()
+	e := setupEcho()
 
-	if updated.Name == "" {
-		t.Error("name should not be updated to empty")
-	}
-	if updated.Status == "" {
-		t.Error("status should not be updated to empty")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, editReq(http.MethodPatch, "/api/courses/algorithms", []byte(`{"startDate":"2024-03-01"}`)))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
 	}
-	if updated.Description == "" {
-		t.Error("description should not be updated to empty")
+	if fields := errorFields(t, rec); len(fields) != 1 || fields[0] != "dateRange" {
+		t.Errorf("expected dateRange error, got %v", fields)
 	}
 }
 
-func TestUpdateCourse_InvalidDateRange(t *testing.T) {
+func TestPatchCourse_NullClearsField(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
-	body := []byte(`{
-		"startDate": "2025-03-01",
-		"endDate":   "2025-02-01"
-	}`)
+	courseMu.Lock()
+	course := courseDB["algorithms"]
+	course.Owners = []string{"pm"}
+	courseDB["algorithms"] = course
+	courseMu.Unlock()
 
-	req := plainReq(http.MethodPut, "/api/courses/algorithms", body)
 	rec := httptest.NewRecorder()
-	e.ServeHTTP(rec, req)
+	e.ServeHTTP(rec, editReq(http.MethodPatch, "/api/courses/algorithms", []byte(`{"description":null,"owners":null}`)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	courseMu.RLock()
+	course = courseDB["algorithms"]
+	courseMu.RUnlock()
+
+	if course.Description != "" || len(course.Owners) != 0 {
+		t.Fatalf("description and owners should be cleared, got %+v", course)
+	}
+	if course.Name != "Algorithms" {
+		t.Errorf("fields missing from the patch must not change, got name %q", course.Name)
+	}
+}
+
+func TestPatchCourse_NullRequiredField(t *testing.T) {
+	resetDB()
+	e := setupEcho()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, editReq(http.MethodPatch, "/api/courses/algorithms", []byte(`{"name":null,"status":"","startDate":"2024-01-02"}`)))
 
 	if rec.Code != http.StatusBadRequest {
 		t.Fatalf("expected 400, got %d", rec.Code)
 	}
+	if fields := strings.Join(errorFields(t, rec), ","); fields != "name,status" {
+		t.Errorf("unexpected error fields: %s", fields)
+	}
+
+	courseMu.RLock()
+	defer courseMu.RUnlock()
+	if courseDB["algorithms"].StartDate != mustDate("2024-01-01") {
+		t.Error("patch must be applied all or nothing")
+	}
 }
 
-func TestUpdateCourse_InvalidDateFormat(t *testing.T) {
-	resetDB()
+func TestPatchCourse_SlugAndNamespace(t *testing.T) {
+	resetNamespaceDB()
 	e := setupEcho()
 
-	cases := []string{
-		`{"startDate": "01-03-2025"}`,
-		`{"endDate": "01-04-2025"}`,
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, editReq(http.MethodPatch, "/api/courses/algorithms", []byte(`{"slug":"new-slug"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("slug change: expected 400, got %d", rec.Code)
 	}
 
-	for _, bodyStr := range cases {
-		req := plainReq(http.MethodPut, "/api/courses/algorithms", []byte(bodyStr))
-		rec := httptest.NewRecorder()
-		e.ServeHTTP(rec, req)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, editReq(http.MethodPatch, "/api/courses/algorithms", []byte(`{"namespaceId":"ns-02","name":"Moved?"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
 
-		if rec.Code != http.StatusBadRequest {
-			t.Fatalf("expected 400, got %d", rec.Code)
-		}
+	var updated Course
+	json.Unmarshal(rec.Body.Bytes(), &updated)
+	if updated.NamespaceID != "ns-01" || updated.Name != "Moved?" {
+		t.Fatalf("namespace must change only through move, got %+v", updated)
 	}
 }
 
-func TestUpdateCourse_IgnoreSlugChange(t *testing.T) {
+func TestPatchCourse_BadRequests(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
-	body := []byte(`{"slug": "new-slug"}`)
+	cases := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"not an object", "/api/courses/algorithms", "application/merge-patch+json", `["name"]`, http.StatusBadRequest},
+		{"invalid json", "/api/courses/algorithms", "application/merge-patch+json", `{"name":`, http.StatusBadRequest},
+		{"wrong type", "/api/courses/algorithms", "application/merge-patch+json", `{"owners":"pm"}`, http.StatusBadRequest},
+		{"content type", "/api/courses/algorithms", "text/plain", `{"name":"x"}`, http.StatusUnsupportedMediaType},
+		{"not found", "/api/courses/unknown", "application/merge-patch+json", `{"name":"x"}`, http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := editReq(http.MethodPatch, tc.path, []byte(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tc.want {
+				t.Fatalf("expected %d, got %d: %s", tc.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestIsValidDateRange_EqualDates(t *testing.T) {
+	if isValidDateRange("2024-01-01", "2024-01-01") {
+		t.Fatal("expected false when dates are equal")
+	}
+}
+
+const newCourseBody = `{"name":"Go","slug":"go","namespaceId":"ns-01","status":"created","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a:repo.git","description":"x"}`
+
+func TestCreateCourse_RequiresNamespaceAdmin(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      *http.Request
+		wantCode int
+	}{
+		{"anonymous", plainReq(http.MethodPost, "/api/courses", []byte(newCourseBody)), http.StatusUnauthorized},
+		{"program manager", authReq(http.MethodPost, "/api/courses", "pm-token", []byte(newCourseBody)), http.StatusForbidden},
+		{"namespace admin of ns-02", authReq(http.MethodPost, "/api/courses", "nsadmin-token", []byte(strings.Replace(newCourseBody, "ns-01", "ns-02", 1))), http.StatusCreated},
+		{"namespace admin", authReq(http.MethodPost, "/api/courses", "nsadmin-token", []byte(newCourseBody)), http.StatusCreated},
+		{"unknown namespace", authReq(http.MethodPost, "/api/courses", "admin-token", []byte(strings.Replace(newCourseBody, "ns-01", "ns-99", 1))), http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resetNamespaceDB()
+			e := setupEcho()
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, tc.req)
+
+			if rec.Code != tc.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tc.wantCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateCourse_DefaultOwnerIsCreator(t *testing.T) {
+	resetNamespaceDB()
+	e := setupEcho()
 
-	req := plainReq(http.MethodPut, "/api/courses/algorithms", body)
 	rec := httptest.NewRecorder()
-	e.ServeHTTP(rec, req)
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/courses", "nsadmin-token", []byte(newCourseBody)))
+
+	var course Course
+	json.Unmarshal(rec.Body.Bytes(), &course)
 
+	if course.NamespaceID != "ns-01" || course.GitlabGroup != "go" {
+		t.Errorf("unexpected course: %+v", course)
+	}
+	if len(course.Owners) != 1 || course.Owners[0] != "nsadmin" {
+		t.Errorf("expected creator as owner, got %v", course.Owners)
+	}
+}
+
+func TestGetCourses_NamespaceFilter(t *testing.T) {
+	resetNamespaceDB()
+	e := setupEcho()
+
+	courseMu.Lock()
+	hidden := courseDB["hidden"]
+	hidden.NamespaceID = "ns-02"
+	courseDB["hidden"] = hidden
+	courseMu.Unlock()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses?namespaceId=ns-02", "admin-token", nil))
+
+	var courses []Course
+	json.Unmarshal(rec.Body.Bytes(), &courses)
+
+	if len(courses) != 1 || courses[0].ID != "hidden" {
+		t.Fatalf("expected only hidden course, got %+v", courses)
+	}
+}
+
+func TestMoveCourse(t *testing.T) {
+	resetNamespaceDB()
+	e := setupEcho()
+	e.POST("/api/courses/:courseId/move", MoveCourseHandler)
+
+	inviteMu.Lock()
+	inviteDB = map[string]Invite{
+		"course-code": {Code: "course-code", CourseID: "algorithms", NamespaceID: "ns-01", Role: RoleStudent},
+		"ns-code":     {Code: "ns-code", NamespaceID: "ns-01", Role: RoleStudent},
+	}
+	inviteMu.Unlock()
+
+	auditMu.Lock()
+	auditLog = nil
+	auditMu.Unlock()
+
+	body := []byte(`{"namespaceId":"ns-02"}`)
+	move := func(token, ifMatch string, body []byte) *httptest.ResponseRecorder {
+		req := authReq(http.MethodPost, "/api/courses/algorithms/move", token, body)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := move("nsadmin-token", `"1"`, body); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+	if rec := move("admin-token", `"1"`, []byte(`{"namespaceId":"ns-99"}`)); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+	if rec := move("admin-token", "", body); rec.Code != http.StatusPreconditionRequired {
+		t.Fatalf("expected 428 without If-Match, got %d", rec.Code)
+	}
+	if rec := move("admin-token", `"7"`, body); rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 for a stale version, got %d", rec.Code)
+	}
+
+	rec := move("admin-token", `"1"`, body)
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", rec.Code)
 	}
+	if etag := rec.Header().Get("ETag"); etag != `"2"` {
+		t.Errorf("expected ETag \"2\", got %q", etag)
+	}
 
 	courseMu.RLock()
-	course := courseDB["algorithms"]
+	moved := courseDB["algorithms"]
 	courseMu.RUnlock()
+	if moved.NamespaceID != "ns-02" {
+		t.Fatalf("course not moved: %+v", moved)
+	}
+
+	inviteMu.RLock()
+	courseInvite, namespaceInvite := inviteDB["course-code"], inviteDB["ns-code"]
+	inviteMu.RUnlock()
+	if courseInvite.NamespaceID != "ns-02" || namespaceInvite.NamespaceID != "ns-01" {
+		t.Fatalf("only course invites must move: %+v, %+v", courseInvite, namespaceInvite)
+	}
 
-	if course.ID != "algorithms" {
-		t.Error("ID should not change")
+	auditMu.RLock()
+	defer auditMu.RUnlock()
+	if len(auditLog) != 1 || auditLog[0].Action != "course.move" || auditLog[0].Details["from"] != "ns-01" || auditLog[0].Actor != "admin" {
+		t.Fatalf("unexpected audit log: %+v", auditLog)
 	}
 }
 
-func TestUpdateCourse_InvalidJSON(t *testing.T) {
+func TestUpdateCourse_IgnoresNamespaceChange(t *testing.T) {
+	resetNamespaceDB()
+	e := setupEcho()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, editReq(http.MethodPut, "/api/courses/algorithms", withFields(t, map[string]any{"namespaceId": "ns-02"})))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var updated Course
+	json.Unmarshal(rec.Body.Bytes(), &updated)
+
+	if updated.NamespaceID != "ns-01" {
+		t.Fatalf("namespace must change only through move, got %q", updated.NamespaceID)
+	}
+}
+
+func TestListCourses_SortedAndFiltered(t *testing.T) {
+	resetNamespaceDB()
+
+	courses := ListCourses("", "")
+	if len(courses) != 2 || courses[0].ID != "algorithms" || courses[1].ID != "hidden" {
+		t.Fatalf("expected courses sorted by id, got %+v", courses)
+	}
+
+	if courses := ListCourses("hidden", "ns-01"); len(courses) != 1 || courses[0].ID != "hidden" {
+		t.Fatalf("unexpected filtered list: %+v", courses)
+	}
+}
+
+func TestSetCourseStatus(t *testing.T) {
+	resetNamespaceDB()
+	auditMu.Lock()
+	auditLog = nil
+	auditMu.Unlock()
+
+	courseMu.Lock()
+	course := courseDB["algorithms"]
+	course.Owners = []string{"student"}
+	courseDB["algorithms"] = course
+	courseMu.Unlock()
+
+	pm, _ := findUserByUsername("pm")
+	if _, err := SetCourseStatus(pm, "algorithms", 0, "in_progress"); apiStatus(err) != http.StatusForbidden {
+		t.Fatalf("program manager must not change the status, got %v", err)
+	}
+
+	owner, _ := findUserByUsername("student")
+	updated, err := SetCourseStatus(owner, "algorithms", 0, "in_progress")
+	if err != nil || updated.Status != "in_progress" {
+		t.Fatalf("owner should change the status: %+v, %v", updated, err)
+	}
+
+	nsadmin, _ := findUserByUsername("nsadmin")
+	if _, err := SetCourseStatus(nsadmin, "algorithms", 0, "bogus"); apiStatus(err) != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid status, got %v", err)
+	}
+	if _, err := SetCourseStatus(nsadmin, "missing", 0, "finished"); apiStatus(err) != http.StatusNotFound {
+		t.Fatalf("expected 404, got %v", err)
+	}
+	if _, err := SetCourseStatus(nsadmin, "algorithms", updated.Version-1, "finished"); apiStatus(err) != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 for a stale version, got %v", err)
+	}
+
+	auditMu.RLock()
+	defer auditMu.RUnlock()
+	if len(auditLog) != 1 || auditLog[0].Action != "course.status" || auditLog[0].Details["from"] != "created" {
+		t.Fatalf("unexpected audit log: %+v", auditLog)
+	}
+}
+
+func TestCourseVisibility(t *testing.T) {
+	resetEnrollmentDB()
+	resetBoardDB()
+	e := setupEcho()
+	e.GET("/api/courses/:courseId/board", GetCourseBoardHandler)
+
+	courseMu.Lock()
+	hidden := courseDB["hidden"]
+	hidden.Owners = []string{"outsider"}
+	courseDB["hidden"] = hidden
+	courseMu.Unlock()
+
+	list := func(token string) string {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses", token, nil))
+		var courses []Course
+		json.Unmarshal(rec.Body.Bytes(), &courses)
+		return courseIDs(courses)
+	}
+
+	for _, tt := range []struct {
+		token string
+		want  string
+	}{
+		{"admin-token", "algorithms,hidden"},
+		{"nsadmin-token", "algorithms,hidden"},
+		{"pm-token", "algorithms,hidden"},
+		{"outsider-token", "hidden"},
+		{"student-token", "algorithms"},
+	} {
+		if got := list(tt.token); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.token, tt.want, got)
+		}
+	}
+
+	status := func(path, token string) int {
+		rec := httptest.NewRecorder()
+		req := plainReq(http.MethodGet, path, nil)
+		if token != "" {
+			req = authReq(http.MethodGet, path, token, nil)
+		}
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// скрытый курс не виден студенту, даже если тот на него записан
+	enrollmentMu.Lock()
+	enrollmentDB["hidden"]["u-4"] = Enrollment{CourseID: "hidden", UserID: "u-4", Username: "student", State: EnrollmentActive}
+	enrollmentMu.Unlock()
+
+	for _, tt := range []struct {
+		path  string
+		token string
+		want  int
+	}{
+		{"/api/courses", "", http.StatusUnauthorized},
+		{"/api/courses/algorithms", "", http.StatusUnauthorized},
+		{"/api/courses/algorithms", "student-token", http.StatusOK},
+		{"/api/courses/algorithms/board", "student-token", http.StatusOK},
+		{"/api/courses/hidden", "student-token", http.StatusNotFound},
+		{"/api/courses/hidden/board", "student-token", http.StatusNotFound},
+		{"/api/courses/hidden", "outsider-token", http.StatusOK},
+		{"/api/courses/hidden/board", "outsider-token", http.StatusOK},
+		{"/api/courses/algorithms", "outsider-token", http.StatusNotFound},
+		{"/api/courses/algorithms/board", "", http.StatusUnauthorized},
+	} {
+		if got := status(tt.path, tt.token); got != tt.want {
+			t.Errorf("GET %s as %q: expected %d, got %d", tt.path, tt.token, tt.want, got)
+		}
+	}
+
+	student, _ := findUserByUsername("student")
+	if mine := ListMyCourses(student); len(mine) != 1 || mine[0].ID != "algorithms" {
+		t.Errorf("hidden course must not be listed in my courses: %+v", mine)
+	}
+
+	// отчисленный студент больше не видит курс
+	enrollmentMu.Lock()
+	enrollmentDB["algorithms"]["u-4"] = Enrollment{CourseID: "algorithms", UserID: "u-4", Username: "student", State: EnrollmentDropped}
+	enrollmentMu.Unlock()
+	if got := status("/api/courses/algorithms", "student-token"); got != http.StatusNotFound {
+		t.Errorf("dropped student must not see the course, got %d", got)
+	}
+}
+
+func TestUpdateCourse_IfMatch(t *testing.T) {
 	resetDB()
 	e := setupEcho()
 
-	body := []byte(`{ "name": "test"`)
-	req := plainReq(http.MethodPut, "/api/courses/algorithms", body)
+	put := func(ifMatch string) *httptest.ResponseRecorder {
+		req := authReq(http.MethodPut, "/api/courses/algorithms", "admin-token", []byte(algorithmsBody))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := put(""); rec.Code != http.StatusPreconditionRequired {
+		t.Fatalf("without If-Match: expected 428, got %d", rec.Code)
+	}
+
+	rec := put(`"1"`)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"2"` {
+		t.Fatalf("expected 200 with ETag \"2\", got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	// Второй учитель правит по старой версии
+	for _, stale := range []string{`"1"`, `W/"2"`, `2`} {
+		if rec := put(stale); rec.Code != http.StatusPreconditionFailed {
+			t.Errorf("If-Match %s: expected 412, got %d", stale, rec.Code)
+		}
+	}
+
+	if rec := put("*"); rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"3"` {
+		t.Fatalf("If-Match *: expected 200 with ETag \"3\", got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestEditCourse_RequiresOwnerOrNamespaceAdmin(t *testing.T) {
+	resetEnrollmentDB()
+	e := setupEcho()
+
+	courseMu.Lock()
+	algorithms := courseDB["algorithms"]
+	algorithms.Owners = []string{"pm"}
+	courseDB["algorithms"] = algorithms
+	courseMu.Unlock()
+
+	send := func(method, path, token, body string) int {
+		var req *http.Request
+		if token == "" {
+			req = plainReq(method, path, []byte(body))
+		} else {
+			req = authReq(method, path, token, []byte(body))
+		}
+		req.Header.Set("If-Match", "*")
+		if method == http.MethodPatch {
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	grab := `{"owners":["outsider"]}`
+	cases := []struct {
+		name, method, path, token, body string
+		want                            int
+	}{
+		{"anonymous patch", http.MethodPatch, "/api/courses/hidden", "", grab, http.StatusUnauthorized},
+		{"anonymous put", http.MethodPut, "/api/courses/algorithms", "", algorithmsBody, http.StatusUnauthorized},
+		// скрытый курс посторонний не видит: 404, а не 403
+		{"outsider on hidden course", http.MethodPatch, "/api/courses/hidden", "outsider-token", grab, http.StatusNotFound},
+		{"outsider put on hidden course", http.MethodPut, "/api/courses/hidden", "outsider-token", "{", http.StatusNotFound},
+		{"enrolled student", http.MethodPatch, "/api/courses/algorithms", "student-token", grab, http.StatusForbidden},
+		// program manager видит скрытый курс, но менять его не может
+		{"program manager", http.MethodPatch, "/api/courses/hidden", "pm-token", grab, http.StatusForbidden},
+		{"invalid body from a stranger", http.MethodPut, "/api/courses/algorithms", "student-token", "{", http.StatusForbidden},
+		{"owner", http.MethodPatch, "/api/courses/algorithms", "pm-token", `{"description":"by owner"}`, http.StatusOK},
+		{"namespace admin", http.MethodPatch, "/api/courses/hidden", "nsadmin-token", `{"description":"by admin"}`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		if got := send(tc.method, tc.path, tc.token, tc.body); got != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, got)
+		}
+	}
+
+	if course, _ := getCourse("hidden"); slices.Contains(course.Owners, "outsider") {
+		t.Fatalf("owners must not be changed by strangers: %v", course.Owners)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses/hidden", "outsider-token", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("hidden course must stay invisible to outsider, got %d", rec.Code)
+	}
+}
+
+func TestReplaceCourse_OwnersNeedEditRights(t *testing.T) {
+	resetEnrollmentDB()
+	e := setupEcho()
+
+	put := func(token string) int {
+		body := withFields(t, map[string]any{"namespaceId": "ns-01", "owners": []string{"student"}})
+		var req *http.Request
+		if token == "" {
+			req = plainReq(http.MethodPut, "/api/courses/algorithms", body)
+		} else {
+			req = authReq(http.MethodPut, "/api/courses/algorithms", token, body)
+		}
+		req.Header.Set("If-Match", "*")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// студент не может сам назначить себя владельцем курса
+	if got := put(""); got != http.StatusUnauthorized {
+		t.Errorf("anonymous: expected 401, got %d", got)
+	}
+	if got := put("student-token"); got != http.StatusForbidden {
+		t.Errorf("student: expected 403, got %d", got)
+	}
+	if course, _ := getCourse("algorithms"); len(course.Owners) != 0 {
+		t.Fatalf("owners must not change: %v", course.Owners)
+	}
+
+	if got := put("nsadmin-token"); got != http.StatusOK {
+		t.Fatalf("namespace admin: expected 200, got %d", got)
+	}
+	if course, _ := getCourse("algorithms"); !slices.Equal(course.Owners, []string{"student"}) {
+		t.Errorf("owners: %v", course.Owners)
+	}
+}
+
+func TestEditCourse_AuditsOwnersAndStatus(t *testing.T) {
+	resetNamespaceDB()
+	e := setupEcho()
+
+	auditMu.Lock()
+	auditLog = nil
+	auditMu.Unlock()
+
+	req := editReq(http.MethodPatch, "/api/courses/algorithms", []byte(`{"owners":["pm"],"status":"in_progress"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
 	rec := httptest.NewRecorder()
 	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
 
-	if rec.Code != http.StatusBadRequest {
-		t.Fatalf("expected 400, got %d", rec.Code)
+	// повторная правка без смены владельцев и статуса в аудит не попадает
+	req = authReq(http.MethodPatch, "/api/courses/algorithms", "admin-token", []byte(`{"description":"x2"}`))
+	req.Header.Set("If-Match", "*")
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	if len(auditLog) != 2 {
+		t.Fatalf("expected 2 audit entries, got %+v", auditLog)
+	}
+	for _, entry := range auditLog {
+		switch entry.Action {
+		case "course.status":
+			if entry.Details["from"] != "created" || entry.Details["to"] != "in_progress" {
+				t.Errorf("status audit: %+v", entry)
+			}
+		case "course.owners":
+			if entry.Details["from"] != "" || entry.Details["to"] != "pm" || entry.Actor != "admin" {
+				t.Errorf("owners audit: %+v", entry)
+			}
+		default:
+			t.Errorf("unexpected audit entry %+v", entry)
+		}
 	}
 }
 
-func TestIsValidDateRange_EqualDates(t *testing.T) {
-	if isValidDateRange("2024-01-01", "2024-01-01") {
-		t.Fatal("expected false when dates are equal")
+func TestPatchCourse_IfMatch(t *testing.T) {
+	resetDB()
+	e := setupEcho()
+
+	patch := func(ifMatch, body string) *httptest.ResponseRecorder {
+		req := authReq(http.MethodPatch, "/api/courses/algorithms", "admin-token", []byte(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := patch("", `{"name":"A"}`); rec.Code != http.StatusPreconditionRequired {
+		t.Fatalf("without If-Match: expected 428, got %d", rec.Code)
+	}
+	if rec := patch(`"1"`, `{"name":"A"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if rec := patch(`"1"`, `{"name":"B"}`); rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale version: expected 412, got %d", rec.Code)
+	}
+
+	courseMu.RLock()
+	defer courseMu.RUnlock()
+	if course := courseDB["algorithms"]; course.Name != "A" || course.Version != 2 {
+		t.Fatalf("stale patch must not be applied: %+v", course)
+	}
+}
+
+func TestCourseVersion_BumpedByEveryChange(t *testing.T) {
+	resetNamespaceDB()
+
+	admin, _ := findUserByUsername("admin")
+	course, err := CreateCourse(admin, PostCourseRequest{Name: "Go", Slug: "go", NamespaceID: "ns-01", Status: "created", StartDate: "2025-01-01", EndDate: "2025-02-01", RepoTemplate: "git@a:repo.git"})
+	if err != nil || course.Version != 1 {
+		t.Fatalf("new course must have version 1: %+v, %v", course, err)
+	}
+
+	course, err = SetCourseStatus(admin, "go", 0, "in_progress")
+	if err != nil || course.Version != 2 {
+		t.Fatalf("status change must bump version: %+v, %v", course, err)
 	}
-}
\ No newline at end of file
+
+	if _, err := ReplaceCourse(admin, "go", 1, courseDocument(course)); apiStatus(err) != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 for stale version, got %v", err)
+	}
+	if course, err = ReplaceCourse(admin, "go", 0, courseDocument(course)); err != nil || course.Version != 3 {
+		t.Fatalf("replace without version check: %+v, %v", course, err)
+	}
+}
+
+func TestGetCourse_IfNoneMatch(t *testing.T) {
+	resetDB()
+	e := setupEcho()
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := authReq(http.MethodGet, "/api/courses/algorithms", "admin-token", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("")
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"1"` {
+		t.Fatalf("expected 200 with ETag \"1\", got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	for _, header := range []string{`"1"`, `W/"1"`, `"5", "1"`, "*"} {
+		if rec := get(header); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: expected empty 304, got %d", header, rec.Code)
+		}
+	}
+
+	e.ServeHTTP(httptest.NewRecorder(), editReq(http.MethodPatch, "/api/courses/algorithms", []byte(`{"name":"Algorithms II"}`)))
+
+	if rec := get(`"1"`); rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"2"` {
+		t.Fatalf("changed course: expected 200 with ETag \"2\", got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
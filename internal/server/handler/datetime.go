@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	// База часовых поясов встроена: в контейнере может не быть /usr/share/zoneinfo
+	_ "time/tzdata"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	// Местное время без смещения, которым преподаватели задают дедлайны
+	localTimeLayout        = "2006-01-02T15:04"
+	localTimeSecondsLayout = "2006-01-02T15:04:05"
+
+	defaultTimezone = "UTC"
+)
+
+// errTimeSkipped - местного времени нет: часы переведены вперед
+var errTimeSkipped = errors.New("time is skipped by the clock change")
+
+// Date - календарная дата курса без времени и часового пояса. Когда день
+// начинается и заканчивается, определяет часовой пояс курса
+type Date struct {
+	t time.Time // полночь UTC
+}
+
+// ParseDate разбирает дату в формате YYYY-MM-DD
+func ParseDate(value string) (Date, error) {
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+	}
+	return Date{t}, nil
+}
+
+func (d Date) IsZero() bool {
+	return d.t.IsZero()
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.t.Format(dateLayout)
+}
+
+func (d Date) Compare(other Date) int {
+	return d.t.Compare(other.t)
+}
+
+// AddDays сдвигает дату на календарные дни
+func (d Date) AddDays(days int) Date {
+	return Date{d.t.AddDate(0, 0, days)}
+}
+
+// DaysUntil - сколько календарных дней от d до other
+func (d Date) DaysUntil(other Date) int {
+	return int(other.t.Sub(d.t) / (24 * time.Hour))
+}
+
+// Start - начало дня по времени loc
+func (d Date) Start(loc *time.Location) time.Time {
+	year, month, day := d.t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// End - последняя секунда дня по времени loc. В день перехода на летнее время
+// в сутках не 24 часа, поэтому конец считается от начала следующего дня
+func (d Date) End(loc *time.Location) time.Time {
+	return d.AddDays(1).Start(loc).Add(-time.Second)
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText - пустая строка дает нулевую дату
+func (d *Date) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*d = Date{}
+		return nil
+	}
+	parsed, err := ParseDate(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Scan читает дату из TEXT-колонки базы
+func (d *Date) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return d.UnmarshalText([]byte(v))
+	case []byte:
+		return d.UnmarshalText(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Date", src)
+	}
+}
+
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// loadTimezone - часовой пояс по имени из базы IANA, пустое имя - UTC.
+// Local не принимается: время сервера не должно влиять на курс
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if name == "Local" {
+		return nil, errors.New("unknown time zone Local")
+	}
+	return time.LoadLocation(name)
+}
+
+// timezoneName - имя, под которым пояс хранится у курса
+func timezoneName(name string) string {
+	if name == "" {
+		return defaultTimezone
+	}
+	return name
+}
+
+func validateTimezone(name string) []ValidationError {
+	var v validator
+	v.check("timezone", name, isTimezone)
+	return v.errs
+}
+
+// courseLocation - часовой пояс курса; значение проверено при сохранении,
+// поэтому ошибка здесь означает UTC
+func courseLocation(course Course) *time.Location {
+	loc, err := loadTimezone(course.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// parseCourseTime разбирает время, которое вводит преподаватель. RFC 3339 со
+// смещением - точный момент. Время без смещения (2024-12-20T18:00) - местное
+// время курса. Дата без времени - начало дня или, с endOfDay, его последняя секунда.
+// Результат - момент в UTC
+func parseCourseTime(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+
+	if date, err := ParseDate(value); err == nil {
+		if endOfDay {
+			return date.End(loc).UTC(), nil
+		}
+		return date.Start(loc).UTC(), nil
+	}
+
+	for _, layout := range []string{localTimeLayout, localTimeSecondsLayout} {
+		if wall, err := time.Parse(layout, value); err == nil {
+			return localInstant(wall, loc)
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q, expected RFC 3339, local YYYY-MM-DDTHH:MM or YYYY-MM-DD", value)
+}
+
+// localInstant переводит местное время loc (wall - показания часов в UTC) в момент.
+// Время, пропущенное при переводе часов вперед, - ошибка. Время, которое при
+// переводе назад наступает дважды, - первое из двух
+func localInstant(wall time.Time, loc *time.Location) (time.Time, error) {
+	var instant time.Time
+	for _, probe := range []time.Time{wall.Add(-24 * time.Hour), wall.Add(24 * time.Hour)} {
+		_, offset := probe.In(loc).Zone()
+		candidate := wall.Add(-time.Duration(offset) * time.Second)
+		if !sameWallClock(candidate.In(loc), wall) {
+			continue
+		}
+		if instant.IsZero() || candidate.Before(instant) {
+			instant = candidate
+		}
+	}
+
+	if instant.IsZero() {
+		return time.Time{}, fmt.Errorf("%s in %s: %w", wall.Format(localTimeSecondsLayout), loc, errTimeSkipped)
+	}
+	return instant.UTC(), nil
+}
+
+func sameWallClock(t, wall time.Time) bool {
+	return t.Format(localTimeSecondsLayout) == wall.Format(localTimeSecondsLayout)
+}
+
+// formatLocal - момент по времени loc в RFC 3339, пустая строка для нулевого
+func formatLocal(t time.Time, loc *time.Location) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
+
+// shiftLocal сдвигает момент на календарные дни, сохраняя местное время loc:
+// дедлайн в 18:00 остается в 18:00 и после перехода на летнее время
+func shiftLocal(t time.Time, days int, loc *time.Location) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.In(loc).AddDate(0, 0, days).UTC()
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func mustDate(value string) Date {
+	d, err := ParseDate(value)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func mustTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t.UTC()
+}
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := loadTimezone(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestDate_JSON(t *testing.T) {
+	var v struct {
+		Date Date `json:"date"`
+	}
+	if err := json.Unmarshal([]byte(`{"date":"2024-12-20"}`), &v); err != nil || v.Date != mustDate("2024-12-20") {
+		t.Fatalf("unmarshal: %v, %v", v.Date, err)
+	}
+	if data, _ := json.Marshal(v); string(data) != `{"date":"2024-12-20"}` {
+		t.Errorf("marshal: %s", data)
+	}
+	if err := json.Unmarshal([]byte(`{"date":"20.12.2024"}`), &v); err == nil {
+		t.Error("invalid date must not be accepted")
+	}
+}
+
+func TestDate_DayBoundaries(t *testing.T) {
+	cases := []struct {
+		name, timezone, date string
+		start, end           string
+		hours                float64
+	}{
+		{"UTC", "UTC", "2024-12-20", "2024-12-20T00:00:00Z", "2024-12-20T23:59:59Z", 24},
+		// курс в Москве заканчивается 20 декабря по московскому времени, в UTC - еще 20-го в 21:00
+		{"Moscow", "Europe/Moscow", "2024-12-20", "2024-12-19T21:00:00Z", "2024-12-20T20:59:59Z", 24},
+		// переход на летнее время: в сутках 23 часа
+		{"Berlin spring forward", "Europe/Berlin", "2025-03-30", "2025-03-29T23:00:00Z", "2025-03-30T21:59:59Z", 23},
+		// переход на зимнее время: в сутках 25 часов
+		{"Berlin fall back", "Europe/Berlin", "2025-10-26", "2025-10-25T22:00:00Z", "2025-10-26T22:59:59Z", 25},
+		{"New York spring forward", "America/New_York", "2025-03-09", "2025-03-09T05:00:00Z", "2025-03-10T03:59:59Z", 23},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			loc := mustLocation(t, tc.timezone)
+			date := mustDate(tc.date)
+
+			start, end := date.Start(loc), date.End(loc)
+			if !start.Equal(mustTime(tc.start)) || !end.Equal(mustTime(tc.end)) {
+				t.Errorf("got %s - %s", start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339))
+			}
+			if hours := end.Add(time.Second).Sub(start).Hours(); hours != tc.hours {
+				t.Errorf("day lasts %v hours, want %v", hours, tc.hours)
+			}
+		})
+	}
+}
+
+func TestParseCourseTime(t *testing.T) {
+	moscow := mustLocation(t, "Europe/Moscow")
+	berlin := mustLocation(t, "Europe/Berlin")
+
+	cases := []struct {
+		name     string
+		value    string
+		loc      *time.Location
+		endOfDay bool
+		want     string
+	}{
+		{"instant with offset ignores the zone", "2024-12-20T18:00:00+05:00", moscow, false, "2024-12-20T13:00:00Z"},
+		{"instant in UTC", "2024-12-20T18:00:00Z", moscow, false, "2024-12-20T18:00:00Z"},
+		{"local time", "2024-12-20T18:00", moscow, false, "2024-12-20T15:00:00Z"},
+		{"local time with seconds", "2024-12-20T18:00:30", moscow, false, "2024-12-20T15:00:30Z"},
+		{"date is the start of the day", "2024-12-20", moscow, false, "2024-12-19T21:00:00Z"},
+		{"date is the end of the day", "2024-12-20", moscow, true, "2024-12-20T20:59:59Z"},
+		{"local time after spring forward", "2025-03-30T18:00", berlin, false, "2025-03-30T16:00:00Z"},
+		{"local time before spring forward", "2025-03-29T18:00", berlin, false, "2025-03-29T17:00:00Z"},
+		// 02:30 26 октября в Берлине наступает дважды, берется первое
+		{"ambiguous local time", "2025-10-26T02:30", berlin, false, "2025-10-26T00:30:00Z"},
+		{"end of the fall back day", "2025-10-26", berlin, true, "2025-10-26T22:59:59Z"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseCourseTime(tc.value, tc.loc, tc.endOfDay)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(mustTime(tc.want)) || got.Location() != time.UTC {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+
+	// 02:30 30 марта в Берлине не существует: часы переводят с 02:00 на 03:00
+	if _, err := parseCourseTime("2025-03-30T02:30", berlin, false); err == nil {
+		t.Error("time skipped by the clock change must be rejected")
+	}
+	if _, err := parseCourseTime("20.12.2024 18:00", moscow, false); err == nil {
+		t.Error("unknown format must be rejected")
+	}
+}
+
+func TestShiftLocal_KeepsWallClockAcrossDST(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+
+	// 18:00 по Берлину зимой - 17:00 UTC, через две недели, уже летом, - 16:00 UTC
+	got := shiftLocal(mustTime("2025-03-20T17:00:00Z"), 14, berlin)
+	if !got.Equal(mustTime("2025-04-03T16:00:00Z")) {
+		t.Errorf("got %s", got)
+	}
+	if got := shiftLocal(time.Time{}, 14, berlin); !got.IsZero() {
+		t.Errorf("zero time must stay zero, got %s", got)
+	}
+}
+
+func TestValidateTimezone(t *testing.T) {
+	for _, name := range []string{"", "UTC", "Europe/Moscow", "America/New_York"} {
+		if errs := validateTimezone(name); len(errs) > 0 {
+			t.Errorf("%q: unexpected errors %v", name, errs)
+		}
+	}
+	for _, name := range []string{"Local", "MSK", "Europe/Atlantis", "+03:00"} {
+		if errs := validateTimezone(name); len(errs) != 1 || errs[0].Field != "timezone" {
+			t.Errorf("%q: expected timezone error, got %v", name, errs)
+		}
+	}
+}
+
+func TestCourse_JSONShowsUTCAndLocalTimes(t *testing.T) {
+	course := Course{
+		ID: "algorithms", StartDate: mustDate("2024-10-01"), EndDate: mustDate("2024-12-20"), Timezone: "Europe/Moscow",
+	}
+
+	data, _ := json.Marshal(course)
+	var got map[string]any
+	json.Unmarshal(data, &got)
+
+	want := map[string]string{
+		"startDate":     "2024-10-01",
+		"endDate":       "2024-12-20",
+		"timezone":      "Europe/Moscow",
+		"startsAt":      "2024-09-30T21:00:00Z",
+		"startsAtLocal": "2024-10-01T00:00:00+03:00",
+		"endsAt":        "2024-12-20T20:59:59Z",
+		"endsAtLocal":   "2024-12-20T23:59:59+03:00",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %v, want %s", key, got[key], value)
+		}
+	}
+
+	var decoded Course
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.EndDate != course.EndDate || decoded.Timezone != course.Timezone {
+		t.Errorf("course must survive a JSON round trip: %+v, %v", decoded, err)
+	}
+
+	trashed, _ := json.Marshal(TrashedCourse{Course: course, PurgeAt: mustTime("2025-01-01T00:00:00Z")})
+	json.Unmarshal(trashed, &got)
+	if got["purgeAt"] != "2025-01-01T00:00:00Z" || got["endsAtLocal"] != want["endsAtLocal"] {
+		t.Errorf("trashed course lost fields: %s", trashed)
+	}
+}
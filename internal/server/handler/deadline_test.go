@@ -29,11 +29,12 @@ func resetBoardDB() {
 			Groups: []BoardGroup{
 				{
 					ID:        "week-1",
+					Version:   1,
 					Name:      "Week 1: Warmup",
-					StartedAt: "2024-10-01T09:00:00Z",
-					EndsAt:    "2024-10-14T18:00:00Z",
+					StartedAt: mustTime("2024-10-01T09:00:00Z"),
+					EndsAt:    mustTime("2024-10-14T18:00:00Z"),
 					Deadlines: []BoardDeadline{
-						{ID: "d1", Label: "Checkpoint", Percent: 0.6, DueAt: "2024-09-20T18:00:00Z", Status: "expired"},
+						{ID: "d1", Label: "Checkpoint", Percent: 0.6, DueAt: mustTime("2024-09-20T18:00:00Z"), Status: "expired"},
 					},
 					Tasks: []BoardTask{
 						{ID: "t1", Name: "Arrays Sprint", Score: 20, ScoreEarned: 20, Stats: 0.82},
@@ -50,11 +51,12 @@ func resetBoardDB() {
 			Groups: []BoardGroup{
 				{
 					ID:        "project-phase-1",
+					Version:   1,
 					Name:      "Project Phase 1",
-					StartedAt: "2024-09-01T09:00:00Z",
-					EndsAt:    "2024-10-15T18:00:00Z",
+					StartedAt: mustTime("2024-09-01T09:00:00Z"),
+					EndsAt:    mustTime("2024-10-15T18:00:00Z"),
 					Deadlines: []BoardDeadline{
-						{ID: "mlops-d1", Label: "Proposal", Percent: 0.3, DueAt: "2024-09-15T18:00:00Z", Status: "expired"},
+						{ID: "mlops-d1", Label: "Proposal", Percent: 0.3, DueAt: mustTime("2024-09-15T18:00:00Z"), Status: "expired"},
 					},
 					Tasks: []BoardTask{
 						{ID: "mlops-t1", Name: "Data Pipeline", Score: 50, ScoreEarned: 45, Stats: 0.9},
@@ -70,10 +72,11 @@ func resetBoardDB() {
    ============================================================ */
 
 func TestGetCourseBoardHandler_ValidCourse(t *testing.T) {
+	resetDB()
 	resetBoardDB()
 	e := setupEchoBoard()
 
-	req := httptest.NewRequest(http.MethodGet, "/api/courses/algorithms/board", nil)
+	req := authReq(http.MethodGet, "/api/courses/algorithms/board", "admin-token", nil)
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
@@ -94,6 +97,7 @@ func TestGetCourseBoardHandler_ValidCourse(t *testing.T) {
 }
 
 func TestGetCourseBoardHandler_CourseWithoutBoard(t *testing.T) {
+	resetDB()
 	resetBoardDB()
 	e := setupEchoBoard()
 
@@ -103,15 +107,15 @@ func TestGetCourseBoardHandler_CourseWithoutBoard(t *testing.T) {
 		ID:           "rust",
 		Name:         "Rust Core",
 		Status:       "created",
-		StartDate:    "2024-10-15",
-		EndDate:      "2025-01-15",
-		RepoTemplate: "git@test/rust.git",
+		StartDate:    mustDate("2024-10-15"),
+		EndDate:      mustDate("2025-01-15"),
+		RepoTemplate: "git@test:rust.git",
 		Description:  "Rust basics",
 		URL:          "/course/rust",
 	}
 	courseMu.Unlock()
 
-	req := httptest.NewRequest(http.MethodGet, "/api/courses/rust/board", nil)
+	req := authReq(http.MethodGet, "/api/courses/rust/board", "admin-token", nil)
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
@@ -128,10 +132,11 @@ func TestGetCourseBoardHandler_CourseWithoutBoard(t *testing.T) {
 }
 
 func TestGetCourseBoardHandler_CourseNotFound(t *testing.T) {
+	resetDB()
 	resetBoardDB()
 	e := setupEchoBoard()
 
-	req := httptest.NewRequest(http.MethodGet, "/api/courses/nonexistent/board", nil)
+	req := authReq(http.MethodGet, "/api/courses/nonexistent/board", "admin-token", nil)
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
@@ -161,7 +166,7 @@ func TestGetCourseBoardHandler_EmptyBoardData(t *testing.T) {
 	courseMu.RUnlock()
 	assert.True(t, exists, "course 'algorithms' must exist")
 
-	req := httptest.NewRequest(http.MethodGet, "/api/courses/algorithms/board", nil)
+	req := authReq(http.MethodGet, "/api/courses/algorithms/board", "admin-token", nil)
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
@@ -186,9 +191,9 @@ func TestGetCourseBoardHandler_JSONStructure(t *testing.T) {
 		ID:           "mlops",
 		Name:         "MLOps Studio",
 		Status:       "all_tasks_issued",
-		StartDate:    "2024-09-01",
-		EndDate:      "2024-11-30",
-		RepoTemplate: "git@test/mlops.git",
+		StartDate:    mustDate("2024-09-01"),
+		EndDate:      mustDate("2024-11-30"),
+		RepoTemplate: "git@test:mlops.git",
 		Description:  "MLOps course",
 		URL:          "/course/mlops",
 	}
@@ -196,7 +201,7 @@ func TestGetCourseBoardHandler_JSONStructure(t *testing.T) {
 	
 	e := setupEchoBoard()
 
-	req := httptest.NewRequest(http.MethodGet, "/api/courses/mlops/board", nil)
+	req := authReq(http.MethodGet, "/api/courses/mlops/board", "admin-token", nil)
 	rec := httptest.NewRecorder()
 
 	e.ServeHTTP(rec, req)
@@ -233,4 +238,39 @@ func TestGetCourseBoardHandler_JSONStructure(t *testing.T) {
 	assert.Contains(t, deadline, "percent")
 	assert.Contains(t, deadline, "dueAt")
 	assert.Contains(t, deadline, "status")
-}
\ No newline at end of file
+}
+func TestGetCourseBoardHandler_ETag(t *testing.T) {
+	resetDB()
+	resetBoardDB()
+	e := setupEchoBoard()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses/algorithms/board", "admin-token", nil))
+	etag := rec.Header().Get("ETag")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEmpty(t, etag)
+
+	req := authReq(http.MethodGet, "/api/courses/algorithms/board", "admin-token", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	// Изменение группы меняет ETag доски
+	boardMu.Lock()
+	board := boardData["algorithms"]
+	board.Groups[0].Name = "Week 1: Arrays"
+	board.Groups[0].Version++
+	boardData["algorithms"] = board
+	boardMu.Unlock()
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+
+	var resp TaskBoardSummary
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.Equal(t, 2, resp.Groups[0].Version)
+}
@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Состояния записи студента на курс
+const (
+	EnrollmentPending   = "pending"
+	EnrollmentActive    = "active"
+	EnrollmentDropped   = "dropped"
+	EnrollmentCompleted = "completed"
+)
+
+// Допустимые переходы между состояниями записи
+var enrollmentTransitions = map[string][]string{
+	EnrollmentPending:   {EnrollmentActive, EnrollmentDropped},
+	EnrollmentActive:    {EnrollmentDropped, EnrollmentCompleted},
+	EnrollmentDropped:   {EnrollmentActive},
+	EnrollmentCompleted: {EnrollmentActive},
+}
+
+// Enrollment - запись студента на курс
+type Enrollment struct {
+	CourseID  string    `json:"courseId"`
+	UserID    string    `json:"userId"`
+	Username  string    `json:"username"`
+	State     string    `json:"state"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// MyCourse - курс в списке GET /api/me/courses
+type MyCourse struct {
+	Course
+	EnrollmentState string `json:"enrollmentState"`
+}
+
+// MarshalJSON - без него EnrollmentState потерялся бы за MarshalJSON встроенного Course
+func (course MyCourse) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		courseView
+		EnrollmentState string `json:"enrollmentState"`
+	}{course.view(), course.EnrollmentState})
+}
+
+// PostEnrollmentRequest - тело запроса на запись студента
+type PostEnrollmentRequest struct {
+	Username string `json:"username"`
+}
+
+// PutEnrollmentRequest - тело запроса на смену состояния записи
+type PutEnrollmentRequest struct {
+	State string `json:"state"`
+}
+
+// In-memory storage
+var (
+	// courseID -> userID -> запись
+	enrollmentDB = map[string]map[string]Enrollment{}
+
+	enrollmentMu sync.RWMutex
+)
+
+func canTransition(from, to string) bool {
+	return slices.Contains(enrollmentTransitions[from], to)
+}
+
+// canTeachCourse - управлять записями могут владельцы курса,
+// админы и program manager'ы его namespace и инстанс-админ
+func canTeachCourse(actor User, course Course) bool {
+	return isCourseOwner(course, actor) || canAssignNamespaceRole(actor, course.NamespaceID, RoleStudent)
+}
+
+func getCourse(courseID string) (Course, error) {
+	courseMu.RLock()
+	course, exists := courseDB[courseID]
+	courseMu.RUnlock()
+
+	if !exists {
+		return Course{}, newAPIError(http.StatusNotFound, "course not found")
+	}
+	return course, nil
+}
+
+// upsertEnrollment создает или перезаписывает запись студента на курс
+func upsertEnrollment(courseID string, user User, state string) Enrollment {
+	enrollmentMu.Lock()
+	defer enrollmentMu.Unlock()
+
+	if enrollmentDB[courseID] == nil {
+		enrollmentDB[courseID] = map[string]Enrollment{}
+	}
+
+	enrollment := Enrollment{
+		CourseID:  courseID,
+		UserID:    user.ID,
+		Username:  user.Username,
+		State:     state,
+		UpdatedAt: time.Now().UTC(),
+	}
+	enrollmentDB[courseID][user.ID] = enrollment
+
+	return enrollment
+}
+
+// activatePendingEnrollments переводит записи pending в active после подтверждения почты
+func activatePendingEnrollments(userID string) {
+	enrollmentMu.Lock()
+	defer enrollmentMu.Unlock()
+
+	for courseID, enrollments := range enrollmentDB {
+		if enrollment, ok := enrollments[userID]; ok && enrollment.State == EnrollmentPending {
+			enrollment.State = EnrollmentActive
+			enrollment.UpdatedAt = time.Now().UTC()
+			enrollmentDB[courseID][userID] = enrollment
+		}
+	}
+}
+
+// enrollmentState возвращает состояние записи или "", если записи нет
+func enrollmentState(courseID, userID string) string {
+	enrollmentMu.RLock()
+	defer enrollmentMu.RUnlock()
+
+	return enrollmentDB[courseID][userID].State
+}
+
+// ListEnrollments возвращает все записи курса, включая отчисленных
+func ListEnrollments(actor User, courseID string) ([]Enrollment, error) {
+	course, err := visibleCourse(actor, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canTeachCourse(actor, course) {
+		return nil, errForbidden
+	}
+
+	enrollmentMu.RLock()
+	enrollments := make([]Enrollment, 0, len(enrollmentDB[courseID]))
+	for _, enrollment := range enrollmentDB[courseID] {
+		enrollments = append(enrollments, enrollment)
+	}
+	enrollmentMu.RUnlock()
+
+	sort.Slice(enrollments, func(i, j int) bool { return enrollments[i].Username < enrollments[j].Username })
+	return enrollments, nil
+}
+
+// EnrollStudent записывает студента на курс или возвращает отчисленного
+func EnrollStudent(actor User, courseID string, req PostEnrollmentRequest) (Enrollment, error) {
+	course, err := visibleCourse(actor, courseID)
+	if err != nil {
+		return Enrollment{}, err
+	}
+
+	if !canTeachCourse(actor, course) {
+		return Enrollment{}, errForbidden
+	}
+
+	if req.Username == "" {
+		return Enrollment{}, validationFailed([]ValidationError{{Field: "username", Message: "username is required"}})
+	}
+
+	user, exists := findUserByUsername(req.Username)
+	if !exists {
+		return Enrollment{}, newAPIError(http.StatusNotFound, "user not found")
+	}
+
+	switch enrollmentState(courseID, user.ID) {
+	case EnrollmentActive, EnrollmentPending:
+		return Enrollment{}, newAPIError(http.StatusConflict, "user is already enrolled")
+	case EnrollmentCompleted:
+		return Enrollment{}, newAPIError(http.StatusConflict, "user has already completed this course")
+	}
+
+	namespaceMu.Lock()
+	if namespaceMembers[course.NamespaceID] == nil {
+		namespaceMembers[course.NamespaceID] = map[string]string{}
+	}
+	if _, member := namespaceMembers[course.NamespaceID][user.ID]; !member {
+		namespaceMembers[course.NamespaceID][user.ID] = RoleStudent
+	}
+	namespaceMu.Unlock()
+
+	return upsertEnrollment(courseID, user, EnrollmentActive), nil
+}
+
+// SetEnrollmentState отчисляет, восстанавливает или завершает обучение студента.
+// Посылки отчисленного остаются, он лишь пропадает из ведомости
+func SetEnrollmentState(actor User, courseID, userID string, req PutEnrollmentRequest) (Enrollment, error) {
+	course, err := visibleCourse(actor, courseID)
+	if err != nil {
+		return Enrollment{}, err
+	}
+
+	if !canTeachCourse(actor, course) {
+		return Enrollment{}, errForbidden
+	}
+
+	enrollmentMu.Lock()
+	defer enrollmentMu.Unlock()
+
+	enrollment, exists := enrollmentDB[courseID][userID]
+	if !exists {
+		return Enrollment{}, newAPIError(http.StatusNotFound, "enrollment not found")
+	}
+
+	if !canTransition(enrollment.State, req.State) {
+		return Enrollment{}, validationFailed([]ValidationError{{Field: "state", Message: "cannot change state from " + enrollment.State + " to " + req.State}})
+	}
+
+	enrollment.State = req.State
+	enrollment.UpdatedAt = time.Now().UTC()
+	enrollmentDB[courseID][userID] = enrollment
+
+	return enrollment, nil
+}
+
+// ListMyCourses возвращает видимые курсы, на которые записан пользователь (кроме отчисления)
+func ListMyCourses(actor User) []MyCourse {
+	enrollmentMu.RLock()
+	states := map[string]string{}
+	for courseID, enrollments := range enrollmentDB {
+		if enrollment, ok := enrollments[actor.ID]; ok && enrollment.State != EnrollmentDropped {
+			states[courseID] = enrollment.State
+		}
+	}
+	enrollmentMu.RUnlock()
+
+	courseMu.RLock()
+	courses := make([]MyCourse, 0, len(states))
+	for courseID, state := range states {
+		if course, exists := courseDB[courseID]; exists {
+			courses = append(courses, MyCourse{Course: course, EnrollmentState: state})
+		}
+	}
+	courseMu.RUnlock()
+
+	// скрытый курс не показывается студенту, даже если тот на него записан
+	courses = slices.DeleteFunc(courses, func(course MyCourse) bool { return !canSeeCourse(actor, course.Course) })
+
+	sort.Slice(courses, func(i, j int) bool { return courses[i].ID < courses[j].ID })
+	return courses
+}
+
+// Хендлеры
+
+// GET /api/me/courses
+func GetMyCoursesHandler(c echo.Context) error {
+	actor, ok := currentUser(c)
+	if !ok {
+		return writeError(c, errUnauthorized)
+	}
+
+	return c.JSON(http.StatusOK, ListMyCourses(actor))
+}
+
+// GET /api/courses/:courseId/enrollments
+func GetEnrollmentsHandler(c echo.Context) error {
+	actor, ok := currentUser(c)
+	if !ok {
+		return writeError(c, errUnauthorized)
+	}
+
+	enrollments, err := ListEnrollments(actor, c.Param("courseId"))
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, enrollments)
+}
+
+// POST /api/courses/:courseId/enrollments
+func CreateEnrollmentHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var req PostEnrollmentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON payload"})
+	}
+
+	enrollment, err := EnrollStudent(actor, c.Param("courseId"), req)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, enrollment)
+}
+
+// PUT /api/courses/:courseId/enrollments/:userId
+func UpdateEnrollmentHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var req PutEnrollmentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON payload"})
+	}
+
+	enrollment, err := SetEnrollmentState(actor, c.Param("courseId"), c.Param("userId"), req)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, enrollment)
+}
@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func setupEchoEnrollments() *echo.Echo {
+	e := echo.New()
+	api := e.Group("/api")
+
+	api.GET("/me/courses", GetMyCoursesHandler)
+	api.GET("/courses/:courseId/enrollments", GetEnrollmentsHandler)
+	api.POST("/courses/:courseId/enrollments", CreateEnrollmentHandler)
+	api.PUT("/courses/:courseId/enrollments/:userId", UpdateEnrollmentHandler)
+	api.GET("/courses/:courseId/scores", GetCourseScoresHandler)
+
+	return e
+}
+
+func resetEnrollmentDB() {
+	resetNamespaceDB()
+
+	enrollmentMu.Lock()
+	enrollmentDB = map[string]map[string]Enrollment{
+		"algorithms": {
+			"u-4": {CourseID: "algorithms", UserID: "u-4", Username: "student", State: EnrollmentActive},
+		},
+		"hidden": {
+			"u-4": {CourseID: "hidden", UserID: "u-4", Username: "student", State: EnrollmentDropped},
+		},
+	}
+	enrollmentMu.Unlock()
+
+	submissionMu.Lock()
+	submissionDB = []Submission{
+		{ID: 1, CourseID: "algorithms", UserID: "u-4", TaskID: "t1", Score: 5},
+		{ID: 2, CourseID: "algorithms", UserID: "u-4", TaskID: "t1", Score: 8},
+		{ID: 3, CourseID: "algorithms", UserID: "u-4", TaskID: "t2", Score: 3},
+	}
+	submissionMu.Unlock()
+}
+
+func TestCanTransition(t *testing.T) {
+	cases := []struct {
+		from, to string
+		ok       bool
+	}{
+		{EnrollmentPending, EnrollmentActive, true},
+		{EnrollmentActive, EnrollmentDropped, true},
+		{EnrollmentDropped, EnrollmentActive, true},
+		{EnrollmentActive, EnrollmentCompleted, true},
+		{EnrollmentDropped, EnrollmentCompleted, false},
+		{EnrollmentCompleted, EnrollmentPending, false},
+		{EnrollmentActive, "graduated", false},
+	}
+
+	for _, tc := range cases {
+		if got := canTransition(tc.from, tc.to); got != tc.ok {
+			t.Errorf("canTransition(%s, %s) = %v, want %v", tc.from, tc.to, got, tc.ok)
+		}
+	}
+}
+
+func TestEnrollStudent(t *testing.T) {
+	resetEnrollmentDB()
+	e := setupEchoEnrollments()
+
+	// студент не может записывать других
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/courses/algorithms/enrollments", "student-token", []byte(`{"username":"outsider"}`)))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/courses/algorithms/enrollments", "pm-token", []byte(`{"username":"outsider"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if enrollmentState("algorithms", "u-5") != EnrollmentActive {
+		t.Fatal("outsider should be enrolled")
+	}
+	if namespaceRole("ns-01", "u-5") != RoleStudent {
+		t.Fatal("enrolled user should become a namespace student")
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/courses/algorithms/enrollments", "pm-token", []byte(`{"username":"outsider"}`)))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 on repeated enrollment, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/courses/algorithms/enrollments", "pm-token", []byte(`{"username":"nobody"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown user, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/courses/missing/enrollments", "pm-token", []byte(`{"username":"outsider"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown course, got %d", rec.Code)
+	}
+}
+
+func TestEnrollStudent_CourseOwner(t *testing.T) {
+	resetEnrollmentDB()
+	e := setupEchoEnrollments()
+
+	courseMu.Lock()
+	course := courseDB["algorithms"]
+	course.Owners = []string{"outsider"}
+	courseDB["algorithms"] = course
+	courseMu.Unlock()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses/algorithms/enrollments", "outsider-token", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for course owner, got %d", rec.Code)
+	}
+
+	var enrollments []Enrollment
+	json.Unmarshal(rec.Body.Bytes(), &enrollments)
+	if len(enrollments) != 1 || enrollments[0].Username != "student" {
+		t.Fatalf("unexpected enrollments: %+v", enrollments)
+	}
+}
+
+func TestDropAndReactivate(t *testing.T) {
+	resetEnrollmentDB()
+	e := setupEchoEnrollments()
+
+	scores := func() []ScoreRow {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses/algorithms/scores", "nsadmin-token", nil))
+		var rows []ScoreRow
+		json.Unmarshal(rec.Body.Bytes(), &rows)
+		return rows
+	}
+
+	if rows := scores(); len(rows) != 1 || rows[0].Score != 11 {
+		t.Fatalf("unexpected scores before drop: %+v", rows)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPut, "/api/courses/algorithms/enrollments/u-4", "nsadmin-token", []byte(`{"state":"dropped"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if rows := scores(); len(rows) != 0 {
+		t.Fatalf("dropped student must not be in scores: %+v", rows)
+	}
+	submissionMu.RLock()
+	kept := len(submissionDB)
+	submissionMu.RUnlock()
+	if kept != 3 {
+		t.Fatalf("submissions of dropped student must be kept, got %d", kept)
+	}
+
+	// из dropped нельзя сразу завершить курс
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPut, "/api/courses/algorithms/enrollments/u-4", "nsadmin-token", []byte(`{"state":"completed"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid transition, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPut, "/api/courses/algorithms/enrollments/u-4", "nsadmin-token", []byte(`{"state":"active"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	if rows := scores(); len(rows) != 1 || rows[0].Score != 11 {
+		t.Fatalf("reactivated student should be back in scores: %+v", rows)
+	}
+}
+
+func TestUpdateEnrollment_Errors(t *testing.T) {
+	resetEnrollmentDB()
+	e := setupEchoEnrollments()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPut, "/api/courses/algorithms/enrollments/u-4", "student-token", []byte(`{"state":"dropped"}`)))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPut, "/api/courses/algorithms/enrollments/u-5", "pm-token", []byte(`{"state":"dropped"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodPut, "/api/courses/algorithms/enrollments/u-4", []byte(`{"state":"dropped"}`)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestGetMyCourses(t *testing.T) {
+	resetEnrollmentDB()
+	e := setupEchoEnrollments()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/me/courses", "student-token", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var courses []MyCourse
+	json.Unmarshal(rec.Body.Bytes(), &courses)
+	if len(courses) != 1 || courses[0].ID != "algorithms" || courses[0].EnrollmentState != EnrollmentActive {
+		t.Fatalf("dropped courses must be excluded: %+v", courses)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodGet, "/api/me/courses", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestHiddenCourse_WritesAnswerNotFound(t *testing.T) {
+	resetEnrollmentDB()
+	outsider, _ := findUserByUsername("outsider")
+
+	calls := map[string]func() error{
+		"list enrollments": func() error { _, err := ListEnrollments(outsider, "hidden"); return err },
+		"enroll": func() error {
+			_, err := EnrollStudent(outsider, "hidden", PostEnrollmentRequest{Username: "student"})
+			return err
+		},
+		"set enrollment state": func() error {
+			_, err := SetEnrollmentState(outsider, "hidden", "u-4", PutEnrollmentRequest{State: EnrollmentActive})
+			return err
+		},
+		"delete":     func() error { _, err := DeleteCourse(outsider, "hidden", 0, time.Now()); return err },
+		"rename":     func() error { _, err := RenameCourse(outsider, "hidden", 0, "secret"); return err },
+		"set status": func() error { _, err := SetCourseStatus(outsider, "hidden", 0, "finished"); return err },
+	}
+	for name, call := range calls {
+		if err := call(); apiStatus(err) != http.StatusNotFound {
+			t.Errorf("%s: invisible course must answer 404, got %v", name, err)
+		}
+	}
+}
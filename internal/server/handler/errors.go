@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// apiError - ошибка доменной операции вместе с HTTP-статусом
+type apiError struct {
+	Status  int
+	Message string
+	Details []ValidationError
+}
+
+func (e *apiError) Error() string {
+	if len(e.Details) == 0 {
+		return e.Message
+	}
+
+	fields := make([]string, 0, len(e.Details))
+	for _, detail := range e.Details {
+		fields = append(fields, detail.Field+": "+detail.Message)
+	}
+	return e.Message + " (" + strings.Join(fields, "; ") + ")"
+}
+
+func newAPIError(status int, message string) *apiError {
+	return &apiError{Status: status, Message: message}
+}
+
+func validationFailed(errs []ValidationError) *apiError {
+	return &apiError{Status: http.StatusBadRequest, Message: "validation failed", Details: errs}
+}
+
+var (
+	errUnauthorized = newAPIError(http.StatusUnauthorized, "authorization required")
+	errForbidden    = newAPIError(http.StatusForbidden, "forbidden")
+)
+
+// writeError отдает ошибку в том же формате, что и остальные хендлеры
+func writeError(c echo.Context, err error) error {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "internal error"})
+	}
+
+	if len(apiErr.Details) > 0 {
+		details := localizeErrors(apiErr.Details, preferredLanguage(c.Request().Header.Get("Accept-Language")))
+		return c.JSON(apiErr.Status, map[string]interface{}{"error": apiErr.Message, "details": details})
+	}
+	return c.JSON(apiErr.Status, map[string]string{"error": apiErr.Message})
+}
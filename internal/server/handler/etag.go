@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	errPreconditionRequired = newAPIError(http.StatusPreconditionRequired, "If-Match header is required")
+	errPreconditionFailed   = newAPIError(http.StatusPreconditionFailed, "resource has been modified, reload it and retry")
+)
+
+// versionETag - ETag ресурса с номером версии
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// contentETag - ETag по содержимому ответа для ресурсов, которые собираются
+// из нескольких источников, как доска заданий
+func contentETag(v any) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// ifMatchVersion - версия из If-Match для изменяющих запросов. "*" - любая
+// версия (0). ETag не нашего формата, в том числе слабый, не совпадет ни с
+// какой версией, поэтому сразу 412
+func ifMatchVersion(c echo.Context) (int, error) {
+	value := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if value == "" {
+		return 0, errPreconditionRequired
+	}
+	if value == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(value, `"`), `"`))
+	if err != nil || version < 1 || value != versionETag(version) {
+		return 0, errPreconditionFailed
+	}
+	return version, nil
+}
+
+// notModified ставит ETag и сообщает, совпал ли он с If-None-Match.
+// Сравнение слабое, как требует RFC 9110: W/"1" совпадает с "1"
+func notModified(c echo.Context, etag string) bool {
+	c.Response().Header().Set("ETag", etag)
+
+	header := c.Request().Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"fcstask-backend/internal/fixtures"
+)
+
+var (
+	// nil - демо-режим выключен, POST /api/demo/reset отвечает 404
+	demoFixtures *fixtures.Fixtures
+	demoMu       sync.Mutex
+)
+
+// SetDemoFixtures включает демо-режим: хранилище можно сбросить к этим фикстурам
+func SetDemoFixtures(f *fixtures.Fixtures) {
+	demoMu.Lock()
+	demoFixtures = f
+	demoMu.Unlock()
+}
+
+// ApplyFixtures заменяет все хранилище содержимым фикстур: пользователи,
+// namespace'ы, курсы, записи, посылки и доски. Приглашения, журнал аудита
+// и незавершенные регистрации сбрасываются
+func ApplyFixtures(f *fixtures.Fixtures) error {
+	s, err := fixturesSnapshot(f)
+	if err != nil {
+		return err
+	}
+
+	applySnapshot(s)
+	return nil
+}
+
+// fixturesSnapshot переводит фикстуры в модели хранилища и проверяет значения
+// ролей, статусов и дат, о которых сам формат фикстур не знает
+func fixturesSnapshot(f *fixtures.Fixtures) (snapshot, error) {
+	if err := f.Validate(); err != nil {
+		return snapshot{}, newAPIError(http.StatusBadRequest, err.Error())
+	}
+
+	s := snapshot{
+		namespaces:  map[string]Namespace{},
+		members:     map[string]map[string]string{},
+		users:       map[string]User{},
+		courses:     map[string]Course{},
+		aliases:     map[string]string{},
+		boards:      map[string]TaskBoardSummary{},
+		enrollments: map[string]map[string]Enrollment{},
+		submissions: []Submission{},
+		invites:     map[string]Invite{},
+		signups:     map[string]Signup{},
+		audit:       []AuditEntry{},
+	}
+
+	var errs []ValidationError
+	invalid := func(field, format string, args ...any) {
+		errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// логин -> ID, в фикстурах пользователи указываются по логину
+	userIDs := map[string]string{}
+	for i, fu := range f.Users {
+		role := fu.Role
+		if role == "" {
+			role = RoleStudent
+		}
+		if !isValidInstanceRole(role) {
+			invalid(fmt.Sprintf("users[%d].role", i), "invalid role value: %s", role)
+		}
+
+		s.users[fu.ID] = User{
+			ID:            fu.ID,
+			Username:      fu.Username,
+			RmsID:         fu.RmsID,
+			Role:          role,
+			Email:         fu.Email,
+			Telegram:      fu.Telegram,
+			Group:         fu.Group,
+			Token:         fu.Token,
+			EmailVerified: true,
+		}
+		userIDs[fu.Username] = fu.ID
+	}
+
+	for i, fn := range f.Namespaces {
+		allowedRoles := fn.AllowedRoles
+		if len(allowedRoles) == 0 {
+			allowedRoles = namespaceRoles
+		}
+		for _, role := range allowedRoles {
+			if !slices.Contains(namespaceRoles, role) {
+				invalid(fmt.Sprintf("namespaces[%d].allowedRoles", i), "invalid role value: %s", role)
+			}
+		}
+
+		s.namespaces[fn.ID] = Namespace{
+			ID:            fn.ID,
+			Name:          fn.Name,
+			Slug:          fn.Slug,
+			Description:   fn.Description,
+			GitlabGroupID: fn.GitlabGroupID,
+			AllowedRoles:  allowedRoles,
+		}
+
+		s.members[fn.ID] = map[string]string{}
+		for _, username := range slices.Sorted(maps.Keys(fn.Members)) {
+			role := fn.Members[username]
+			if !slices.Contains(allowedRoles, role) {
+				invalid(fmt.Sprintf("namespaces[%d].members.%s", i, username), "role %s is not allowed in the namespace", role)
+			}
+			s.members[fn.ID][userIDs[username]] = role
+		}
+	}
+
+	for i, fc := range f.Courses {
+		field := func(name string) string { return fmt.Sprintf("courses[%d].%s", i, name) }
+
+		status := fc.Status
+		if status == "" {
+			status = "created"
+		}
+
+		// Курс из фикстур проверяется по правилам POST /api/courses. Владельцев
+		// и namespace уже проверил fixtures.Validate, курс может быть без namespace
+		req := PostCourseRequest{
+			Name:         fc.Name,
+			Slug:         fc.Slug,
+			Status:       status,
+			StartDate:    fc.StartDate,
+			EndDate:      fc.EndDate,
+			Timezone:     fc.Timezone,
+			RepoTemplate: fc.RepoTemplate,
+			Description:  fc.Description,
+			NamespaceID:  fc.Namespace,
+		}
+		var v validator
+		req.checkFields(&v)
+		courseErrs := slices.DeleteFunc(v.errs, func(e ValidationError) bool { return e.Field == "namespaceId" })
+		errs = append(errs, prefixErrors(field(""), courseErrs)...)
+
+		owners := append([]string{}, fc.Owners...)
+		startDate, _ := ParseDate(fc.StartDate)
+		endDate, _ := ParseDate(fc.EndDate)
+		course := Course{
+			ID:           fc.Slug,
+			Name:         fc.Name,
+			Status:       status,
+			StartDate:    startDate,
+			EndDate:      endDate,
+			Timezone:     timezoneName(fc.Timezone),
+			RepoTemplate: fc.RepoTemplate,
+			Description:  fc.Description,
+			URL:          "/course/" + fc.Slug,
+			NamespaceID:  fc.Namespace,
+			GitlabGroup:  fc.Slug,
+			Owners:       owners,
+			Version:      1,
+		}
+		s.courses[fc.Slug] = course
+
+		if len(fc.Enrollments) > 0 {
+			s.enrollments[fc.Slug] = map[string]Enrollment{}
+		}
+		for _, username := range slices.Sorted(maps.Keys(fc.Enrollments)) {
+			state := fc.Enrollments[username]
+			if _, ok := enrollmentTransitions[state]; !ok {
+				invalid(field("enrollments."+username), "invalid state value: %s", state)
+			}
+			userID := userIDs[username]
+			s.enrollments[fc.Slug][userID] = Enrollment{CourseID: fc.Slug, UserID: userID, Username: username, State: state}
+		}
+
+		for _, fsub := range fc.Submissions {
+			s.submissions = append(s.submissions, Submission{
+				ID:          len(s.submissions) + 1,
+				CourseID:    fc.Slug,
+				UserID:      userIDs[fsub.Student],
+				TaskID:      fsub.Task,
+				Score:       fsub.Score,
+				SubmittedAt: fsub.SubmittedAt.UTC(),
+			})
+		}
+
+		if fc.Board != nil {
+			board, boardErrs := fixturesBoard(fc, course)
+			errs = append(errs, prefixErrors(field("board."), boardErrs)...)
+			s.boards[fc.Slug] = board
+		}
+	}
+
+	if len(errs) > 0 {
+		return snapshot{}, validationFailed(errs)
+	}
+	return s, nil
+}
+
+// fixturesBoard переводит доску фикстур в модель. Время разбирается как
+// в структуре курса: со смещением, местное время курса или дата
+func fixturesBoard(fc fixtures.Course, course Course) (TaskBoardSummary, []ValidationError) {
+	loc := courseLocation(course)
+	var v validator
+	parseTime := func(field, value string, endOfDay bool) time.Time {
+		if value == "" {
+			return time.Time{}
+		}
+		t, _ := v.checkTime(field, value, loc, endOfDay)
+		return t
+	}
+
+	board := TaskBoardSummary{
+		CourseName:    fc.Name,
+		CourseStatus:  course.Status,
+		SolvedScore:   fc.Board.SolvedScore,
+		MaxScore:      fc.Board.MaxScore,
+		SolvedPercent: fc.Board.SolvedPercent,
+		Groups:        []BoardGroup{},
+	}
+
+	for i, fg := range fc.Board.Groups {
+		field := fmt.Sprintf("groups[%d]", i)
+		group := BoardGroup{
+			ID:        fg.ID,
+			Version:   1,
+			Name:      fg.Name,
+			IsSpecial: fg.IsSpecial,
+			StartedAt: parseTime(field+".startedAt", fg.StartedAt, false),
+			EndsAt:    parseTime(field+".endsAt", fg.EndsAt, true),
+			Deadlines: []BoardDeadline{},
+			Tasks:     []BoardTask{},
+		}
+		for j, fd := range fg.Deadlines {
+			group.Deadlines = append(group.Deadlines, BoardDeadline{
+				ID:      fd.ID,
+				Label:   fd.Label,
+				Percent: fd.Percent,
+				DueAt:   parseTime(fmt.Sprintf("%s.deadlines[%d].dueAt", field, j), fd.DueAt, true),
+				Status:  fd.Status,
+			})
+		}
+		for _, ft := range fg.Tasks {
+			group.Tasks = append(group.Tasks, BoardTask(ft))
+		}
+		board.Groups = append(board.Groups, group)
+	}
+	return board, v.errs
+}
+
+// POST /api/demo/reset
+func DemoResetHandler(c echo.Context) error {
+	demoMu.Lock()
+	defer demoMu.Unlock()
+
+	if demoFixtures == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "demo mode is disabled"})
+	}
+
+	actor, ok := currentUser(c)
+	if !ok {
+		return writeError(c, errUnauthorized)
+	}
+	if actor.Role != RoleInstanceAdmin {
+		return writeError(c, errForbidden)
+	}
+
+	if err := ApplyFixtures(demoFixtures); err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, GetInstanceSummary(c.Request().Context()))
+}
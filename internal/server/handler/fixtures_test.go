@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"fcstask-backend/internal/fixtures"
+)
+
+func TestApplyFixtures_Demo(t *testing.T) {
+	if err := ApplyFixtures(fixtures.Demo()); err != nil {
+		t.Fatal(err)
+	}
+
+	course, err := getCourse("algorithms")
+	if err != nil || course.URL != "/course/algorithms" || course.NamespaceID != "ns-01" || len(course.Owners) != 2 {
+		t.Fatalf("course not applied: %+v, %v", course, err)
+	}
+	if namespaceMembers["ns-01"]["u-2"] != RoleProgramManager {
+		t.Errorf("members must be keyed by user ID: %v", namespaceMembers["ns-01"])
+	}
+	if enrollment := enrollmentDB["golang"]["u-3"]; enrollment.State != EnrollmentCompleted || enrollment.Username != "sasha" {
+		t.Errorf("enrollment not applied: %+v", enrollment)
+	}
+	if board := boardData["algorithms"]; board.CourseName != "Algorithms 101" || len(board.Groups[1].Tasks) != 3 || !board.Groups[1].Tasks[2].IsBonus {
+		t.Errorf("board not applied: %+v", board)
+	}
+
+	if rows := gradebook(t.Context(), "algorithms"); len(rows) != 1 || rows[0].Score != 30 {
+		t.Errorf("scores from fixtures: %+v", rows)
+	}
+}
+
+func TestApplyFixtures_InvalidValues(t *testing.T) {
+	resetDB()
+
+	f := &fixtures.Fixtures{
+		Users: []fixtures.User{{ID: "u-1", Username: "kate", Role: "superuser"}},
+		Courses: []fixtures.Course{{
+			Slug: "os", Name: "OS", Status: "archived", StartDate: "2025-05-30", EndDate: "2025-02-01",
+			Enrollments: map[string]string{"kate": "sleeping"},
+		}},
+	}
+
+	err := ApplyFixtures(f)
+	if apiStatus(err) != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %v", err)
+	}
+	for _, want := range []string{"users[0].role", "courses[0].status", "courses[0].dateRange", "courses[0].repoTemplate", "courses[0].enrollments.kate"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %s: %v", want, err)
+		}
+	}
+
+	// при ошибке хранилище не меняется
+	if _, err := getCourse("algorithms"); err != nil {
+		t.Error("invalid fixtures must not be applied")
+	}
+}
+
+func TestDemoResetHandler(t *testing.T) {
+	e := echo.New()
+	e.POST("/api/demo/reset", DemoResetHandler)
+
+	reset := func(token string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, authReq(http.MethodPost, "/api/demo/reset", token, nil))
+		return rec
+	}
+
+	SetDemoFixtures(nil)
+	if err := ApplyFixtures(fixtures.Demo()); err != nil {
+		t.Fatal(err)
+	}
+	if rec := reset("alex-token"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 outside demo mode, got %d", rec.Code)
+	}
+
+	SetDemoFixtures(fixtures.Demo())
+	t.Cleanup(func() { SetDemoFixtures(nil) })
+
+	if rec := reset(""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+	if rec := reset("sasha-token"); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a student, got %d", rec.Code)
+	}
+
+	// портим данные и сбрасываем обратно
+	courseMu.Lock()
+	delete(courseDB, "algorithms")
+	courseMu.Unlock()
+	inviteMu.Lock()
+	inviteDB["leftover"] = Invite{Code: "leftover"}
+	inviteMu.Unlock()
+
+	rec := reset("alex-token")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var summary InstanceSummary
+	json.Unmarshal(rec.Body.Bytes(), &summary)
+	if summary.TotalCourses != 6 || summary.TotalUsers != 3 {
+		t.Errorf("unexpected summary after reset: %+v", summary)
+	}
+	if _, err := getCourse("algorithms"); err != nil {
+		t.Error("course not restored by reset")
+	}
+	if len(inviteDB) != 0 {
+		t.Errorf("invites must be cleared: %v", inviteDB)
+	}
+}
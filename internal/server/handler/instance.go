@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"fcstask-backend/internal/health"
+)
+
+// HealthChecker - источник состояния зависимостей инстанса
+type HealthChecker interface {
+	Check(ctx context.Context) health.Report
+}
+
+// InstanceSummary - сводка для панели инстанс-админа
+type InstanceSummary struct {
+	TotalCourses    int                `json:"totalCourses"`
+	TotalUsers      int                `json:"totalUsers"`
+	TotalNamespaces int                `json:"totalNamespaces"`
+	HealthStatus    string             `json:"healthStatus"`
+	Components      []health.Component `json:"components"`
+}
+
+var healthChecker HealthChecker
+
+// SetHealthChecker подключает проверки зависимостей к сводке инстанса
+func SetHealthChecker(h HealthChecker) {
+	healthChecker = h
+}
+
+// GetInstanceSummary считает сводку по хранилищу и проверкам зависимостей
+func GetInstanceSummary(ctx context.Context) InstanceSummary {
+	var summary InstanceSummary
+
+	courseMu.RLock()
+	summary.TotalCourses = len(courseDB)
+	courseMu.RUnlock()
+
+	userMu.RLock()
+	summary.TotalUsers = len(userDB)
+	userMu.RUnlock()
+
+	namespaceMu.RLock()
+	summary.TotalNamespaces = len(namespaceDB)
+	namespaceMu.RUnlock()
+
+	report := health.Report{Status: health.StatusOK}
+	if healthChecker != nil {
+		report = healthChecker.Check(ctx)
+	}
+
+	summary.HealthStatus = report.Status
+	summary.Components = report.Components
+	if summary.Components == nil {
+		summary.Components = []health.Component{}
+	}
+
+	return summary
+}
+
+// CourseStatusCounts возвращает число курсов по статусам, для метрик
+func CourseStatusCounts() map[string]int {
+	courseMu.RLock()
+	defer courseMu.RUnlock()
+
+	counts := map[string]int{}
+	for _, course := range courseDB {
+		counts[course.Status]++
+	}
+	return counts
+}
+
+// GET /api/instance/summary
+func GetInstanceSummaryHandler(c echo.Context) error {
+	actor, ok := currentUser(c)
+	if !ok {
+		return writeError(c, errUnauthorized)
+	}
+	if actor.Role != RoleInstanceAdmin {
+		return writeError(c, errForbidden)
+	}
+
+	return c.JSON(http.StatusOK, GetInstanceSummary(c.Request().Context()))
+}
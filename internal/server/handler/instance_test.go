@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"fcstask-backend/internal/health"
+)
+
+func setupEchoInstance() *echo.Echo {
+	e := echo.New()
+	e.GET("/api/instance/summary", GetInstanceSummaryHandler)
+	return e
+}
+
+func TestGetInstanceSummary_Counts(t *testing.T) {
+	resetNamespaceDB()
+	SetHealthChecker(nil)
+	e := setupEchoInstance()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/instance/summary", "admin-token", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var summary InstanceSummary
+	json.Unmarshal(rec.Body.Bytes(), &summary)
+	if summary.TotalCourses != 2 || summary.TotalUsers != 5 || summary.TotalNamespaces != 2 || summary.HealthStatus != health.StatusOK {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestGetInstanceSummary_Degraded(t *testing.T) {
+	resetNamespaceDB()
+	registry := health.NewRegistry(time.Second)
+	registry.Register("mailer", health.CheckerFunc(func(context.Context) error { return nil }))
+	registry.Register("vcs", health.CheckerFunc(func(context.Context) error { return errors.New("connection refused") }))
+	SetHealthChecker(registry)
+	defer SetHealthChecker(nil)
+	e := setupEchoInstance()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/instance/summary", "admin-token", nil))
+
+	var summary InstanceSummary
+	json.Unmarshal(rec.Body.Bytes(), &summary)
+	if summary.HealthStatus != health.StatusDegraded {
+		t.Fatalf("expected degraded, got %+v", summary)
+	}
+
+	failed := summary.Components[1]
+	if failed.Name != "vcs" || failed.Error != "connection refused" || failed.Since.IsZero() {
+		t.Errorf("unexpected failed component: %+v", failed)
+	}
+}
+
+func TestGetInstanceSummary_Forbidden(t *testing.T) {
+	resetNamespaceDB()
+	e := setupEchoInstance()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/instance/summary", "nsadmin-token", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Invite - инвайт-код на курс или в namespace
+type Invite struct {
+	Code        string    `json:"code"`
+	CourseID    string    `json:"courseId,omitempty"`
+	NamespaceID string    `json:"namespaceId"`
+	Role        string    `json:"role"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	MaxUses     int       `json:"maxUses"`
+	Uses        int       `json:"uses"`
+	Revoked     bool      `json:"revoked"`
+	CreatedBy   string    `json:"createdBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// PostInviteRequest - тело запроса на создание инвайта.
+// Указывается либо courseId, либо namespaceId
+type PostInviteRequest struct {
+	CourseID    string `json:"courseId"`
+	NamespaceID string `json:"namespaceId"`
+	Role        string `json:"role"`
+	ExpiresAt   string `json:"expiresAt"`
+	MaxUses     int    `json:"maxUses"`
+}
+
+// In-memory storage
+var (
+	inviteDB = map[string]Invite{}
+	inviteMu sync.RWMutex
+)
+
+func (req *PostInviteRequest) Validate() []ValidationError {
+	var errs []ValidationError
+
+	switch {
+	case req.CourseID == "" && req.NamespaceID == "":
+		errs = append(errs, ValidationError{Field: "courseId", Message: "courseId or namespaceId is required"})
+	case req.CourseID != "" && req.NamespaceID != "":
+		errs = append(errs, ValidationError{Field: "namespaceId", Message: "only one of courseId and namespaceId may be set"})
+	}
+
+	if req.Role == "" {
+		errs = append(errs, ValidationError{Field: "role", Message: "role is required"})
+	} else if req.CourseID != "" && req.Role != RoleStudent {
+		errs = append(errs, ValidationError{Field: "role", Message: "course invites can only grant the student role"})
+	} else if !slices.Contains(namespaceRoles, req.Role) {
+		errs = append(errs, ValidationError{Field: "role", Message: "invalid role value"})
+	}
+
+	if req.ExpiresAt == "" {
+		errs = append(errs, ValidationError{Field: "expiresAt", Message: "expiresAt is required"})
+	} else if expiresAt, err := time.Parse(time.RFC3339, req.ExpiresAt); err != nil {
+		errs = append(errs, ValidationError{Field: "expiresAt", Message: "expiresAt must be in RFC 3339 format"})
+	} else if !expiresAt.After(time.Now()) {
+		errs = append(errs, ValidationError{Field: "expiresAt", Message: "expiresAt must be in the future"})
+	}
+
+	if req.MaxUses <= 0 {
+		errs = append(errs, ValidationError{Field: "maxUses", Message: "maxUses must be positive"})
+	}
+
+	return errs
+}
+
+// inviteNamespace определяет namespace, в котором действует инвайт
+func inviteNamespace(courseID, namespaceID string) (string, error) {
+	if courseID == "" {
+		_, err := getNamespace(namespaceID)
+		return namespaceID, err
+	}
+
+	courseMu.RLock()
+	course, exists := courseDB[courseID]
+	courseMu.RUnlock()
+
+	if !exists {
+		return "", newAPIError(http.StatusNotFound, "course not found")
+	}
+	return course.NamespaceID, nil
+}
+
+// CreateInvite генерирует инвайт. Выдавать инвайт на роль может тот,
+// кто может назначить эту роль в namespace напрямую
+func CreateInvite(actor User, req PostInviteRequest) (Invite, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return Invite{}, validationFailed(errs)
+	}
+
+	namespaceID, err := inviteNamespace(req.CourseID, req.NamespaceID)
+	if err != nil {
+		return Invite{}, err
+	}
+
+	if !canAssignNamespaceRole(actor, namespaceID, req.Role) {
+		return Invite{}, errForbidden
+	}
+
+	expiresAt, _ := time.Parse(time.RFC3339, req.ExpiresAt)
+
+	invite := Invite{
+		Code:        randomToken(8),
+		CourseID:    req.CourseID,
+		NamespaceID: namespaceID,
+		Role:        req.Role,
+		ExpiresAt:   expiresAt.UTC(),
+		MaxUses:     req.MaxUses,
+		CreatedBy:   actor.Username,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	inviteMu.Lock()
+	inviteDB[invite.Code] = invite
+	inviteMu.Unlock()
+
+	return invite, nil
+}
+
+// ListInvites возвращает инвайты namespace, которыми может управлять пользователь
+func ListInvites(actor User, namespaceID, courseID string) []Invite {
+	inviteMu.RLock()
+	invites := make([]Invite, 0, len(inviteDB))
+	for _, invite := range inviteDB {
+		if namespaceID != "" && invite.NamespaceID != namespaceID {
+			continue
+		}
+		if courseID != "" && invite.CourseID != courseID {
+			continue
+		}
+		invites = append(invites, invite)
+	}
+	inviteMu.RUnlock()
+
+	visible := invites[:0]
+	for _, invite := range invites {
+		if canAssignNamespaceRole(actor, invite.NamespaceID, invite.Role) {
+			visible = append(visible, invite)
+		}
+	}
+
+	sort.Slice(visible, func(i, j int) bool { return visible[i].CreatedAt.Before(visible[j].CreatedAt) })
+	return visible
+}
+
+// RevokeInvite отзывает инвайт, уже выполненные регистрации остаются
+// Права проверяются по копии без inviteMu: namespaceMu под ним не берется
+// (см. moveCourseData). Если инвайт успел переехать, проверка повторяется
+func RevokeInvite(actor User, code string) (Invite, error) {
+	for {
+		inviteMu.RLock()
+		checked, exists := inviteDB[code]
+		inviteMu.RUnlock()
+		if !exists {
+			return Invite{}, newAPIError(http.StatusNotFound, "invite not found")
+		}
+
+		if !canAssignNamespaceRole(actor, checked.NamespaceID, checked.Role) {
+			return Invite{}, errForbidden
+		}
+
+		inviteMu.Lock()
+		invite, exists := inviteDB[code]
+		if exists && invite.NamespaceID == checked.NamespaceID && invite.Role == checked.Role {
+			invite.Revoked = true
+			inviteDB[code] = invite
+			inviteMu.Unlock()
+			return invite, nil
+		}
+		inviteMu.Unlock()
+	}
+}
+
+// inviteCourseDeleted - лежит ли курс инвайта в корзине. Вызывается до inviteMu:
+// courseMu берется раньше локов данных курса (см. moveCourseData)
+func inviteCourseDeleted(code string) bool {
+	inviteMu.RLock()
+	courseID := inviteDB[code].CourseID
+	inviteMu.RUnlock()
+
+	return courseID != "" && isDeletedCourse(courseID)
+}
+
+// usableInvite проверяет инвайт, не расходуя его. Вызывается под inviteMu,
+// courseDeleted - результат inviteCourseDeleted
+func usableInvite(code string, now time.Time, courseDeleted bool) (Invite, error) {
+	invite, exists := inviteDB[code]
+	switch {
+	case !exists:
+		return Invite{}, newAPIError(http.StatusNotFound, "invite not found")
+	case invite.Revoked:
+		return Invite{}, newAPIError(http.StatusGone, "invite has been revoked")
+	case !now.Before(invite.ExpiresAt):
+		return Invite{}, newAPIError(http.StatusGone, "invite has expired")
+	case invite.Uses >= invite.MaxUses:
+		return Invite{}, newAPIError(http.StatusGone, "invite usage limit reached")
+	case courseDeleted:
+		return Invite{}, newAPIError(http.StatusGone, "course has been deleted")
+	}
+	return invite, nil
+}
+
+// redeemInvite проверяет инвайт и засчитывает одно использование
+func redeemInvite(code string, now time.Time) (Invite, error) {
+	courseDeleted := inviteCourseDeleted(code)
+
+	inviteMu.Lock()
+	defer inviteMu.Unlock()
+
+	invite, err := usableInvite(code, now, courseDeleted)
+	if err != nil {
+		return Invite{}, err
+	}
+
+	invite.Uses++
+	inviteDB[code] = invite
+
+	return invite, nil
+}
+
+// Хендлеры
+
+// POST /api/invites
+func CreateInviteHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var req PostInviteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON payload"})
+	}
+
+	invite, err := CreateInvite(actor, req)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, invite)
+}
+
+// GET /api/invites?namespaceId=...&courseId=...
+func GetInvitesHandler(c echo.Context) error {
+	actor, ok := currentUser(c)
+	if !ok {
+		return writeError(c, errUnauthorized)
+	}
+
+	return c.JSON(http.StatusOK, ListInvites(actor, c.QueryParam("namespaceId"), c.QueryParam("courseId")))
+}
+
+// DELETE /api/invites/:code
+func RevokeInviteHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	invite, err := RevokeInvite(actor, c.Param("code"))
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, invite)
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func setupEchoInvites() *echo.Echo {
+	e := echo.New()
+	api := e.Group("/api")
+
+	api.POST("/invites", CreateInviteHandler)
+	api.GET("/invites", GetInvitesHandler)
+	api.DELETE("/invites/:code", RevokeInviteHandler)
+
+	return e
+}
+
+func resetInviteDB() {
+	resetNamespaceDB()
+
+	inviteMu.Lock()
+	inviteDB = map[string]Invite{}
+	inviteMu.Unlock()
+}
+
+func inviteBody(scope, role string, maxUses int) []byte {
+	expiresAt := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	return []byte(fmt.Sprintf(`{%s,"role":%q,"expiresAt":%q,"maxUses":%d}`, scope, role, expiresAt, maxUses))
+}
+
+func TestCreateInvite_Permissions(t *testing.T) {
+	cases := []struct {
+		name     string
+		token    string
+		body     []byte
+		wantCode int
+	}{
+		{"ns admin invites pm", "nsadmin-token", inviteBody(`"namespaceId":"ns-01"`, RoleProgramManager, 5), http.StatusCreated},
+		{"pm invites students to course", "pm-token", inviteBody(`"courseId":"algorithms"`, RoleStudent, 30), http.StatusCreated},
+		{"pm cannot invite pm", "pm-token", inviteBody(`"namespaceId":"ns-01"`, RoleProgramManager, 5), http.StatusForbidden},
+		{"student cannot invite", "student-token", inviteBody(`"courseId":"algorithms"`, RoleStudent, 5), http.StatusForbidden},
+		{"course invite only for students", "admin-token", inviteBody(`"courseId":"algorithms"`, RoleNamespaceAdmin, 5), http.StatusBadRequest},
+		{"both scopes", "admin-token", inviteBody(`"courseId":"algorithms","namespaceId":"ns-01"`, RoleStudent, 5), http.StatusBadRequest},
+		{"zero uses", "admin-token", inviteBody(`"namespaceId":"ns-01"`, RoleStudent, 0), http.StatusBadRequest},
+		{"unknown course", "admin-token", inviteBody(`"courseId":"nope"`, RoleStudent, 5), http.StatusNotFound},
+		{"past expiry", "admin-token", []byte(`{"namespaceId":"ns-01","role":"student","expiresAt":"2020-01-01T00:00:00Z","maxUses":1}`), http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resetInviteDB()
+			e := setupEchoInvites()
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, authReq(http.MethodPost, "/api/invites", tc.token, tc.body))
+
+			if rec.Code != tc.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tc.wantCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateInvite_CourseScopeResolvesNamespace(t *testing.T) {
+	resetInviteDB()
+	e := setupEchoInvites()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/invites", "admin-token", inviteBody(`"courseId":"algorithms"`, RoleStudent, 3)))
+
+	var invite Invite
+	json.Unmarshal(rec.Body.Bytes(), &invite)
+
+	if invite.Code == "" || invite.NamespaceID != "ns-01" || invite.CourseID != "algorithms" || invite.CreatedBy != "admin" {
+		t.Fatalf("unexpected invite: %+v", invite)
+	}
+}
+
+func TestListAndRevokeInvites(t *testing.T) {
+	resetInviteDB()
+	e := setupEchoInvites()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/invites", "nsadmin-token", inviteBody(`"namespaceId":"ns-01"`, RoleNamespaceAdmin, 1)))
+	var adminInvite Invite
+	json.Unmarshal(rec.Body.Bytes(), &adminInvite)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/invites", "pm-token", inviteBody(`"namespaceId":"ns-01"`, RoleStudent, 1)))
+
+	// program manager видит только инвайты на студентов
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/invites?namespaceId=ns-01", "pm-token", nil))
+	var invites []Invite
+	json.Unmarshal(rec.Body.Bytes(), &invites)
+	if len(invites) != 1 || invites[0].Role != RoleStudent {
+		t.Fatalf("unexpected invites for pm: %+v", invites)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodDelete, "/api/invites/"+adminInvite.Code, "pm-token", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodDelete, "/api/invites/"+adminInvite.Code, "nsadmin-token", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	if _, err := redeemInvite(adminInvite.Code, time.Now()); err == nil {
+		t.Fatal("revoked invite must not be redeemable")
+	}
+}
+
+func TestRedeemInvite_LimitsAndExpiry(t *testing.T) {
+	resetInviteDB()
+
+	now := time.Now()
+	inviteMu.Lock()
+	inviteDB["once"] = Invite{Code: "once", NamespaceID: "ns-01", Role: RoleStudent, MaxUses: 1, ExpiresAt: now.Add(time.Hour)}
+	inviteDB["old"] = Invite{Code: "old", NamespaceID: "ns-01", Role: RoleStudent, MaxUses: 10, ExpiresAt: now.Add(-time.Second)}
+	inviteMu.Unlock()
+
+	if _, err := redeemInvite("once", now); err != nil {
+		t.Fatalf("first use should succeed: %v", err)
+	}
+	if _, err := redeemInvite("once", now); err == nil {
+		t.Fatal("second use should exceed the limit")
+	}
+	if _, err := redeemInvite("old", now); err == nil {
+		t.Fatal("expired invite should be rejected")
+	}
+	if _, err := redeemInvite("missing", now); err == nil {
+		t.Fatal("unknown invite should be rejected")
+	}
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// mergePatch применяет JSON Merge Patch (RFC 7396): объекты сливаются по ключам,
+// null удаляет ключ, любое другое значение заменяет целиком, включая массивы
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
+
+// isMergePatchContentType - PATCH принимает application/merge-patch+json
+// и, для простых клиентов, application/json
+func isMergePatchContentType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	return mediaType == "application/merge-patch+json" || mediaType == "application/json"
+}
+
+// decodeJSONBody разбирает тело в v. Значение не того типа в поле становится
+// ошибкой валидации этого поля, а не общим "invalid JSON payload"
+func decodeJSONBody(data []byte, v any) error {
+	err := json.Unmarshal(data, v)
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return validationFailed([]ValidationError{newValidationError(typeErr.Field, CodeInvalidType, map[string]string{"type": jsonTypeName(typeErr.Type)})})
+	default:
+		return newAPIError(http.StatusBadRequest, "invalid JSON payload")
+	}
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	default:
+		return "number"
+	}
+}
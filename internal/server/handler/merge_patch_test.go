@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Примеры из приложения A RFC 7396
+func TestMergePatch(t *testing.T) {
+	cases := []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tc := range cases {
+		var target, patch, want any
+		json.Unmarshal([]byte(tc.target), &target)
+		json.Unmarshal([]byte(tc.patch), &patch)
+		json.Unmarshal([]byte(tc.want), &want)
+
+		got, _ := json.Marshal(mergePatch(target, patch))
+		wantJSON, _ := json.Marshal(want)
+		if string(got) != string(wantJSON) {
+			t.Errorf("mergePatch(%s, %s) = %s, want %s", tc.target, tc.patch, got, wantJSON)
+		}
+	}
+}
+
+func TestIsMergePatchContentType(t *testing.T) {
+	for contentType, want := range map[string]bool{
+		"application/merge-patch+json":                true,
+		"application/merge-patch+json; charset=utf-8": true,
+		"application/json":                            true,
+		"Application/JSON":                            true,
+		"application/json-patch+json":                 false,
+		"text/plain":                                  false,
+		"":                                            false,
+	} {
+		if got := isMergePatchContentType(contentType); got != want {
+			t.Errorf("isMergePatchContentType(%q) = %v, want %v", contentType, got, want)
+		}
+	}
+}
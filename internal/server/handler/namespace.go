@@ -0,0 +1,459 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Namespace - пространство, объединяющее курсы и пользователей
+type Namespace struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Slug          string   `json:"slug"`
+	Description   string   `json:"description,omitempty"`
+	GitlabGroupID string   `json:"gitlabGroupId"`
+	AllowedRoles  []string `json:"allowedRoles"`
+	CoursesCount  int      `json:"coursesCount"`
+	UsersCount    int      `json:"usersCount"`
+}
+
+// NamespaceUser - участник namespace с ролью в нем
+type NamespaceUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	RmsID    string `json:"rmsId"`
+	Role     string `json:"role"`
+}
+
+// NamespaceCourse - курс в карточке namespace
+type NamespaceCourse struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Status      string   `json:"status"`
+	GitlabGroup string   `json:"gitlabGroup"`
+	Owners      []string `json:"owners"`
+	URL         string   `json:"url"`
+}
+
+// NamespaceDetails - ответ GET /api/namespaces/:namespaceId
+type NamespaceDetails struct {
+	Namespace Namespace         `json:"namespace"`
+	Users     []NamespaceUser   `json:"users"`
+	Courses   []NamespaceCourse `json:"courses"`
+}
+
+// PostNamespaceRequest - тело запроса на создание namespace
+type PostNamespaceRequest struct {
+	Name          string   `json:"name"`
+	Slug          string   `json:"slug"`
+	Description   string   `json:"description"`
+	GitlabGroupID string   `json:"gitlabGroupId"`
+	AllowedRoles  []string `json:"allowedRoles"`
+}
+
+// PostNamespaceUserRequest - тело запроса на добавление пользователя в namespace
+type PostNamespaceUserRequest struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// PutNamespaceUserRequest - тело запроса на смену роли пользователя в namespace
+type PutNamespaceUserRequest struct {
+	Role string `json:"role"`
+}
+
+var namespaceRoles = []string{RoleNamespaceAdmin, RoleProgramManager, RoleStudent}
+
+// In-memory storage
+var (
+	namespaceDB = map[string]Namespace{}
+
+	// namespaceID -> userID -> роль
+	namespaceMembers = map[string]map[string]string{}
+
+	namespaceMu sync.RWMutex
+)
+
+func (req *PostNamespaceRequest) Validate() []ValidationError {
+	var errs []ValidationError
+
+	if req.Name == "" {
+		errs = append(errs, ValidationError{Field: "name", Message: "name is required"})
+	}
+
+	if req.Slug == "" {
+		errs = append(errs, ValidationError{Field: "slug", Message: "slug is required"})
+	}
+
+	for _, role := range req.AllowedRoles {
+		if !slices.Contains(namespaceRoles, role) {
+			errs = append(errs, ValidationError{Field: "allowedRoles", Message: "invalid role value: " + role})
+		}
+	}
+
+	return errs
+}
+
+// namespaceRole возвращает роль пользователя в namespace или "", если он не участник
+func namespaceRole(namespaceID, userID string) string {
+	namespaceMu.RLock()
+	defer namespaceMu.RUnlock()
+
+	return namespaceMembers[namespaceID][userID]
+}
+
+// canAssignNamespaceRole - кто какие роли может выдавать:
+// инстанс-админ и админ namespace - любые, program manager - только студентов
+func canAssignNamespaceRole(actor User, namespaceID, role string) bool {
+	namespaceMu.RLock()
+	defer namespaceMu.RUnlock()
+
+	return canAssignNamespaceRoleLocked(actor, namespaceID, role)
+}
+
+// canAssignNamespaceRoleLocked - то же под уже взятым namespaceMu
+func canAssignNamespaceRoleLocked(actor User, namespaceID, role string) bool {
+	if actor.Role == RoleInstanceAdmin {
+		return true
+	}
+
+	switch namespaceMembers[namespaceID][actor.ID] {
+	case RoleNamespaceAdmin:
+		return true
+	case RoleProgramManager:
+		return role == RoleStudent
+	default:
+		return false
+	}
+}
+
+func canViewNamespace(actor User, namespaceID string) bool {
+	return actor.Role == RoleInstanceAdmin || namespaceRole(namespaceID, actor.ID) != ""
+}
+
+// withCounts дополняет namespace производными счетчиками
+func withCounts(ns Namespace) Namespace {
+	namespaceMu.RLock()
+	ns.UsersCount = len(namespaceMembers[ns.ID])
+	namespaceMu.RUnlock()
+
+	courseMu.RLock()
+	ns.CoursesCount = 0
+	for _, course := range courseDB {
+		if course.NamespaceID == ns.ID {
+			ns.CoursesCount++
+		}
+	}
+	courseMu.RUnlock()
+
+	return ns
+}
+
+func getNamespace(namespaceID string) (Namespace, error) {
+	namespaceMu.RLock()
+	ns, exists := namespaceDB[namespaceID]
+	namespaceMu.RUnlock()
+
+	if !exists {
+		return Namespace{}, newAPIError(http.StatusNotFound, "namespace not found")
+	}
+	return ns, nil
+}
+
+// ListNamespaces возвращает namespace, доступные пользователю
+func ListNamespaces(actor User) []Namespace {
+	namespaceMu.RLock()
+	visible := make([]Namespace, 0, len(namespaceDB))
+	for id, ns := range namespaceDB {
+		if actor.Role == RoleInstanceAdmin || namespaceMembers[id][actor.ID] != "" {
+			visible = append(visible, ns)
+		}
+	}
+	namespaceMu.RUnlock()
+
+	sort.Slice(visible, func(i, j int) bool { return visible[i].ID < visible[j].ID })
+
+	for i := range visible {
+		visible[i] = withCounts(visible[i])
+	}
+	return visible
+}
+
+// GetNamespaceDetails возвращает namespace вместе с участниками и курсами
+func GetNamespaceDetails(actor User, namespaceID string) (NamespaceDetails, error) {
+	ns, err := getNamespace(namespaceID)
+	if err != nil {
+		return NamespaceDetails{}, err
+	}
+
+	if !canViewNamespace(actor, namespaceID) {
+		return NamespaceDetails{}, errForbidden
+	}
+
+	namespaceMu.RLock()
+	members := make(map[string]string, len(namespaceMembers[namespaceID]))
+	for userID, role := range namespaceMembers[namespaceID] {
+		members[userID] = role
+	}
+	namespaceMu.RUnlock()
+
+	users := make([]NamespaceUser, 0, len(members))
+	userMu.RLock()
+	for userID, role := range members {
+		user := userDB[userID]
+		users = append(users, NamespaceUser{ID: user.ID, Username: user.Username, RmsID: user.RmsID, Role: role})
+	}
+	userMu.RUnlock()
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	courses := []NamespaceCourse{}
+	courseMu.RLock()
+	for _, course := range courseDB {
+		if course.NamespaceID == namespaceID {
+			courses = append(courses, NamespaceCourse{
+				ID:          course.ID,
+				Name:        course.Name,
+				Status:      course.Status,
+				GitlabGroup: course.GitlabGroup,
+				Owners:      course.Owners,
+				URL:         course.URL,
+			})
+		}
+	}
+	courseMu.RUnlock()
+	sort.Slice(courses, func(i, j int) bool { return courses[i].ID < courses[j].ID })
+
+	return NamespaceDetails{Namespace: withCounts(ns), Users: users, Courses: courses}, nil
+}
+
+// CreateNamespace создает namespace, доступно только инстанс-админу
+func CreateNamespace(actor User, req PostNamespaceRequest) (Namespace, error) {
+	if actor.Role != RoleInstanceAdmin {
+		return Namespace{}, errForbidden
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return Namespace{}, validationFailed(errs)
+	}
+
+	allowedRoles := req.AllowedRoles
+	if len(allowedRoles) == 0 {
+		allowedRoles = namespaceRoles
+	}
+
+	namespaceMu.Lock()
+	defer namespaceMu.Unlock()
+
+	for _, ns := range namespaceDB {
+		if ns.Slug == req.Slug {
+			return Namespace{}, newAPIError(http.StatusConflict, "namespace with this slug already exists")
+		}
+	}
+
+	id := ""
+	for n := len(namespaceDB) + 1; ; n++ {
+		id = fmt.Sprintf("ns-%02d", n)
+		if _, taken := namespaceDB[id]; !taken {
+			break
+		}
+	}
+
+	ns := Namespace{
+		ID:            id,
+		Name:          req.Name,
+		Slug:          req.Slug,
+		Description:   req.Description,
+		GitlabGroupID: req.GitlabGroupID,
+		AllowedRoles:  allowedRoles,
+	}
+	namespaceDB[id] = ns
+	namespaceMembers[id] = map[string]string{}
+
+	return ns, nil
+}
+
+func checkNamespaceRole(ns Namespace, role string) error {
+	if role == "" {
+		return validationFailed([]ValidationError{{Field: "role", Message: "role is required"}})
+	}
+	if !slices.Contains(namespaceRoles, role) {
+		return validationFailed([]ValidationError{{Field: "role", Message: "invalid role value"}})
+	}
+	if !slices.Contains(ns.AllowedRoles, role) {
+		return validationFailed([]ValidationError{{Field: "role", Message: "role is not allowed in this namespace"}})
+	}
+	return nil
+}
+
+// AddNamespaceUser добавляет существующего пользователя в namespace
+func AddNamespaceUser(actor User, namespaceID string, req PostNamespaceUserRequest) (NamespaceUser, error) {
+	ns, err := getNamespace(namespaceID)
+	if err != nil {
+		return NamespaceUser{}, err
+	}
+
+	// Тем, кто не выдает роли в namespace, ошибки валидации не показываются
+	if !canAssignNamespaceRole(actor, namespaceID, RoleStudent) {
+		return NamespaceUser{}, errForbidden
+	}
+
+	if req.Username == "" {
+		return NamespaceUser{}, validationFailed([]ValidationError{{Field: "username", Message: "username is required"}})
+	}
+	if err := checkNamespaceRole(ns, req.Role); err != nil {
+		return NamespaceUser{}, err
+	}
+
+	user, exists := findUserByUsername(req.Username)
+	if !exists {
+		return NamespaceUser{}, newAPIError(http.StatusNotFound, "user not found")
+	}
+
+	// Права проверяются заново под тем же локом, что и запись
+	namespaceMu.Lock()
+	defer namespaceMu.Unlock()
+
+	if !canAssignNamespaceRoleLocked(actor, namespaceID, req.Role) {
+		return NamespaceUser{}, errForbidden
+	}
+
+	if _, member := namespaceMembers[namespaceID][user.ID]; member {
+		return NamespaceUser{}, newAPIError(http.StatusConflict, "user is already a member of this namespace")
+	}
+	namespaceMembers[namespaceID][user.ID] = req.Role
+
+	return NamespaceUser{ID: user.ID, Username: user.Username, RmsID: user.RmsID, Role: req.Role}, nil
+}
+
+// SetNamespaceUserRole меняет роль участника namespace
+func SetNamespaceUserRole(actor User, namespaceID, userID string, req PutNamespaceUserRequest) (NamespaceUser, error) {
+	ns, err := getNamespace(namespaceID)
+	if err != nil {
+		return NamespaceUser{}, err
+	}
+
+	// Тем, кто не выдает роли в namespace, ошибки валидации не показываются
+	if !canAssignNamespaceRole(actor, namespaceID, RoleStudent) {
+		return NamespaceUser{}, errForbidden
+	}
+
+	if err := checkNamespaceRole(ns, req.Role); err != nil {
+		return NamespaceUser{}, err
+	}
+
+	// Права проверяются заново под тем же локом, что и запись: роль actor
+	// или пользователя могла поменяться после первой проверки
+	namespaceMu.Lock()
+	current := namespaceMembers[namespaceID][userID]
+	if current == "" {
+		namespaceMu.Unlock()
+		return NamespaceUser{}, newAPIError(http.StatusNotFound, "user is not a member of this namespace")
+	}
+
+	// Понизить или повысить можно только того, чью текущую роль actor тоже может выдавать
+	if !canAssignNamespaceRoleLocked(actor, namespaceID, req.Role) || !canAssignNamespaceRoleLocked(actor, namespaceID, current) {
+		namespaceMu.Unlock()
+		return NamespaceUser{}, errForbidden
+	}
+
+	namespaceMembers[namespaceID][userID] = req.Role
+	namespaceMu.Unlock()
+
+	userMu.RLock()
+	user := userDB[userID]
+	userMu.RUnlock()
+
+	return NamespaceUser{ID: user.ID, Username: user.Username, RmsID: user.RmsID, Role: req.Role}, nil
+}
+
+// Хендлеры
+
+// GET /api/namespaces
+func GetNamespacesHandler(c echo.Context) error {
+	actor, ok := currentUser(c)
+	if !ok {
+		return writeError(c, errUnauthorized)
+	}
+
+	return c.JSON(http.StatusOK, ListNamespaces(actor))
+}
+
+// GET /api/namespaces/:namespaceId
+func GetNamespaceHandler(c echo.Context) error {
+	actor, ok := currentUser(c)
+	if !ok {
+		return writeError(c, errUnauthorized)
+	}
+
+	details, err := GetNamespaceDetails(actor, c.Param("namespaceId"))
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, details)
+}
+
+// POST /api/namespaces
+func CreateNamespaceHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var req PostNamespaceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON payload"})
+	}
+
+	ns, err := CreateNamespace(actor, req)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, withCounts(ns))
+}
+
+// POST /api/namespaces/:namespaceId/users
+func AddNamespaceUserHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var req PostNamespaceUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON payload"})
+	}
+
+	user, err := AddNamespaceUser(actor, c.Param("namespaceId"), req)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, user)
+}
+
+// PUT /api/namespaces/:namespaceId/users/:userId
+func UpdateNamespaceUserHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var req PutNamespaceUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON payload"})
+	}
+
+	user, err := SetNamespaceUserRole(actor, c.Param("namespaceId"), c.Param("userId"), req)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
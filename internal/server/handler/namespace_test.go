@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func setupEchoNamespaces() *echo.Echo {
+	e := echo.New()
+	api := e.Group("/api")
+
+	api.GET("/namespaces", GetNamespacesHandler)
+	api.POST("/namespaces", CreateNamespaceHandler)
+	api.GET("/namespaces/:namespaceId", GetNamespaceHandler)
+	api.POST("/namespaces/:namespaceId/users", AddNamespaceUserHandler)
+	api.PUT("/namespaces/:namespaceId/users/:userId", UpdateNamespaceUserHandler)
+
+	return e
+}
+
+// authReq - запрос от имени пользователя с указанным токеном
+func authReq(method, path, token string, body []byte) *http.Request {
+	req := plainReq(method, path, body)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	return req
+}
+
+// resetUsers - пользователи тестов: инстанс-админ, админ и PM namespace, студент и посторонний
+func resetUsers() {
+	userMu.Lock()
+	userDB = map[string]User{
+		"u-1": {ID: "u-1", Username: "admin", RmsID: "rms-1", Role: RoleInstanceAdmin, Token: "admin-token", EmailVerified: true},
+		"u-2": {ID: "u-2", Username: "nsadmin", RmsID: "rms-2", Role: RoleStudent, Token: "nsadmin-token", EmailVerified: true},
+		"u-3": {ID: "u-3", Username: "pm", RmsID: "rms-3", Role: RoleStudent, Token: "pm-token", EmailVerified: true},
+		"u-4": {ID: "u-4", Username: "student", RmsID: "rms-4", Role: RoleStudent, Token: "student-token", EmailVerified: true},
+		"u-5": {ID: "u-5", Username: "outsider", RmsID: "rms-5", Role: RoleStudent, Token: "outsider-token", EmailVerified: true},
+	}
+	userMu.Unlock()
+}
+
+func resetNamespaceDB() {
+	resetUsers()
+
+	namespaceMu.Lock()
+	namespaceDB = map[string]Namespace{
+		"ns-01": {ID: "ns-01", Name: "Core CS", Slug: "core-cs", GitlabGroupID: "1", AllowedRoles: namespaceRoles},
+		"ns-02": {ID: "ns-02", Name: "Students Only", Slug: "students-only", GitlabGroupID: "2", AllowedRoles: []string{RoleStudent}},
+	}
+	namespaceMembers = map[string]map[string]string{
+		"ns-01": {"u-2": RoleNamespaceAdmin, "u-3": RoleProgramManager, "u-4": RoleStudent},
+		"ns-02": {"u-2": RoleNamespaceAdmin},
+	}
+	namespaceMu.Unlock()
+
+	resetDB()
+	courseMu.Lock()
+	for id, course := range courseDB {
+		course.NamespaceID = "ns-01"
+		course.GitlabGroup = id
+		courseDB[id] = course
+	}
+	courseMu.Unlock()
+}
+
+func TestGetNamespaces_Unauthorized(t *testing.T) {
+	resetNamespaceDB()
+	e := setupEchoNamespaces()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodGet, "/api/namespaces", nil))
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestGetNamespaces_DerivedCounts(t *testing.T) {
+	resetNamespaceDB()
+	e := setupEchoNamespaces()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/namespaces", "admin-token", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var namespaces []Namespace
+	json.Unmarshal(rec.Body.Bytes(), &namespaces)
+
+	if len(namespaces) != 2 {
+		t.Fatalf("expected 2 namespaces, got %d", len(namespaces))
+	}
+	if namespaces[0].ID != "ns-01" || namespaces[0].CoursesCount != 2 || namespaces[0].UsersCount != 3 {
+		t.Errorf("unexpected counts: %+v", namespaces[0])
+	}
+	if namespaces[1].CoursesCount != 0 || namespaces[1].UsersCount != 1 {
+		t.Errorf("unexpected counts: %+v", namespaces[1])
+	}
+}
+
+func TestGetNamespaces_OnlyMemberships(t *testing.T) {
+	resetNamespaceDB()
+	e := setupEchoNamespaces()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/namespaces", "student-token", nil))
+
+	var namespaces []Namespace
+	json.Unmarshal(rec.Body.Bytes(), &namespaces)
+
+	if len(namespaces) != 1 || namespaces[0].ID != "ns-01" {
+		t.Fatalf("student should see only ns-01, got %+v", namespaces)
+	}
+}
+
+func TestGetNamespace_Details(t *testing.T) {
+	resetNamespaceDB()
+	e := setupEchoNamespaces()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/namespaces/ns-01", "pm-token", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var details NamespaceDetails
+	json.Unmarshal(rec.Body.Bytes(), &details)
+
+	if details.Namespace.Slug != "core-cs" {
+		t.Errorf("unexpected namespace: %+v", details.Namespace)
+	}
+	if len(details.Users) != 3 || details.Users[0].Username != "nsadmin" || details.Users[0].Role != RoleNamespaceAdmin {
+		t.Errorf("unexpected users: %+v", details.Users)
+	}
+	if len(details.Courses) != 2 {
+		t.Errorf("expected 2 courses, got %d", len(details.Courses))
+	}
+}
+
+func TestGetNamespace_NotFoundAndForbidden(t *testing.T) {
+	resetNamespaceDB()
+	e := setupEchoNamespaces()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/namespaces/unknown", "admin-token", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/namespaces/ns-01", "outsider-token", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+}
+
+func TestCreateNamespace(t *testing.T) {
+	resetNamespaceDB()
+	e := setupEchoNamespaces()
+
+	body := []byte(`{"name":"Systems","slug":"systems","gitlabGroupId":"42"}`)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/namespaces", "nsadmin-token", body))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for non instance admin, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/namespaces", "admin-token", body))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+
+	var ns Namespace
+	json.Unmarshal(rec.Body.Bytes(), &ns)
+	if ns.ID != "ns-03" || len(ns.AllowedRoles) != len(namespaceRoles) {
+		t.Errorf("unexpected namespace: %+v", ns)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/namespaces", "admin-token", body))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for duplicate slug, got %d", rec.Code)
+	}
+}
+
+func TestAddNamespaceUser_RolePermissions(t *testing.T) {
+	cases := []struct {
+		name     string
+		token    string
+		ns       string
+		body     string
+		wantCode int
+	}{
+		{"admin adds pm", "admin-token", "ns-01", `{"username":"outsider","role":"program_manager"}`, http.StatusCreated},
+		{"ns admin adds ns admin", "nsadmin-token", "ns-01", `{"username":"outsider","role":"namespace_admin"}`, http.StatusCreated},
+		{"pm adds student", "pm-token", "ns-01", `{"username":"outsider","role":"student"}`, http.StatusCreated},
+		{"pm cannot add pm", "pm-token", "ns-01", `{"username":"outsider","role":"program_manager"}`, http.StatusForbidden},
+		{"student cannot add", "student-token", "ns-01", `{"username":"outsider","role":"student"}`, http.StatusForbidden},
+		{"role not allowed in ns", "nsadmin-token", "ns-02", `{"username":"outsider","role":"program_manager"}`, http.StatusBadRequest},
+		{"invalid role", "admin-token", "ns-01", `{"username":"outsider","role":"king"}`, http.StatusBadRequest},
+		{"stranger gets no validation details", "outsider-token", "ns-01", `{"role":"king"}`, http.StatusForbidden},
+		{"student gets no validation details", "student-token", "ns-01", `{"username":"","role":"student"}`, http.StatusForbidden},
+		{"unknown user", "admin-token", "ns-01", `{"username":"ghost","role":"student"}`, http.StatusNotFound},
+		{"already member", "admin-token", "ns-01", `{"username":"student","role":"student"}`, http.StatusConflict},
+		{"unknown namespace", "admin-token", "ns-99", `{"username":"outsider","role":"student"}`, http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resetNamespaceDB()
+			e := setupEchoNamespaces()
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, authReq(http.MethodPost, "/api/namespaces/"+tc.ns+"/users", tc.token, []byte(tc.body)))
+
+			if rec.Code != tc.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tc.wantCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestUpdateNamespaceUser(t *testing.T) {
+	resetNamespaceDB()
+	e := setupEchoNamespaces()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPut, "/api/namespaces/ns-01/users/u-4", "nsadmin-token", []byte(`{"role":"program_manager"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if role := namespaceRole("ns-01", "u-4"); role != RoleProgramManager {
+		t.Fatalf("role not updated, got %q", role)
+	}
+
+	// program manager не может понизить админа namespace
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPut, "/api/namespaces/ns-01/users/u-2", "pm-token", []byte(`{"role":"student"}`)))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPut, "/api/namespaces/ns-01/users/u-5", "admin-token", []byte(`{"role":"student"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for non-member, got %d", rec.Code)
+	}
+
+	// права проверяются раньше тела запроса
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPut, "/api/namespaces/ns-01/users/u-4", "outsider-token", []byte(`{"role":"king"}`)))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for invalid role from a stranger, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPut, "/api/namespaces/ns-01/users/u-4", "nsadmin-token", []byte(`{"role":"king"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid role, got %d", rec.Code)
+	}
+}
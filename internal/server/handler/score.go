@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"fcstask-backend/internal/tracing"
+)
+
+// Submission - посылка студента по задаче
+type Submission struct {
+	ID          int       `json:"id"`
+	CourseID    string    `json:"courseId"`
+	UserID      string    `json:"userId"`
+	TaskID      string    `json:"taskId"`
+	Score       int       `json:"score"`
+	SubmittedAt time.Time `json:"submittedAt"`
+
+	// Задачу удалили из структуры курса: посылка хранится, но в ведомость не входит
+	Archived bool `json:"archived,omitempty"`
+}
+
+// ScoreRow - строка ведомости GET /api/courses/:courseId/scores
+type ScoreRow struct {
+	ID        int    `json:"id"`
+	Student   string `json:"student"`
+	Score     int    `json:"score"`
+	Submitted string `json:"submitted"`
+}
+
+// In-memory storage
+var (
+	submissionDB = []Submission{}
+
+	submissionMu sync.RWMutex
+)
+
+// gradebook строит ведомость: лучший балл по каждой задаче, суммарно по студенту.
+// В ведомость попадают только активные и завершившие курс студенты,
+// посылки отчисленных и архивные посылки хранятся, но не показываются.
+// Дата последней посылки - по часовому поясу курса
+func gradebook(ctx context.Context, courseID string) []ScoreRow {
+	_, span := tracing.Tracer(tracerName).Start(ctx, "scores.gradebook", trace.WithAttributes(attribute.String("course.id", courseID)))
+	defer span.End()
+
+	courseMu.RLock()
+	loc := courseLocation(courseDB[courseID])
+	courseMu.RUnlock()
+
+	enrollmentMu.RLock()
+	students := map[string]string{}
+	for userID, enrollment := range enrollmentDB[courseID] {
+		if enrollment.State == EnrollmentActive || enrollment.State == EnrollmentCompleted {
+			students[userID] = enrollment.Username
+		}
+	}
+	enrollmentMu.RUnlock()
+
+	best := map[string]map[string]int{}
+	last := map[string]time.Time{}
+
+	submissionMu.RLock()
+	for _, submission := range submissionDB {
+		if submission.CourseID != courseID || submission.Archived {
+			continue
+		}
+		if _, ok := students[submission.UserID]; !ok {
+			continue
+		}
+
+		if best[submission.UserID] == nil {
+			best[submission.UserID] = map[string]int{}
+		}
+		if submission.Score > best[submission.UserID][submission.TaskID] {
+			best[submission.UserID][submission.TaskID] = submission.Score
+		}
+		if submission.SubmittedAt.After(last[submission.UserID]) {
+			last[submission.UserID] = submission.SubmittedAt
+		}
+	}
+	submissionMu.RUnlock()
+
+	rows := make([]ScoreRow, 0, len(students))
+	for userID, username := range students {
+		row := ScoreRow{Student: username}
+		for _, score := range best[userID] {
+			row.Score += score
+		}
+		if submitted, ok := last[userID]; ok {
+			row.Submitted = submitted.In(loc).Format("2006-01-02")
+		}
+		rows = append(rows, row)
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Student < rows[j].Student })
+	for i := range rows {
+		rows[i].ID = i + 1
+	}
+	span.SetAttributes(attribute.Int("scores.rows", len(rows)))
+
+	return rows
+}
+
+// CourseScores возвращает ведомость курса. Ведомость видят те же, кто управляет
+// записями; для курса, который actor не видит, - 404
+func CourseScores(ctx context.Context, actor User, courseID string) ([]ScoreRow, error) {
+	course, err := visibleCourse(actor, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canTeachCourse(actor, course) {
+		return nil, errForbidden
+	}
+
+	return gradebook(ctx, courseID), nil
+}
+
+// GET /api/courses/:courseId/scores
+func GetCourseScoresHandler(c echo.Context) error {
+	actor, ok := currentUser(c)
+	if !ok {
+		return writeError(c, errUnauthorized)
+	}
+
+	rows, err := CourseScores(c.Request().Context(), actor, c.Param("courseId"))
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, rows)
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGradebook_BestScorePerTask(t *testing.T) {
+	resetEnrollmentDB()
+	upsertEnrollment("algorithms", User{ID: "u-5", Username: "outsider"}, EnrollmentCompleted)
+	upsertEnrollment("algorithms", User{ID: "u-3", Username: "pm"}, EnrollmentPending)
+
+	submissionMu.Lock()
+	submissionDB = append(submissionDB,
+		Submission{ID: 4, CourseID: "algorithms", UserID: "u-5", TaskID: "t1", Score: 4, SubmittedAt: time.Date(2024, 10, 7, 0, 0, 0, 0, time.UTC)},
+		Submission{ID: 5, CourseID: "algorithms", UserID: "u-3", TaskID: "t1", Score: 9},
+		Submission{ID: 6, CourseID: "hidden", UserID: "u-4", TaskID: "t1", Score: 9},
+	)
+	submissionMu.Unlock()
+
+	rows := gradebook(context.Background(), "algorithms")
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %+v", rows)
+	}
+
+	if rows[0].ID != 1 || rows[0].Student != "outsider" || rows[0].Score != 4 || rows[0].Submitted != "2024-10-07" {
+		t.Errorf("unexpected first row: %+v", rows[0])
+	}
+	if rows[1].ID != 2 || rows[1].Student != "student" || rows[1].Score != 11 {
+		t.Errorf("unexpected second row: %+v", rows[1])
+	}
+}
+
+func TestGradebook_SubmittedInCourseTimezone(t *testing.T) {
+	resetEnrollmentDB()
+	courseMu.Lock()
+	course := courseDB["algorithms"]
+	course.Timezone = "Europe/Moscow"
+	courseDB["algorithms"] = course
+	courseMu.Unlock()
+
+	submissionMu.Lock()
+	submissionDB = append(submissionDB,
+		Submission{ID: 4, CourseID: "algorithms", UserID: "u-4", TaskID: "t9", Score: 1, SubmittedAt: time.Date(2024, 10, 7, 22, 30, 0, 0, time.UTC)},
+	)
+	submissionMu.Unlock()
+
+	// 22:30 UTC - уже 8 октября по Москве
+	rows := gradebook(context.Background(), "algorithms")
+	if len(rows) != 1 || rows[0].Submitted != "2024-10-08" {
+		t.Fatalf("expected the course-local date, got %+v", rows)
+	}
+}
+
+func TestGetCourseScores_NotFound(t *testing.T) {
+	resetEnrollmentDB()
+	e := setupEchoEnrollments()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses/missing/scores", "admin-token", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestGetCourseScores_OnlyTeachers(t *testing.T) {
+	resetEnrollmentDB()
+	e := setupEchoEnrollments()
+
+	courseMu.Lock()
+	algorithms := courseDB["algorithms"]
+	algorithms.Owners = []string{"outsider"}
+	courseDB["algorithms"] = algorithms
+	courseMu.Unlock()
+
+	get := func(path, token string) int {
+		req := plainReq(http.MethodGet, path, nil)
+		if token != "" {
+			req = authReq(http.MethodGet, path, token, nil)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	cases := []struct {
+		name, path, token string
+		want              int
+	}{
+		{"anonymous", "/api/courses/algorithms/scores", "", http.StatusUnauthorized},
+		{"enrolled student", "/api/courses/algorithms/scores", "student-token", http.StatusForbidden},
+		// скрытый курс посторонний не видит
+		{"outsider on hidden course", "/api/courses/hidden/scores", "outsider-token", http.StatusNotFound},
+		{"course owner", "/api/courses/algorithms/scores", "outsider-token", http.StatusOK},
+		{"program manager", "/api/courses/hidden/scores", "pm-token", http.StatusOK},
+		{"namespace admin", "/api/courses/algorithms/scores", "nsadmin-token", http.StatusOK},
+	}
+	for _, tc := range cases {
+		if got := get(tc.path, tc.token); got != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, got)
+		}
+	}
+}
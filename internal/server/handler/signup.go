@@ -0,0 +1,399 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"fcstask-backend/internal/logging"
+	"fcstask-backend/internal/provision"
+)
+
+// Статусы регистрации для страницы завершения
+const (
+	SignupAwaitingVerification = "awaiting_verification"
+	SignupProvisioning         = "provisioning"
+	SignupCompleted            = "completed"
+	SignupFailed               = "failed"
+)
+
+// Provisioner - очередь создания репозиториев студентов
+type Provisioner interface {
+	Enqueue(ctx context.Context, job provision.Job) (string, error)
+	Status(id string) (provision.Status, bool)
+}
+
+// SignupRequest - тело POST /api/signup
+type SignupRequest struct {
+	InviteCode string `json:"inviteCode"`
+	Email      string `json:"email"`
+	Telegram   string `json:"telegram"`
+	Group      string `json:"group"`
+}
+
+// Signup - выполненная регистрация по инвайту
+type Signup struct {
+	ID          string
+	UserID      string
+	InviteCode  string
+	CourseID    string
+	NamespaceID string
+	JobID       string
+	JobError    string
+	CreatedAt   time.Time
+
+	// Репозиторий создается только после подтверждения почты
+	AwaitingVerification bool
+}
+
+// SignupResponse - ответ POST /api/signup.
+// Токен отдается только новому пользователю, вошедшему он не нужен
+type SignupResponse struct {
+	SignupID string `json:"signupId"`
+	UserID   string `json:"userId"`
+	Username string `json:"username"`
+	Token    string `json:"token,omitempty"`
+	Status   string `json:"status"`
+
+	EmailVerified bool `json:"emailVerified"`
+}
+
+// SignupStatus - ответ GET /api/signup/status
+type SignupStatus struct {
+	SignupID    string `json:"signupId"`
+	Status      string `json:"status"`
+	CourseID    string `json:"courseId,omitempty"`
+	NamespaceID string `json:"namespaceId"`
+	RepoURL     string `json:"repoUrl,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+var telegramPattern = regexp.MustCompile(`^@?[A-Za-z0-9_]{5,32}$`)
+
+var errEmailRegistered = newAPIError(http.StatusConflict, "email is already registered, sign in to accept the invite")
+
+// In-memory storage
+var (
+	signupDB = map[string]Signup{}
+	signupMu sync.RWMutex
+
+	provisioner Provisioner
+)
+
+// SetProvisioner подключает очередь провижининга репозиториев
+func SetProvisioner(p Provisioner) {
+	provisioner = p
+}
+
+func (req *SignupRequest) Validate() []ValidationError {
+	var errs []ValidationError
+
+	if req.InviteCode == "" {
+		errs = append(errs, ValidationError{Field: "inviteCode", Message: "inviteCode is required"})
+	}
+
+	if req.Email == "" {
+		errs = append(errs, ValidationError{Field: "email", Message: "email is required"})
+	} else if addr, err := mail.ParseAddress(req.Email); err != nil || addr.Address != req.Email {
+		errs = append(errs, ValidationError{Field: "email", Message: "invalid email address"})
+	}
+
+	if req.Telegram != "" && !telegramPattern.MatchString(req.Telegram) {
+		errs = append(errs, ValidationError{Field: "telegram", Message: "invalid telegram handle"})
+	}
+
+	return errs
+}
+
+var usernameUnsafe = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// createSignupUser заводит нового пользователя, логин берется из email
+func createSignupUser(req SignupRequest) User {
+	base := usernameUnsafe.ReplaceAllString(strings.ToLower(strings.SplitN(req.Email, "@", 2)[0]), "")
+	if base == "" {
+		base = "user"
+	}
+
+	userMu.Lock()
+	defer userMu.Unlock()
+
+	username := base
+	for n := 2; ; n++ {
+		taken := false
+		for _, user := range userDB {
+			if user.Username == username {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			break
+		}
+		username = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	user := User{
+		ID:       nextUserID(),
+		Username: username,
+		Role:     RoleStudent,
+		Email:    req.Email,
+		Telegram: req.Telegram,
+		Group:    req.Group,
+		Token:    randomToken(24),
+
+		EmailVerified: emailVerification == nil,
+	}
+	userDB[user.ID] = user
+
+	return user
+}
+
+// RegisterByInvite выполняет регистрацию: проверяет инвайт, создает нового
+// пользователя или применяет инвайт к actor, записывает его и запускает создание репозитория
+func RegisterByInvite(ctx context.Context, actor *User, req SignupRequest) (SignupResponse, error) {
+	if errs := req.Validate(); len(errs) > 0 {
+		return SignupResponse{}, validationFailed(errs)
+	}
+
+	// Анонимный запрос к чужому аккаунту не привязывается: владелец почты
+	// принимает инвайт сам, войдя в аккаунт. Инвайт проверяется заранее,
+	// чтобы без действующего кода нельзя было перебирать адреса
+	if _, exists := findUserByEmail(req.Email); actor == nil && exists {
+		courseDeleted := inviteCourseDeleted(req.InviteCode)
+		inviteMu.RLock()
+		_, err := usableInvite(req.InviteCode, time.Now(), courseDeleted)
+		inviteMu.RUnlock()
+		if err != nil {
+			return SignupResponse{}, err
+		}
+		return SignupResponse{}, errEmailRegistered
+	}
+
+	invite, err := redeemInvite(req.InviteCode, time.Now())
+	if err != nil {
+		return SignupResponse{}, err
+	}
+
+	var user User
+	created := false
+	if actor != nil {
+		user = *actor
+	} else {
+		user = createSignupUser(req)
+		created = true
+	}
+
+	if !user.EmailVerified && emailVerification != nil {
+		sendVerification(ctx, user)
+	}
+
+	namespaceMu.Lock()
+	if namespaceMembers[invite.NamespaceID] == nil {
+		namespaceMembers[invite.NamespaceID] = map[string]string{}
+	}
+	if _, member := namespaceMembers[invite.NamespaceID][user.ID]; !member {
+		namespaceMembers[invite.NamespaceID][user.ID] = invite.Role
+	}
+	namespaceMu.Unlock()
+
+	signup := Signup{
+		ID:          randomToken(12),
+		UserID:      user.ID,
+		InviteCode:  invite.Code,
+		CourseID:    invite.CourseID,
+		NamespaceID: invite.NamespaceID,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	provisioning := false
+	if invite.CourseID != "" {
+		// Запись остается pending, пока почта не подтверждена
+		if user.EmailVerified {
+			upsertEnrollment(invite.CourseID, user, EnrollmentActive)
+			provisioning = provisioner != nil
+		} else {
+			upsertEnrollment(invite.CourseID, user, EnrollmentPending)
+			signup.AwaitingVerification = true
+		}
+	}
+
+	signupMu.Lock()
+	signupDB[signup.ID] = signup
+	signupMu.Unlock()
+
+	if provisioning {
+		enqueueProvisioning(ctx, signup.ID, user)
+	}
+
+	resp := SignupResponse{
+		SignupID: signup.ID,
+		UserID:   user.ID,
+		Username: user.Username,
+		Status:   signupStatus(signup).Status,
+
+		EmailVerified: user.EmailVerified,
+	}
+
+	// Задача встает в очередь после записи регистрации. Если очередь уже
+	// ответила (вне SyncState), статус берется из регистрации
+	signupMu.RLock()
+	if queued := signupDB[signup.ID]; queued.JobID != "" || queued.JobError != "" {
+		resp.Status = signupStatus(queued).Status
+	} else if provisioning {
+		resp.Status = SignupProvisioning
+	}
+	signupMu.RUnlock()
+	if created {
+		resp.Token = user.Token
+	}
+
+	return resp, nil
+}
+
+// enqueueProvisioning ставит создание репозитория студента в очередь, когда
+// регистрация записана в базу, и сохраняет в ней идентификатор задачи
+func enqueueProvisioning(ctx context.Context, signupID string, user User) {
+	if provisioner == nil {
+		return
+	}
+
+	afterStateSaved(ctx, func(ctx context.Context) {
+		signupMu.RLock()
+		signup, exists := signupDB[signupID]
+		signupMu.RUnlock()
+		if !exists {
+			return
+		}
+
+		courseMu.RLock()
+		course := courseDB[signup.CourseID]
+		courseMu.RUnlock()
+
+		jobID, enqueueErr := provisioner.Enqueue(ctx, provision.Job{
+			Group:    course.GitlabGroup,
+			Name:     user.Username,
+			Template: course.RepoTemplate,
+		})
+
+		err := updateAfterSave(ctx, func() bool {
+			signupMu.Lock()
+			defer signupMu.Unlock()
+
+			signup, exists := signupDB[signupID]
+			if !exists {
+				return false
+			}
+			if enqueueErr != nil {
+				signup.JobError = enqueueErr.Error()
+			} else {
+				signup.JobID = jobID
+			}
+			signupDB[signupID] = signup
+			return true
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to save provisioning job", "signup_id", signupID, "error", err)
+		}
+	})
+}
+
+// startPendingProvisioning запускает провижининг, отложенный до подтверждения почты
+func startPendingProvisioning(ctx context.Context, user User) {
+	signupMu.Lock()
+	var pending []string
+	for id, signup := range signupDB {
+		if signup.UserID == user.ID && signup.AwaitingVerification {
+			signup.AwaitingVerification = false
+			signupDB[id] = signup
+			pending = append(pending, id)
+		}
+	}
+	signupMu.Unlock()
+
+	for _, id := range pending {
+		enqueueProvisioning(ctx, id, user)
+	}
+}
+
+func signupStatus(signup Signup) SignupStatus {
+	status := SignupStatus{
+		SignupID:    signup.ID,
+		Status:      SignupCompleted,
+		CourseID:    signup.CourseID,
+		NamespaceID: signup.NamespaceID,
+	}
+
+	if signup.AwaitingVerification {
+		status.Status = SignupAwaitingVerification
+		return status
+	}
+
+	if signup.JobError != "" {
+		status.Status = SignupFailed
+		status.Error = signup.JobError
+		return status
+	}
+
+	if signup.JobID == "" || provisioner == nil {
+		return status
+	}
+
+	job, ok := provisioner.Status(signup.JobID)
+	if !ok {
+		return status
+	}
+
+	switch job.State {
+	case provision.StateDone:
+		status.RepoURL = job.RepoURL
+	case provision.StateFailed:
+		status.Status = SignupFailed
+		status.Error = job.Error
+	default:
+		status.Status = SignupProvisioning
+	}
+
+	return status
+}
+
+// Хендлеры
+
+// POST /api/signup
+func SignupHandler(c echo.Context) error {
+	var req SignupRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid JSON payload"})
+	}
+
+	var actor *User
+	if user, ok := currentUser(c); ok {
+		actor = &user
+	}
+
+	resp, err := RegisterByInvite(c.Request().Context(), actor, req)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// GET /api/signup/status?signupId=...
+func GetSignupStatusHandler(c echo.Context) error {
+	signupMu.RLock()
+	signup, exists := signupDB[c.QueryParam("signupId")]
+	signupMu.RUnlock()
+
+	if !exists {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "signup not found"})
+	}
+
+	return c.JSON(http.StatusOK, signupStatus(signup))
+}
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"fcstask-backend/internal/provision"
+)
+
+// stubProvisioner - провижининг с заранее заданным результатом
+type stubProvisioner struct {
+	jobs   []provision.Job
+	status provision.Status
+	err    error
+}
+
+func (p *stubProvisioner) Enqueue(_ context.Context, job provision.Job) (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	p.jobs = append(p.jobs, job)
+	return "job-1", nil
+}
+
+func (p *stubProvisioner) Status(string) (provision.Status, bool) {
+	return p.status, true
+}
+
+func setupEchoSignup() *echo.Echo {
+	e := echo.New()
+	api := e.Group("/api")
+
+	api.POST("/signup", SignupHandler)
+	api.GET("/signup/status", GetSignupStatusHandler)
+
+	return e
+}
+
+func resetSignupDB(p Provisioner) {
+	resetInviteDB()
+
+	now := time.Now()
+	inviteMu.Lock()
+	inviteDB["course-code"] = Invite{Code: "course-code", CourseID: "algorithms", NamespaceID: "ns-01", Role: RoleStudent, MaxUses: 10, ExpiresAt: now.Add(time.Hour)}
+	inviteDB["ns-code"] = Invite{Code: "ns-code", NamespaceID: "ns-02", Role: RoleProgramManager, MaxUses: 1, ExpiresAt: now.Add(time.Hour)}
+	inviteMu.Unlock()
+
+	signupMu.Lock()
+	signupDB = map[string]Signup{}
+	signupMu.Unlock()
+
+	enrollmentMu.Lock()
+	enrollmentDB = map[string]map[string]Enrollment{}
+	enrollmentMu.Unlock()
+
+	SetProvisioner(p)
+}
+
+func TestSignup_NewUserWithCourseInvite(t *testing.T) {
+	prov := &stubProvisioner{status: provision.Status{State: provision.StateRunning}}
+	resetSignupDB(prov)
+	e := setupEchoSignup()
+
+	body := []byte(`{"inviteCode":"course-code","email":"Ivan.Petrov@edu.hse.ru","telegram":"@ivan_petrov","group":"BSE-221"}`)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodPost, "/api/signup", body))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp SignupResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+
+	if resp.Username != "ivan.petrov" || resp.Token == "" || resp.Status != SignupProvisioning {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if namespaceRole("ns-01", resp.UserID) != RoleStudent {
+		t.Error("user should join the course namespace as a student")
+	}
+	if enrollmentState("algorithms", resp.UserID) != EnrollmentActive {
+		t.Error("user should be enrolled into the course")
+	}
+	if len(prov.jobs) != 1 || prov.jobs[0].Group != "algorithms" || prov.jobs[0].Name != "ivan.petrov" || prov.jobs[0].Template != "git@test:repo.git" {
+		t.Errorf("unexpected provisioning jobs: %+v", prov.jobs)
+	}
+
+	prov.status = provision.Status{State: provision.StateDone, RepoURL: "git@gitlab.local:algorithms/ivan.petrov.git"}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodGet, "/api/signup/status?signupId="+resp.SignupID, nil))
+
+	var status SignupStatus
+	json.Unmarshal(rec.Body.Bytes(), &status)
+	if status.Status != SignupCompleted || status.RepoURL == "" || status.CourseID != "algorithms" {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+}
+
+func TestSignup_ExistingEmailRequiresSignIn(t *testing.T) {
+	resetNamespaceDB()
+	resetSignupDB(nil)
+	e := setupEchoSignup()
+
+	userMu.Lock()
+	outsider := userDB["u-5"]
+	outsider.Email = "outsider@example.com"
+	userDB["u-5"] = outsider
+	userMu.Unlock()
+
+	body := []byte(`{"inviteCode":"ns-code","email":"Outsider@example.com"}`)
+
+	// без действующего инвайта занятость почты не раскрывается
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodPost, "/api/signup", []byte(`{"inviteCode":"missing","email":"outsider@example.com"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown invite, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodPost, "/api/signup", body))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("anonymous signup with a registered email: expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "u-5") || strings.Contains(rec.Body.String(), "outsider\"") {
+		t.Errorf("response must not reveal the account: %s", rec.Body.String())
+	}
+	if namespaceRole("ns-02", "u-5") != "" {
+		t.Error("role must not be granted to an account without its owner")
+	}
+
+	// владелец аккаунта принимает инвайт сам, инвайт не израсходован отказом
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/signup", "outsider-token", body))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp SignupResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.UserID != "u-5" || resp.Token != "" || resp.Status != SignupCompleted {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if namespaceRole("ns-02", "u-5") != RoleProgramManager {
+		t.Error("role from invite should be granted")
+	}
+}
+
+func TestSignup_AuthenticatedUserKeepsRole(t *testing.T) {
+	resetSignupDB(nil)
+	e := setupEchoSignup()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/signup", "pm-token", []byte(`{"inviteCode":"course-code","email":"pm@example.com"}`)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	if namespaceRole("ns-01", "u-3") != RoleProgramManager {
+		t.Error("existing membership must not be downgraded")
+	}
+}
+
+func TestSignup_Errors(t *testing.T) {
+	cases := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"missing fields", `{}`, http.StatusBadRequest},
+		{"bad email", `{"inviteCode":"course-code","email":"not-an-email"}`, http.StatusBadRequest},
+		{"bad telegram", `{"inviteCode":"course-code","email":"a@b.c","telegram":"@x"}`, http.StatusBadRequest},
+		{"unknown invite", `{"inviteCode":"nope","email":"a@b.c"}`, http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resetSignupDB(nil)
+			e := setupEchoSignup()
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, plainReq(http.MethodPost, "/api/signup", []byte(tc.body)))
+
+			if rec.Code != tc.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tc.wantCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestSignup_UsedUpInvite(t *testing.T) {
+	resetSignupDB(nil)
+	e := setupEchoSignup()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodPost, "/api/signup", []byte(`{"inviteCode":"ns-code","email":"first@example.com"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodPost, "/api/signup", []byte(`{"inviteCode":"ns-code","email":"second@example.com"}`)))
+	if rec.Code != http.StatusGone {
+		t.Fatalf("expected 410, got %d", rec.Code)
+	}
+}
+
+func TestSignup_ProvisioningQueueFull(t *testing.T) {
+	resetSignupDB(&stubProvisioner{err: provision.ErrQueueFull})
+	e := setupEchoSignup()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodPost, "/api/signup", []byte(`{"inviteCode":"course-code","email":"late@example.com"}`)))
+
+	var resp SignupResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Status != SignupFailed {
+		t.Fatalf("expected failed status, got %+v", resp)
+	}
+}
+
+func TestGetSignupStatus_NotFound(t *testing.T) {
+	resetSignupDB(nil)
+	e := setupEchoSignup()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodGet, "/api/signup/status?signupId=nope", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
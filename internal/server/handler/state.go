@@ -0,0 +1,1085 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"fcstask-backend/internal/logging"
+)
+
+// Хранилище в базе. Хендлеры работают с картами в памяти, а база - общий снимок
+// для сервера и CLI: LoadState заменяет карты ее содержимым, SaveState
+// записывает только изменившиеся строки
+
+// ErrStateConflict - пока снимок меняли в памяти, базу успел записать другой процесс
+var ErrStateConflict = errors.New("state was changed by another process, retry")
+
+var (
+	// версия снимка в базе, с которой совпадают карты в памяти
+	stateVersion atomic.Int64
+
+	// изменяющие запросы выполняются по одному, чтобы снимок не перемешался
+	stateGate sync.RWMutex
+
+	// строки, которые лежат в базе версии stateVersion
+	stored storedState
+
+	// время последней сверки версии чтением, unix nano
+	lastStateCheck atomic.Int64
+)
+
+// Чтения сверяют версию снимка с базой не чаще этого интервала, поэтому чужие
+// изменения видны им с задержкой. Изменяющие запросы сверяются всегда
+var stateCheckInterval = time.Second
+
+// GET-запросы, которые тем не менее меняют состояние (ссылка из письма)
+var mutatingReads = map[string]bool{
+	"/api/signup/verify": true,
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// tokenHash - в базе хранится только хеш токена
+func tokenHash(user User) string {
+	if user.TokenHash != "" || user.Token == "" {
+		return user.TokenHash
+	}
+	return hashToken(user.Token)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseTime(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+func nullTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: formatTime(*t), Valid: true}
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
+
+func readStateVersion(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}) (int64, error) {
+	var version int64
+	if err := q.QueryRowContext(ctx, `SELECT version FROM state_version WHERE id = 1`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("read state version (is the schema migrated?): %w", err)
+	}
+	return version, nil
+}
+
+// snapshot - содержимое хранилища, которое переживает рестарт
+type snapshot struct {
+	namespaces  map[string]Namespace
+	members     map[string]map[string]string
+	users       map[string]User
+	courses     map[string]Course // вместе с удаленными, у них DeletedAt != nil
+	aliases     map[string]string
+	boards      map[string]TaskBoardSummary
+	enrollments map[string]map[string]Enrollment
+	submissions []Submission
+	invites     map[string]Invite
+	signups     map[string]Signup
+	audit       []AuditEntry
+}
+
+// LoadState заменяет карты в памяти содержимым базы.
+// Возвращает false, если в базу еще ничего не сохраняли - тогда карты не трогаются
+func LoadState(ctx context.Context, db *sql.DB) (bool, error) {
+	ctx, span := storageSpan(ctx, "load", "state")
+	defer span.End()
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	version, err := readStateVersion(ctx, tx)
+	if err != nil {
+		return false, err
+	}
+	if version == 0 {
+		stateVersion.Store(0)
+		stored = storedState{}
+		return false, nil
+	}
+
+	s, err := readSnapshot(ctx, tx)
+	if err != nil {
+		return false, err
+	}
+	rows, err := snapshotRows(s)
+	if err != nil {
+		return false, err
+	}
+	audit, err := storedAudit(s.audit)
+	if err != nil {
+		return false, err
+	}
+
+	applySnapshot(s)
+	stateVersion.Store(version)
+	stored = storedState{rows: rows, audit: audit}
+	return true, nil
+}
+
+// applySnapshot заменяет карты в памяти, каждую под своей блокировкой
+func applySnapshot(s snapshot) {
+	namespaceMu.Lock()
+	namespaceDB, namespaceMembers = s.namespaces, s.members
+	namespaceMu.Unlock()
+
+	userMu.Lock()
+	userDB = s.users
+	userMu.Unlock()
+
+	courses, trash := map[string]Course{}, map[string]Course{}
+	for id, course := range s.courses {
+		if course.DeletedAt != nil {
+			trash[id] = course
+		} else {
+			courses[id] = course
+		}
+	}
+	courseMu.Lock()
+	courseDB, trashDB, courseAliases = courses, trash, s.aliases
+	courseMu.Unlock()
+
+	boardMu.Lock()
+	boardData = s.boards
+	boardMu.Unlock()
+
+	enrollmentMu.Lock()
+	enrollmentDB = s.enrollments
+	enrollmentMu.Unlock()
+
+	submissionMu.Lock()
+	submissionDB = s.submissions
+	submissionMu.Unlock()
+
+	inviteMu.Lock()
+	inviteDB = s.invites
+	inviteMu.Unlock()
+
+	signupMu.Lock()
+	signupDB = s.signups
+	signupMu.Unlock()
+
+	auditMu.Lock()
+	auditLog = s.audit
+	auditMu.Unlock()
+}
+
+func readSnapshot(ctx context.Context, tx *sql.Tx) (snapshot, error) {
+	s := snapshot{
+		namespaces:  map[string]Namespace{},
+		members:     map[string]map[string]string{},
+		users:       map[string]User{},
+		courses:     map[string]Course{},
+		aliases:     map[string]string{},
+		boards:      map[string]TaskBoardSummary{},
+		enrollments: map[string]map[string]Enrollment{},
+		invites:     map[string]Invite{},
+		signups:     map[string]Signup{},
+	}
+
+	// each выполняет запрос и вызывает scan для каждой строки
+	each := func(query string, scan func(rows *sql.Rows) error) error {
+		rows, err := tx.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			if err := scan(rows); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	}
+
+	err := each(`SELECT id, name, slug, description, gitlab_group_id, allowed_roles FROM namespaces`, func(rows *sql.Rows) error {
+		var ns Namespace
+		var allowedRoles string
+		if err := rows.Scan(&ns.ID, &ns.Name, &ns.Slug, &ns.Description, &ns.GitlabGroupID, &allowedRoles); err != nil {
+			return err
+		}
+		ns.AllowedRoles = splitList(allowedRoles)
+		s.namespaces[ns.ID] = ns
+		s.members[ns.ID] = map[string]string{}
+		return nil
+	})
+	if err != nil {
+		return s, fmt.Errorf("load namespaces: %w", err)
+	}
+
+	err = each(`SELECT namespace_id, user_id, role FROM namespace_members`, func(rows *sql.Rows) error {
+		var namespaceID, userID, role string
+		if err := rows.Scan(&namespaceID, &userID, &role); err != nil {
+			return err
+		}
+		if s.members[namespaceID] == nil {
+			s.members[namespaceID] = map[string]string{}
+		}
+		s.members[namespaceID][userID] = role
+		return nil
+	})
+	if err != nil {
+		return s, fmt.Errorf("load namespace members: %w", err)
+	}
+
+	err = each(`SELECT id, username, rms_id, role, email, telegram, grp, token_hash, email_verified, verification_nonce FROM users`, func(rows *sql.Rows) error {
+		var user User
+		var email sql.NullString
+		if err := rows.Scan(&user.ID, &user.Username, &user.RmsID, &user.Role, &email, &user.Telegram, &user.Group, &user.TokenHash, &user.EmailVerified, &user.VerificationNonce); err != nil {
+			return err
+		}
+		user.Email = email.String
+		s.users[user.ID] = user
+		return nil
+	})
+	if err != nil {
+		return s, fmt.Errorf("load users: %w", err)
+	}
+
+	err = each(`SELECT id, name, status, start_date, end_date, timezone, repo_template, description, url, namespace_id, gitlab_group, version, deleted_at FROM courses`, func(rows *sql.Rows) error {
+		var course Course
+		var namespaceID, deletedAt sql.NullString
+		if err := rows.Scan(&course.ID, &course.Name, &course.Status, &course.StartDate, &course.EndDate, &course.Timezone, &course.RepoTemplate, &course.Description, &course.URL, &namespaceID, &course.GitlabGroup, &course.Version, &deletedAt); err != nil {
+			return err
+		}
+		course.NamespaceID = namespaceID.String
+		if deletedAt.Valid {
+			at, err := parseTime(deletedAt.String)
+			if err != nil {
+				return fmt.Errorf("course %s deleted_at: %w", course.ID, err)
+			}
+			course.DeletedAt = &at
+		}
+		course.Owners = []string{}
+		s.courses[course.ID] = course
+		return nil
+	})
+	if err != nil {
+		return s, fmt.Errorf("load courses: %w", err)
+	}
+
+	// rowid сохраняет порядок владельцев, в котором их записали
+	err = each(`SELECT course_id, username FROM course_owners ORDER BY rowid`, func(rows *sql.Rows) error {
+		var courseID, username string
+		if err := rows.Scan(&courseID, &username); err != nil {
+			return err
+		}
+		if course, ok := s.courses[courseID]; ok {
+			course.Owners = append(course.Owners, username)
+			s.courses[courseID] = course
+		}
+		return nil
+	})
+	if err != nil {
+		return s, fmt.Errorf("load course owners: %w", err)
+	}
+
+	err = each(`SELECT slug, course_id FROM course_aliases`, func(rows *sql.Rows) error {
+		var alias, courseID string
+		if err := rows.Scan(&alias, &courseID); err != nil {
+			return err
+		}
+		s.aliases[alias] = courseID
+		return nil
+	})
+	if err != nil {
+		return s, fmt.Errorf("load course aliases: %w", err)
+	}
+
+	err = each(`SELECT course_id, data FROM course_boards`, func(rows *sql.Rows) error {
+		var courseID, data string
+		if err := rows.Scan(&courseID, &data); err != nil {
+			return err
+		}
+		var board TaskBoardSummary
+		if err := json.Unmarshal([]byte(data), &board); err != nil {
+			return fmt.Errorf("board of %s: %w", courseID, err)
+		}
+		// Доски, сохраненные до появления версий групп
+		for i := range board.Groups {
+			if board.Groups[i].Version == 0 {
+				board.Groups[i].Version = 1
+			}
+		}
+		s.boards[courseID] = board
+		return nil
+	})
+	if err != nil {
+		return s, fmt.Errorf("load course boards: %w", err)
+	}
+
+	err = each(`SELECT course_id, user_id, state, updated_at FROM enrollments`, func(rows *sql.Rows) error {
+		var enrollment Enrollment
+		var updatedAt string
+		if err := rows.Scan(&enrollment.CourseID, &enrollment.UserID, &enrollment.State, &updatedAt); err != nil {
+			return err
+		}
+		var err error
+		if enrollment.UpdatedAt, err = parseTime(updatedAt); err != nil {
+			return err
+		}
+		enrollment.Username = s.users[enrollment.UserID].Username
+		if s.enrollments[enrollment.CourseID] == nil {
+			s.enrollments[enrollment.CourseID] = map[string]Enrollment{}
+		}
+		s.enrollments[enrollment.CourseID][enrollment.UserID] = enrollment
+		return nil
+	})
+	if err != nil {
+		return s, fmt.Errorf("load enrollments: %w", err)
+	}
+
+	err = each(`SELECT id, course_id, user_id, task_id, score, submitted_at, archived FROM submissions ORDER BY id`, func(rows *sql.Rows) error {
+		var submission Submission
+		var submittedAt string
+		if err := rows.Scan(&submission.ID, &submission.CourseID, &submission.UserID, &submission.TaskID, &submission.Score, &submittedAt, &submission.Archived); err != nil {
+			return err
+		}
+		var err error
+		if submission.SubmittedAt, err = parseTime(submittedAt); err != nil {
+			return err
+		}
+		s.submissions = append(s.submissions, submission)
+		return nil
+	})
+	if err != nil {
+		return s, fmt.Errorf("load submissions: %w", err)
+	}
+
+	err = each(`SELECT code, course_id, namespace_id, role, expires_at, max_uses, uses, revoked, created_by, created_at FROM invites`, func(rows *sql.Rows) error {
+		var invite Invite
+		var courseID sql.NullString
+		var expiresAt, createdAt string
+		if err := rows.Scan(&invite.Code, &courseID, &invite.NamespaceID, &invite.Role, &expiresAt, &invite.MaxUses, &invite.Uses, &invite.Revoked, &invite.CreatedBy, &createdAt); err != nil {
+			return err
+		}
+		invite.CourseID = courseID.String
+		var err error
+		if invite.ExpiresAt, err = parseTime(expiresAt); err != nil {
+			return err
+		}
+		if invite.CreatedAt, err = parseTime(createdAt); err != nil {
+			return err
+		}
+		s.invites[invite.Code] = invite
+		return nil
+	})
+	if err != nil {
+		return s, fmt.Errorf("load invites: %w", err)
+	}
+
+	err = each(`SELECT id, user_id, invite_code, course_id, namespace_id, job_id, job_error, awaiting_verification, created_at FROM signups`, func(rows *sql.Rows) error {
+		var signup Signup
+		var createdAt string
+		if err := rows.Scan(&signup.ID, &signup.UserID, &signup.InviteCode, &signup.CourseID, &signup.NamespaceID, &signup.JobID, &signup.JobError, &signup.AwaitingVerification, &createdAt); err != nil {
+			return err
+		}
+		var err error
+		if signup.CreatedAt, err = parseTime(createdAt); err != nil {
+			return err
+		}
+		s.signups[signup.ID] = signup
+		return nil
+	})
+	if err != nil {
+		return s, fmt.Errorf("load signups: %w", err)
+	}
+
+	err = each(`SELECT at, actor, action, target, details FROM audit_log ORDER BY id`, func(rows *sql.Rows) error {
+		var entry AuditEntry
+		var at, details string
+		if err := rows.Scan(&at, &entry.Actor, &entry.Action, &entry.Target, &details); err != nil {
+			return err
+		}
+		var err error
+		if entry.At, err = parseTime(at); err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(details), &entry.Details); err != nil {
+			return err
+		}
+		s.audit = append(s.audit, entry)
+		return nil
+	})
+	if err != nil {
+		return s, fmt.Errorf("load audit log: %w", err)
+	}
+
+	return s, nil
+}
+
+// takeSnapshot копирует карты в памяти под их блокировками
+func takeSnapshot() snapshot {
+	var s snapshot
+
+	namespaceMu.RLock()
+	s.namespaces = make(map[string]Namespace, len(namespaceDB))
+	for id, ns := range namespaceDB {
+		s.namespaces[id] = ns
+	}
+	s.members = make(map[string]map[string]string, len(namespaceMembers))
+	for id, members := range namespaceMembers {
+		s.members[id] = make(map[string]string, len(members))
+		for userID, role := range members {
+			s.members[id][userID] = role
+		}
+	}
+	namespaceMu.RUnlock()
+
+	userMu.RLock()
+	s.users = make(map[string]User, len(userDB))
+	for id, user := range userDB {
+		s.users[id] = user
+	}
+	userMu.RUnlock()
+
+	courseMu.RLock()
+	s.courses = make(map[string]Course, len(courseDB)+len(trashDB))
+	for id, course := range courseDB {
+		s.courses[id] = course
+	}
+	for id, course := range trashDB {
+		s.courses[id] = course
+	}
+	s.aliases = make(map[string]string, len(courseAliases))
+	for alias, courseID := range courseAliases {
+		s.aliases[alias] = courseID
+	}
+	courseMu.RUnlock()
+
+	boardMu.RLock()
+	s.boards = make(map[string]TaskBoardSummary, len(boardData))
+	for id, board := range boardData {
+		s.boards[id] = board
+	}
+	boardMu.RUnlock()
+
+	enrollmentMu.RLock()
+	s.enrollments = make(map[string]map[string]Enrollment, len(enrollmentDB))
+	for courseID, enrollments := range enrollmentDB {
+		s.enrollments[courseID] = make(map[string]Enrollment, len(enrollments))
+		for userID, enrollment := range enrollments {
+			s.enrollments[courseID][userID] = enrollment
+		}
+	}
+	enrollmentMu.RUnlock()
+
+	submissionMu.RLock()
+	s.submissions = append([]Submission(nil), submissionDB...)
+	submissionMu.RUnlock()
+
+	inviteMu.RLock()
+	s.invites = make(map[string]Invite, len(inviteDB))
+	for code, invite := range inviteDB {
+		s.invites[code] = invite
+	}
+	inviteMu.RUnlock()
+
+	signupMu.RLock()
+	s.signups = make(map[string]Signup, len(signupDB))
+	for id, signup := range signupDB {
+		s.signups[id] = signup
+	}
+	signupMu.RUnlock()
+
+	auditMu.RLock()
+	s.audit = append([]AuditEntry(nil), auditLog...)
+	auditMu.RUnlock()
+
+	return s
+}
+
+// storedState - содержимое базы в виде строк таблиц: таблица -> ключ строки -> значения колонок.
+// SaveState сравнивает с ним снимок и пишет только разницу
+type storedState struct {
+	rows  map[string]map[string][]any
+	audit auditMark
+}
+
+// auditMark - сколько записей аудита в базе и последняя из них. Аудит только дописывается
+type auditMark struct {
+	count int
+	last  []any
+}
+
+// stateTable - таблица снимка. Первые keys значений строки - ее ключ
+type stateTable struct {
+	name string
+	keys int
+	rows func(s snapshot) ([][]any, error)
+	put  func(ctx context.Context, tx *sql.Tx, row []any) error
+	drop func(ctx context.Context, tx *sql.Tx, row []any) error
+}
+
+// upsertTable - таблица, строки которой вставляются или обновляются по ключу
+func upsertTable(name string, columns []string, keys int, rows func(s snapshot) ([][]any, error)) stateTable {
+	set := make([]string, 0, len(columns)-keys)
+	for _, column := range columns[keys:] {
+		set = append(set, column+" = excluded."+column)
+	}
+	where := make([]string, 0, keys)
+	for _, column := range columns[:keys] {
+		where = append(where, column+" = ?")
+	}
+
+	upsert := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s`,
+		name, strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "),
+		strings.Join(columns[:keys], ", "), strings.Join(set, ", "))
+	remove := `DELETE FROM ` + name + ` WHERE ` + strings.Join(where, " AND ")
+
+	return stateTable{
+		name: name,
+		keys: keys,
+		rows: rows,
+		put: func(ctx context.Context, tx *sql.Tx, row []any) error {
+			_, err := tx.ExecContext(ctx, upsert, row...)
+			return err
+		},
+		drop: func(ctx context.Context, tx *sql.Tx, row []any) error {
+			_, err := tx.ExecContext(ctx, remove, row[:keys]...)
+			return err
+		},
+	}
+}
+
+// Таблицы снимка от родительских к зависимым
+var stateTables = []stateTable{
+	upsertTable("namespaces", []string{"id", "name", "slug", "description", "gitlab_group_id", "allowed_roles"}, 1, func(s snapshot) ([][]any, error) {
+		rows := make([][]any, 0, len(s.namespaces))
+		for _, ns := range s.namespaces {
+			rows = append(rows, []any{ns.ID, ns.Name, ns.Slug, ns.Description, ns.GitlabGroupID, strings.Join(ns.AllowedRoles, ",")})
+		}
+		return rows, nil
+	}),
+	upsertTable("users", []string{"id", "username", "rms_id", "role", "email", "telegram", "grp", "token_hash", "email_verified", "verification_nonce"}, 1, func(s snapshot) ([][]any, error) {
+		rows := make([][]any, 0, len(s.users))
+		for _, user := range s.users {
+			rows = append(rows, []any{user.ID, user.Username, user.RmsID, user.Role, nullString(user.Email), user.Telegram, user.Group, tokenHash(user), user.EmailVerified, user.VerificationNonce})
+		}
+		return rows, nil
+	}),
+	upsertTable("namespace_members", []string{"namespace_id", "user_id", "role"}, 2, func(s snapshot) ([][]any, error) {
+		var rows [][]any
+		for namespaceID, members := range s.members {
+			for userID, role := range members {
+				rows = append(rows, []any{namespaceID, userID, role})
+			}
+		}
+		return rows, nil
+	}),
+	upsertTable("courses", []string{"id", "name", "status", "start_date", "end_date", "timezone", "repo_template", "description", "url", "namespace_id", "gitlab_group", "version", "deleted_at"}, 1, func(s snapshot) ([][]any, error) {
+		rows := make([][]any, 0, len(s.courses))
+		for _, course := range s.courses {
+			rows = append(rows, []any{course.ID, course.Name, course.Status, course.StartDate, course.EndDate, timezoneName(course.Timezone), course.RepoTemplate, course.Description, course.URL, nullString(course.NamespaceID), course.GitlabGroup, course.Version, nullTime(course.DeletedAt)})
+		}
+		return rows, nil
+	}),
+	// Владельцы курса - одна строка снимка на курс: порядок владельцев задает rowid,
+	// поэтому при любом изменении список курса переписывается целиком
+	{
+		name: "course_owners",
+		keys: 1,
+		rows: func(s snapshot) ([][]any, error) {
+			rows := make([][]any, 0, len(s.courses))
+			for _, course := range s.courses {
+				row := []any{course.ID}
+				for _, owner := range course.Owners {
+					row = append(row, owner)
+				}
+				rows = append(rows, row)
+			}
+			return rows, nil
+		},
+		put: func(ctx context.Context, tx *sql.Tx, row []any) error {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM course_owners WHERE course_id = ?`, row[0]); err != nil {
+				return err
+			}
+			for _, owner := range row[1:] {
+				if _, err := tx.ExecContext(ctx, `INSERT INTO course_owners (course_id, username) VALUES (?, ?)`, row[0], owner); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		drop: func(ctx context.Context, tx *sql.Tx, row []any) error {
+			_, err := tx.ExecContext(ctx, `DELETE FROM course_owners WHERE course_id = ?`, row[0])
+			return err
+		},
+	},
+	upsertTable("course_aliases", []string{"slug", "course_id"}, 1, func(s snapshot) ([][]any, error) {
+		rows := make([][]any, 0, len(s.aliases))
+		for alias, courseID := range s.aliases {
+			rows = append(rows, []any{alias, courseID})
+		}
+		return rows, nil
+	}),
+	upsertTable("course_boards", []string{"course_id", "data"}, 1, func(s snapshot) ([][]any, error) {
+		rows := make([][]any, 0, len(s.boards))
+		for courseID, board := range s.boards {
+			data, err := json.Marshal(board)
+			if err != nil {
+				return nil, fmt.Errorf("board of %s: %w", courseID, err)
+			}
+			rows = append(rows, []any{courseID, string(data)})
+		}
+		return rows, nil
+	}),
+	upsertTable("enrollments", []string{"course_id", "user_id", "state", "updated_at"}, 2, func(s snapshot) ([][]any, error) {
+		var rows [][]any
+		for _, enrollments := range s.enrollments {
+			for _, enrollment := range enrollments {
+				rows = append(rows, []any{enrollment.CourseID, enrollment.UserID, enrollment.State, formatTime(enrollment.UpdatedAt)})
+			}
+		}
+		return rows, nil
+	}),
+	upsertTable("submissions", []string{"id", "course_id", "user_id", "task_id", "score", "submitted_at", "archived"}, 1, func(s snapshot) ([][]any, error) {
+		rows := make([][]any, 0, len(s.submissions))
+		for _, submission := range s.submissions {
+			rows = append(rows, []any{submission.ID, submission.CourseID, submission.UserID, submission.TaskID, submission.Score, formatTime(submission.SubmittedAt), submission.Archived})
+		}
+		return rows, nil
+	}),
+	upsertTable("invites", []string{"code", "course_id", "namespace_id", "role", "expires_at", "max_uses", "uses", "revoked", "created_by", "created_at"}, 1, func(s snapshot) ([][]any, error) {
+		rows := make([][]any, 0, len(s.invites))
+		for _, invite := range s.invites {
+			rows = append(rows, []any{invite.Code, nullString(invite.CourseID), invite.NamespaceID, invite.Role, formatTime(invite.ExpiresAt), invite.MaxUses, invite.Uses, invite.Revoked, invite.CreatedBy, formatTime(invite.CreatedAt)})
+		}
+		return rows, nil
+	}),
+	upsertTable("signups", []string{"id", "user_id", "invite_code", "course_id", "namespace_id", "job_id", "job_error", "awaiting_verification", "created_at"}, 1, func(s snapshot) ([][]any, error) {
+		rows := make([][]any, 0, len(s.signups))
+		for _, signup := range s.signups {
+			rows = append(rows, []any{signup.ID, signup.UserID, signup.InviteCode, signup.CourseID, signup.NamespaceID, signup.JobID, signup.JobError, signup.AwaitingVerification, formatTime(signup.CreatedAt)})
+		}
+		return rows, nil
+	}),
+}
+
+func rowKey(row []any, keys int) string {
+	parts := make([]string, keys)
+	for i, value := range row[:keys] {
+		parts[i] = fmt.Sprint(value)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// snapshotRows раскладывает снимок на строки таблиц
+func snapshotRows(s snapshot) (map[string]map[string][]any, error) {
+	tables := make(map[string]map[string][]any, len(stateTables))
+	for _, table := range stateTables {
+		rows, err := table.rows(s)
+		if err != nil {
+			return nil, fmt.Errorf("save %s: %w", table.name, err)
+		}
+		tables[table.name] = make(map[string][]any, len(rows))
+		for _, row := range rows {
+			tables[table.name][rowKey(row, table.keys)] = row
+		}
+	}
+	return tables, nil
+}
+
+func auditRow(entry AuditEntry) ([]any, error) {
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return nil, err
+	}
+	return []any{formatTime(entry.At), entry.Actor, entry.Action, entry.Target, string(details)}, nil
+}
+
+func storedAudit(audit []AuditEntry) (auditMark, error) {
+	if len(audit) == 0 {
+		return auditMark{}, nil
+	}
+	last, err := auditRow(audit[len(audit)-1])
+	if err != nil {
+		return auditMark{}, err
+	}
+	return auditMark{count: len(audit), last: last}, nil
+}
+
+// SaveState записывает в базу строки, которые изменились в памяти с последней
+// загрузки или записи этого процесса. Если базу за это время менял кто-то
+// другой - ErrStateConflict
+func SaveState(ctx context.Context, db *sql.DB) error {
+	ctx, span := storageSpan(ctx, "save", "state")
+	defer span.End()
+
+	s := takeSnapshot()
+	rows, err := snapshotRows(s)
+	if err != nil {
+		return err
+	}
+	audit, err := storedAudit(s.audit)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	expected := stateVersion.Load()
+	res, err := tx.ExecContext(ctx, `UPDATE state_version SET version = version + 1 WHERE id = 1 AND version = ?`, expected)
+	if err != nil {
+		return fmt.Errorf("bump state version (is the schema migrated?): %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrStateConflict
+	}
+
+	if err := writeChanges(ctx, tx, rows, s.audit); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	stateVersion.Store(expected + 1)
+	stored = storedState{rows: rows, audit: audit}
+	return nil
+}
+
+// writeChanges удаляет, вставляет и обновляет строки, которыми rows отличается
+// от stored, и дописывает новые записи аудита
+func writeChanges(ctx context.Context, tx *sql.Tx, rows map[string]map[string][]any, audit []AuditEntry) error {
+	// Внешние ключи проверяются при коммите: переименованный курс удаляется
+	// раньше, чем на новый ID переедут его посылки и инвайты
+	if _, err := tx.ExecContext(ctx, `PRAGMA defer_foreign_keys = ON`); err != nil {
+		return err
+	}
+
+	// Удаления идут первыми, чтобы освободить уникальные slug'и, логины и почты
+	for i := len(stateTables) - 1; i >= 0; i-- {
+		table := stateTables[i]
+		for key, row := range stored.rows[table.name] {
+			if _, exists := rows[table.name][key]; exists {
+				continue
+			}
+			if err := table.drop(ctx, tx, row); err != nil {
+				return fmt.Errorf("delete from %s: %w", table.name, err)
+			}
+		}
+	}
+
+	for _, table := range stateTables {
+		saved := stored.rows[table.name]
+		for key, row := range rows[table.name] {
+			if old, exists := saved[key]; exists && slices.Equal(old, row) {
+				continue
+			}
+			if err := table.put(ctx, tx, row); err != nil {
+				return fmt.Errorf("save %s: %w", table.name, err)
+			}
+		}
+	}
+
+	// Аудит только дописывается. Если его заменили целиком (фикстуры),
+	// таблица переписывается
+	from := stored.audit.count
+	if from > 0 {
+		last := []any(nil)
+		if from <= len(audit) {
+			var err error
+			if last, err = auditRow(audit[from-1]); err != nil {
+				return err
+			}
+		}
+		if !slices.Equal(last, stored.audit.last) {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM audit_log`); err != nil {
+				return fmt.Errorf("clear audit_log: %w", err)
+			}
+			from = 0
+		}
+	}
+	for _, entry := range audit[from:] {
+		row, err := auditRow(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO audit_log (at, actor, action, target, details) VALUES (?, ?, ?, ?, ?)`, row...); err != nil {
+			return fmt.Errorf("save audit_log: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// refreshState подхватывает изменения, записанные другим процессом (например, CLI).
+// Вызывается под stateGate.Lock
+func refreshState(ctx context.Context, db *sql.DB) error {
+	version, err := readStateVersion(ctx, db)
+	if err != nil || version == stateVersion.Load() {
+		return err
+	}
+
+	_, err = LoadState(ctx, db)
+	return err
+}
+
+// discardUnsaved возвращает карты в памяти к содержимому базы после неудачной записи.
+// Если перечитать базу не вышло, версия сбрасывается, и это сделает следующий запрос.
+// Вызывается под stateGate.Lock
+func discardUnsaved(ctx context.Context, db *sql.DB) error {
+	stateVersion.Store(-1)
+	_, err := LoadState(ctx, db)
+	return err
+}
+
+// UpdateState выполняет изменение вне HTTP-запроса, например из фоновой задачи,
+// по тем же правилам, что SyncState: сначала подхватывает чужие изменения,
+// потом сохраняет снимок, если fn что-то поменяла. Несохраненное изменение
+// откатывается. Без базы (демо-режим) только вызывает fn
+func UpdateState(ctx context.Context, db *sql.DB, fn func() bool) error {
+	stateGate.Lock()
+	defer stateGate.Unlock()
+
+	if db == nil {
+		fn()
+		return nil
+	}
+
+	if err := refreshState(ctx, db); err != nil {
+		return err
+	}
+	if !fn() {
+		return nil
+	}
+	if err := SaveState(ctx, db); err != nil {
+		if reloadErr := discardUnsaved(ctx, db); reloadErr != nil {
+			return errors.Join(err, reloadErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func mutates(c echo.Context) bool {
+	switch c.Request().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return mutatingReads[c.Path()]
+	default:
+		return true
+	}
+}
+
+// stateCheckDue - пора ли чтению сверить версию снимка с базой
+func stateCheckDue() bool {
+	now := time.Now().UnixNano()
+	last := lastStateCheck.Load()
+	return now-last >= int64(stateCheckInterval) && lastStateCheck.CompareAndSwap(last, now)
+}
+
+// bufferedResponse придерживает ответ изменяющего запроса, пока снимок не сохранен
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponse) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponse) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedResponse) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// flush отдает придержанный ответ клиенту
+func (w *bufferedResponse) flush(to http.ResponseWriter) error {
+	header := to.Header()
+	clear(header)
+	maps.Copy(header, w.header)
+
+	if w.status == 0 {
+		return nil
+	}
+	to.WriteHeader(w.status)
+	_, err := to.Write(w.body.Bytes())
+	return err
+}
+
+type afterSaveKey struct{}
+
+// afterSave - внешние действия изменяющего запроса: письма и задачи провижининга.
+// Выполняются только после записи снимка и уже без stateGate
+type afterSave struct {
+	db      *sql.DB
+	saved   bool
+	actions []func(ctx context.Context)
+}
+
+func (p *afterSave) run(ctx context.Context) {
+	p.saved = true
+	for _, action := range p.actions {
+		action(ctx)
+	}
+}
+
+// afterStateSaved откладывает внешнее действие до записи изменения в базу:
+// если изменение откатится, письмо не уйдет и задача не встанет в очередь.
+// Вне SyncState (демо-режим, тесты) действие выполняется сразу
+func afterStateSaved(ctx context.Context, action func(ctx context.Context)) {
+	if pending, ok := ctx.Value(afterSaveKey{}).(*afterSave); ok && !pending.saved {
+		pending.actions = append(pending.actions, action)
+		return
+	}
+	action(ctx)
+}
+
+// updateAfterSave сохраняет изменение, которое сделало отложенное действие
+func updateAfterSave(ctx context.Context, fn func() bool) error {
+	var db *sql.DB
+	if pending, ok := ctx.Value(afterSaveKey{}).(*afterSave); ok {
+		db = pending.db
+	}
+	return UpdateState(ctx, db, fn)
+}
+
+// SyncState держит карты в памяти согласованными с базой: перед запросом
+// подхватывает чужие изменения, после успешного изменяющего запроса сохраняет снимок.
+// Изменяющие запросы выполняются по одному, их ответ и внешние действия - только
+// после записи: если снимок сохранить не удалось, изменение откатывается, а клиент
+// получает 409 (базу успел изменить другой процесс) или 503
+func SyncState(db *sql.DB) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !strings.HasPrefix(c.Path(), "/api/") {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+			logger := logging.FromContext(ctx)
+
+			if !mutates(c) {
+				if stateCheckDue() {
+					if version, err := readStateVersion(ctx, db); err != nil {
+						logger.Warn("failed to check stored state, serving from memory", "error", err)
+					} else if version != stateVersion.Load() {
+						stateGate.Lock()
+						if err := refreshState(ctx, db); err != nil {
+							logger.Warn("failed to reload stored state, serving from memory", "error", err)
+						}
+						stateGate.Unlock()
+					}
+				}
+
+				stateGate.RLock()
+				defer stateGate.RUnlock()
+				return next(c)
+			}
+
+			buffer, pending, err := mutate(c, next, db)
+			if pending != nil {
+				pending.run(c.Request().Context())
+			}
+			if buffer == nil {
+				return err
+			}
+			if flushErr := buffer.flush(c.Response().Writer); flushErr != nil {
+				return flushErr
+			}
+			return err
+		}
+	}
+}
+
+// mutate выполняет изменяющий запрос под stateGate и сохраняет снимок.
+// Возвращает придержанный ответ и, если снимок записан, отложенные действия.
+// Без придержанного ответа ошибка уже записана в c
+func mutate(c echo.Context, next echo.HandlerFunc, db *sql.DB) (*bufferedResponse, *afterSave, error) {
+	ctx := c.Request().Context()
+	logger := logging.FromContext(ctx)
+
+	stateGate.Lock()
+	defer stateGate.Unlock()
+
+	// Изменение, которое не получится сохранить, лучше не принимать
+	if err := refreshState(ctx, db); err != nil {
+		logger.Error("failed to reload stored state", "error", err)
+		return nil, nil, c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "storage unavailable"})
+	}
+
+	pending := &afterSave{db: db}
+	c.SetRequest(c.Request().WithContext(context.WithValue(ctx, afterSaveKey{}, pending)))
+
+	res := c.Response()
+	original := res.Writer
+	buffer := &bufferedResponse{header: original.Header().Clone()}
+	res.Writer = buffer
+	err := next(c)
+	res.Writer = original
+
+	// Неуспешный запрос ничего не сохраняет, и его действия не выполняются
+	if err != nil || res.Status >= http.StatusBadRequest {
+		return buffer, nil, err
+	}
+
+	if err := SaveState(ctx, db); err != nil {
+		logger.Error("failed to save state, change discarded", "error", err)
+		if err := discardUnsaved(ctx, db); err != nil {
+			logger.Error("failed to reload stored state", "error", err)
+		}
+
+		res.Committed, res.Size = false, 0
+		if errors.Is(err, ErrStateConflict) {
+			return nil, nil, c.JSON(http.StatusConflict, map[string]string{"error": ErrStateConflict.Error()})
+		}
+		return nil, nil, c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "storage unavailable"})
+	}
+
+	return buffer, pending, nil
+}
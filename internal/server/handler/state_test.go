@@ -0,0 +1,484 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	_ "modernc.org/sqlite"
+
+	"fcstask-backend/internal/migrate"
+	"fcstask-backend/internal/provision"
+)
+
+// openStateDB - мигрированная база в каталоге теста, версия снимка сброшена
+func openStateDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := "file:" + filepath.Join(t.TempDir(), "state.db") + "?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m, err := migrate.New(db, migrate.Embedded())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	stateVersion.Store(0)
+	stored = storedState{}
+	lastStateCheck.Store(0)
+	t.Cleanup(func() {
+		stateVersion.Store(0)
+		stored = storedState{}
+	})
+
+	return db
+}
+
+func resetStateDB() {
+	resetEnrollmentDB()
+
+	resetBoardDB()
+	delete(boardData, "mlops")
+
+	inviteMu.Lock()
+	inviteDB = map[string]Invite{
+		"course-code": {
+			Code: "course-code", CourseID: "algorithms", NamespaceID: "ns-01", Role: RoleStudent,
+			ExpiresAt: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), MaxUses: 10, Uses: 2,
+			CreatedBy: "admin", CreatedAt: time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC),
+		},
+	}
+	inviteMu.Unlock()
+
+	auditMu.Lock()
+	auditLog = []AuditEntry{
+		{At: time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC), Actor: "admin", Action: "course.move", Target: "algorithms", Details: map[string]string{"from": "ns-02", "to": "ns-01"}},
+	}
+	auditMu.Unlock()
+}
+
+func TestState_RoundTrip(t *testing.T) {
+	db := openStateDB(t)
+	ctx := context.Background()
+	resetStateDB()
+
+	if loaded, err := LoadState(ctx, db); err != nil || loaded {
+		t.Fatalf("empty database must not be loaded: %v, %v", loaded, err)
+	}
+
+	courseMu.Lock()
+	algorithms := courseDB["algorithms"]
+	algorithms.Version = 7
+	courseDB["algorithms"] = algorithms
+	courseMu.Unlock()
+	submissionMu.Lock()
+	submissionDB[0].Archived = true
+	submissionMu.Unlock()
+
+	want := takeSnapshot()
+	if err := SaveState(ctx, db); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	// затираем память, чтобы убедиться, что все вернулось из базы
+	userMu.Lock()
+	userDB = map[string]User{}
+	userMu.Unlock()
+	courseMu.Lock()
+	courseDB = map[string]Course{}
+	courseMu.Unlock()
+
+	if loaded, err := LoadState(ctx, db); err != nil || !loaded {
+		t.Fatalf("load: %v, %v", loaded, err)
+	}
+	got := takeSnapshot()
+
+	if len(got.users) != len(want.users) || len(got.courses) != len(want.courses) || len(got.namespaces) != len(want.namespaces) {
+		t.Fatalf("counts differ: users %d/%d, courses %d/%d, namespaces %d/%d",
+			len(got.users), len(want.users), len(got.courses), len(want.courses), len(got.namespaces), len(want.namespaces))
+	}
+
+	algorithms = got.courses["algorithms"]
+	if algorithms.NamespaceID != "ns-01" || algorithms.Version != 7 || len(algorithms.Owners) != len(want.courses["algorithms"].Owners) {
+		t.Errorf("course not restored: %+v", algorithms)
+	}
+	if board := got.boards["algorithms"]; len(got.boards) != 1 || len(board.Groups) != 1 || board.Groups[0].Tasks[0].ID != "t1" || board.Groups[0].Version != 1 {
+		t.Errorf("board not restored: %+v", got.boards)
+	}
+	if got.members["ns-01"]["u-3"] != RoleProgramManager {
+		t.Errorf("membership not restored: %v", got.members["ns-01"])
+	}
+	if got.enrollments["hidden"]["u-4"].State != EnrollmentDropped || got.enrollments["hidden"]["u-4"].Username != "student" {
+		t.Errorf("enrollment not restored: %+v", got.enrollments["hidden"]["u-4"])
+	}
+	if len(got.submissions) != 3 || got.submissions[1].Score != 8 || !got.submissions[0].Archived || got.submissions[1].Archived {
+		t.Errorf("submissions not restored: %+v", got.submissions)
+	}
+	if invite := got.invites["course-code"]; invite.Uses != 2 || !invite.ExpiresAt.Equal(want.invites["course-code"].ExpiresAt) {
+		t.Errorf("invite not restored: %+v", invite)
+	}
+	if len(got.audit) != 1 || got.audit[0].Details["to"] != "ns-01" {
+		t.Errorf("audit log not restored: %+v", got.audit)
+	}
+
+	// токены в базе не хранятся, но по ним по-прежнему можно войти
+	if admin := got.users["u-1"]; admin.Token != "" || admin.TokenHash == "" {
+		t.Errorf("token must be stored as a hash: %+v", admin)
+	}
+	c := echo.New().NewContext(authReq(http.MethodGet, "/", "admin-token", nil), httptest.NewRecorder())
+	if user, ok := currentUser(c); !ok || user.ID != "u-1" {
+		t.Error("loaded user must authenticate with the original token")
+	}
+}
+
+func TestState_TrashRoundTrip(t *testing.T) {
+	db := openStateDB(t)
+	ctx := context.Background()
+	resetStateDB()
+
+	admin, _ := findUserByUsername("admin")
+	deleted, err := DeleteCourse(admin, "hidden", 0, time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveState(ctx, db); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	courseMu.Lock()
+	courseDB, trashDB = map[string]Course{}, map[string]Course{}
+	courseMu.Unlock()
+
+	if _, err := LoadState(ctx, db); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	courseMu.RLock()
+	defer courseMu.RUnlock()
+	if _, live := courseDB["hidden"]; live {
+		t.Error("deleted course must not be loaded as a live one")
+	}
+	if got := trashDB["hidden"]; got.DeletedAt == nil || !got.DeletedAt.Equal(*deleted.DeletedAt) || got.Version != deleted.Version {
+		t.Errorf("deleted course not restored: %+v", got)
+	}
+	if _, live := courseDB["algorithms"]; !live {
+		t.Error("live course lost")
+	}
+}
+
+func TestState_AliasRoundTrip(t *testing.T) {
+	db := openStateDB(t)
+	ctx := context.Background()
+	resetStateDB()
+
+	admin, _ := findUserByUsername("admin")
+	if _, err := RenameCourse(admin, "algorithms", 0, "algo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveState(ctx, db); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	courseMu.Lock()
+	courseDB, courseAliases = map[string]Course{}, map[string]string{}
+	courseMu.Unlock()
+
+	if _, err := LoadState(ctx, db); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if target, ok := resolveCourseAlias("algorithms"); !ok || target != "algo" {
+		t.Errorf("alias not restored: %q, %v", target, ok)
+	}
+	courseMu.RLock()
+	defer courseMu.RUnlock()
+	if got := courseDB["algo"]; got.GitlabGroup != "algorithms" {
+		t.Errorf("renamed course not restored: %+v", got)
+	}
+}
+
+func TestState_WritesOnlyChangedRows(t *testing.T) {
+	db := openStateDB(t)
+	ctx := context.Background()
+	resetStateDB()
+
+	courseMu.Lock()
+	algorithms := courseDB["algorithms"]
+	algorithms.Owners = []string{"pm", "admin"}
+	courseDB["algorithms"] = algorithms
+	courseMu.Unlock()
+
+	if err := SaveState(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	// строку, которую память не меняла, запись не трогает
+	if _, err := db.Exec(`UPDATE namespaces SET description = 'untouched' WHERE id = 'ns-02'`); err != nil {
+		t.Fatal(err)
+	}
+	var firstAuditID int
+	if err := db.QueryRow(`SELECT MIN(id) FROM audit_log`).Scan(&firstAuditID); err != nil {
+		t.Fatal(err)
+	}
+
+	// переименование переносит посылки и инвайты курса на новый ID
+	admin, _ := findUserByUsername("admin")
+	if _, err := RenameCourse(admin, "algorithms", 0, "algo"); err != nil {
+		t.Fatal(err)
+	}
+	inviteMu.Lock()
+	delete(inviteDB, "course-code")
+	inviteMu.Unlock()
+
+	if err := SaveState(ctx, db); err != nil {
+		t.Fatalf("save after rename: %v", err)
+	}
+
+	var description string
+	if err := db.QueryRow(`SELECT description FROM namespaces WHERE id = 'ns-02'`).Scan(&description); err != nil || description != "untouched" {
+		t.Errorf("unchanged row was rewritten: %q, %v", description, err)
+	}
+
+	count := func(query string) int {
+		t.Helper()
+		var n int
+		if err := db.QueryRow(query).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if n := count(`SELECT COUNT(*) FROM courses WHERE id = 'algorithms'`); n != 0 {
+		t.Error("old course row must be deleted")
+	}
+	if n := count(`SELECT COUNT(*) FROM submissions WHERE course_id = 'algo'`); n == 0 {
+		t.Error("submissions must move to the new course ID")
+	}
+	if n := count(`SELECT COUNT(*) FROM course_owners WHERE course_id = 'algo'`); n == 0 {
+		t.Error("owners must move to the new course ID")
+	}
+	if n := count(`SELECT COUNT(*) FROM invites`); n != 0 {
+		t.Error("deleted invite must be removed")
+	}
+	if n := count(`SELECT COUNT(*) FROM audit_log WHERE id = ` + strconv.Itoa(firstAuditID)); n != 1 {
+		t.Error("audit log must be appended, not rewritten")
+	}
+	if n := count(`SELECT COUNT(*) FROM audit_log`); n != 2 {
+		t.Errorf("expected 2 audit entries, got %d", n)
+	}
+}
+
+func TestSyncState_SaveConflict(t *testing.T) {
+	db := openStateDB(t)
+	ctx := context.Background()
+	resetStateDB()
+
+	if err := SaveState(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.Use(SyncState(db))
+	e.POST("/api/namespaces", func(c echo.Context) error {
+		// другой процесс записал базу, пока выполнялся запрос
+		if _, err := db.Exec(`UPDATE state_version SET version = version + 1`); err != nil {
+			t.Fatal(err)
+		}
+		return CreateNamespaceHandler(c)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/namespaces", "admin-token", []byte(`{"name":"New","slug":"new"}`)))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get(echo.HeaderLocation) != "" || strings.Contains(rec.Body.String(), `"slug"`) {
+		t.Errorf("unsaved response leaked: %v %s", rec.Header(), rec.Body.String())
+	}
+
+	// несохраненное изменение откатывается
+	namespaceMu.RLock()
+	for _, ns := range namespaceDB {
+		if ns.Slug == "new" {
+			t.Error("unsaved namespace must be discarded")
+		}
+	}
+	namespaceMu.RUnlock()
+
+	var version int64
+	if err := db.QueryRow(`SELECT version FROM state_version`).Scan(&version); err != nil || version != stateVersion.Load() {
+		t.Errorf("memory must be reloaded from the database: %d vs %d, %v", version, stateVersion.Load(), err)
+	}
+}
+
+// conflictingSignup - маршрут регистрации, на котором базу успевает записать другой процесс
+func conflictingSignup(t *testing.T, db *sql.DB, conflict *bool) *echo.Echo {
+	e := echo.New()
+	e.Use(SyncState(db))
+	e.POST("/api/signup", func(c echo.Context) error {
+		if *conflict {
+			if _, err := db.Exec(`UPDATE state_version SET version = version + 1`); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return SignupHandler(c)
+	})
+	return e
+}
+
+func TestSyncState_SideEffectsAfterSave(t *testing.T) {
+	db := openStateDB(t)
+	ctx := context.Background()
+	resetStateDB()
+	prov := &stubProvisioner{status: provision.Status{State: provision.StatePending}}
+	resetSignupDB(prov)
+	if err := SaveState(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	conflict := true
+	e := conflictingSignup(t, db, &conflict)
+	body := []byte(`{"inviteCode":"course-code","email":"new@example.com"}`)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodPost, "/api/signup", body))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(prov.jobs) != 0 {
+		t.Fatal("discarded signup must not queue a repository")
+	}
+
+	conflict = false
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodPost, "/api/signup", body))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(prov.jobs) != 1 {
+		t.Fatalf("saved signup must queue a repository, got %d jobs", len(prov.jobs))
+	}
+
+	var resp SignupResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	var jobID string
+	if err := db.QueryRow(`SELECT job_id FROM signups WHERE id = ?`, resp.SignupID).Scan(&jobID); err != nil || jobID != "job-1" {
+		t.Errorf("job ID must be saved with the signup: %q, %v", jobID, err)
+	}
+}
+
+func TestSyncState_NoMailForDiscardedSignup(t *testing.T) {
+	db := openStateDB(t)
+	ctx := context.Background()
+	resetStateDB()
+	resetSignupDB(nil)
+	outbox := enableVerification(t)
+	if err := SaveState(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	conflict := true
+	e := conflictingSignup(t, db, &conflict)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodPost, "/api/signup", []byte(`{"inviteCode":"course-code","email":"new@example.com"}`)))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if files, _ := outbox.Messages(); len(files) != 0 {
+		t.Fatalf("discarded signup must not send mail, got %d", len(files))
+	}
+}
+
+func TestState_ConflictingWrites(t *testing.T) {
+	db := openStateDB(t)
+	ctx := context.Background()
+	resetStateDB()
+
+	if err := SaveState(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	// другой процесс успел записать базу
+	if _, err := db.Exec(`UPDATE state_version SET version = version + 1`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveState(ctx, db); !errors.Is(err, ErrStateConflict) {
+		t.Fatalf("expected ErrStateConflict, got %v", err)
+	}
+
+	if _, err := LoadState(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveState(ctx, db); err != nil {
+		t.Fatalf("save after reload: %v", err)
+	}
+}
+
+func TestSyncState(t *testing.T) {
+	db := openStateDB(t)
+	ctx := context.Background()
+	resetStateDB()
+
+	if err := SaveState(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.Use(SyncState(db))
+	e.GET("/api/courses/:courseId", GetCourseHandler)
+	e.POST("/api/namespaces", CreateNamespaceHandler)
+
+	// изменение от другого процесса подхватывается следующим запросом
+	if _, err := db.Exec(`UPDATE courses SET status = 'finished' WHERE id = 'algorithms'`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE state_version SET version = version + 1`); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodGet, "/api/courses/algorithms", nil))
+	if course, _ := getCourse("algorithms"); course.Status != "finished" {
+		t.Fatalf("external change not picked up: %q", course.Status)
+	}
+
+	// успешное изменение сохраняется, неуспешное - нет
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/namespaces", "admin-token", []byte(`{"name":"New","slug":"new"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	versionAfterCreate := stateVersion.Load()
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/namespaces", "admin-token", []byte(`{"name":"Dup","slug":"new"}`)))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rec.Code)
+	}
+	if stateVersion.Load() != versionAfterCreate {
+		t.Error("failed request must not be saved")
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM namespaces WHERE slug = 'new'`).Scan(&count); err != nil || count != 1 {
+		t.Fatalf("created namespace not saved: %d, %v", count, err)
+	}
+}
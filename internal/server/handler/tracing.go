@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"fcstask-backend/internal/tracing"
+)
+
+// tracerName - имя трейсера спанов пакета
+const tracerName = "fcstask-backend/internal/server/handler"
+
+// storageSpan - дочерний спан вокруг запроса к базе
+func storageSpan(ctx context.Context, operation, table string) (context.Context, trace.Span) {
+	return tracing.Tracer(tracerName).Start(ctx, "storage."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.operation.name", operation),
+			attribute.String("db.collection.name", table),
+		),
+	)
+}
@@ -0,0 +1,333 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TrashedCourse - удаленный курс в корзине
+type TrashedCourse struct {
+	Course
+
+	// Когда курс будет удален окончательно
+	PurgeAt time.Time `json:"purgeAt"`
+}
+
+// MarshalJSON - без него PurgeAt потерялся бы за MarshalJSON встроенного Course
+func (course TrashedCourse) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		courseView
+		PurgeAt time.Time `json:"purgeAt"`
+	}{course.view(), course.PurgeAt})
+}
+
+var (
+	// Удаленные курсы, под courseMu. Slug удаленного курса занят до очистки
+	trashDB = map[string]Course{}
+
+	// Сколько удаленный курс хранится в корзине
+	trashRetention   = 30 * 24 * time.Hour
+	trashRetentionMu sync.RWMutex
+)
+
+// purgeActor - от его имени в аудит пишется окончательное удаление
+var purgeActor = User{Username: "system:trash-purge"}
+
+// SetTrashRetention задает срок хранения удаленных курсов
+func SetTrashRetention(retention time.Duration) {
+	trashRetentionMu.Lock()
+	trashRetention = retention
+	trashRetentionMu.Unlock()
+}
+
+func getTrashRetention() time.Duration {
+	trashRetentionMu.RLock()
+	defer trashRetentionMu.RUnlock()
+	return trashRetention
+}
+
+func isDeletedCourse(courseID string) bool {
+	courseMu.RLock()
+	defer courseMu.RUnlock()
+	_, deleted := trashDB[courseID]
+	return deleted
+}
+
+// administersAnyNamespace - корзину видят инстанс-админ и админы namespace
+func administersAnyNamespace(actor User) bool {
+	if actor.Role == RoleInstanceAdmin {
+		return true
+	}
+
+	namespaceMu.RLock()
+	defer namespaceMu.RUnlock()
+	for _, members := range namespaceMembers {
+		if members[actor.ID] == RoleNamespaceAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteCourse переносит курс в корзину. Записи, посылки и доска сохраняются,
+// пока курс не очищен, slug остается занятым. Доступно админам namespace.
+// version - ожидаемая версия курса, 0 - без проверки
+func DeleteCourse(actor User, courseID string, version int, now time.Time) (Course, error) {
+	course, err := visibleCourse(actor, courseID)
+	if err != nil {
+		return Course{}, err
+	}
+
+	if !canAdministerNamespace(actor, course.NamespaceID) {
+		return Course{}, newAPIError(http.StatusForbidden, "only namespace admins can delete courses")
+	}
+
+	courseMu.Lock()
+	course, exists := courseDB[courseID]
+	if !exists {
+		courseMu.Unlock()
+		return Course{}, newAPIError(http.StatusNotFound, "course not found")
+	}
+	if version != 0 && course.Version != version {
+		courseMu.Unlock()
+		return Course{}, errPreconditionFailed
+	}
+	deletedAt := now.UTC()
+	course.DeletedAt = &deletedAt
+	course.Version++
+	delete(courseDB, courseID)
+	trashDB[courseID] = course
+	courseMu.Unlock()
+
+	recordAudit(actor, "course.delete", courseID, nil)
+
+	return course, nil
+}
+
+// RestoreCourse возвращает курс из корзины со всеми записями и посылками
+func RestoreCourse(actor User, courseID string, version int) (Course, error) {
+	courseMu.RLock()
+	course, exists := trashDB[courseID]
+	courseMu.RUnlock()
+
+	if !exists {
+		return Course{}, newAPIError(http.StatusNotFound, "deleted course not found")
+	}
+
+	if !canAdministerNamespace(actor, course.NamespaceID) {
+		return Course{}, newAPIError(http.StatusForbidden, "only namespace admins can restore courses")
+	}
+
+	courseMu.Lock()
+	course, exists = trashDB[courseID]
+	if !exists {
+		courseMu.Unlock()
+		return Course{}, newAPIError(http.StatusNotFound, "deleted course not found")
+	}
+	if version != 0 && course.Version != version {
+		courseMu.Unlock()
+		return Course{}, errPreconditionFailed
+	}
+	course.DeletedAt = nil
+	course.Version++
+	delete(trashDB, courseID)
+	courseDB[courseID] = course
+	courseMu.Unlock()
+
+	recordAudit(actor, "course.restore", courseID, nil)
+
+	return course, nil
+}
+
+// ListTrash - удаленные курсы в namespace'ах, которыми управляет пользователь,
+// сначала удаленные последними
+func ListTrash(actor User) ([]TrashedCourse, error) {
+	if !administersAnyNamespace(actor) {
+		return nil, errForbidden
+	}
+
+	retention := getTrashRetention()
+
+	courseMu.RLock()
+	courses := make([]Course, 0, len(trashDB))
+	for _, course := range trashDB {
+		courses = append(courses, course)
+	}
+	courseMu.RUnlock()
+
+	trash := make([]TrashedCourse, 0, len(courses))
+	for _, course := range courses {
+		if canAdministerNamespace(actor, course.NamespaceID) {
+			trash = append(trash, TrashedCourse{Course: course, PurgeAt: course.DeletedAt.Add(retention)})
+		}
+	}
+
+	slices.SortFunc(trash, func(a, b TrashedCourse) int {
+		if c := b.DeletedAt.Compare(*a.DeletedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return trash, nil
+}
+
+// PurgeDeletedCourses окончательно удаляет курсы, пролежавшие в корзине
+// дольше срока хранения, вместе с записями, посылками, доской и инвайтами.
+// Возвращает slug'и удаленных курсов, они и старые slug'и этих курсов снова свободны
+func PurgeDeletedCourses(now time.Time) []string {
+	cutoff := now.Add(-getTrashRetention())
+
+	courseMu.Lock()
+	var purged []string
+	for id, course := range trashDB {
+		if !course.DeletedAt.After(cutoff) {
+			purged = append(purged, id)
+			delete(trashDB, id)
+		}
+	}
+	for alias, target := range courseAliases {
+		if slices.Contains(purged, target) {
+			delete(courseAliases, alias)
+		}
+	}
+	courseMu.Unlock()
+
+	if len(purged) == 0 {
+		return nil
+	}
+	slices.Sort(purged)
+
+	enrollmentMu.Lock()
+	for _, id := range purged {
+		delete(enrollmentDB, id)
+	}
+	enrollmentMu.Unlock()
+
+	submissionMu.Lock()
+	submissionDB = slices.DeleteFunc(submissionDB, func(submission Submission) bool {
+		return slices.Contains(purged, submission.CourseID)
+	})
+	submissionMu.Unlock()
+
+	boardMu.Lock()
+	for _, id := range purged {
+		delete(boardData, id)
+	}
+	boardMu.Unlock()
+
+	inviteMu.Lock()
+	for code, invite := range inviteDB {
+		if slices.Contains(purged, invite.CourseID) {
+			delete(inviteDB, code)
+		}
+	}
+	inviteMu.Unlock()
+
+	for _, id := range purged {
+		recordAudit(purgeActor, "course.purge", id, nil)
+	}
+
+	return purged
+}
+
+// RunTrashPurge раз в interval очищает корзину и сохраняет результат в базу
+// (db == nil в демо-режиме). После каждого запуска вызывает onRun с его ошибкой.
+// Работает до отмены ctx. Неположительный interval отключает очистку
+func RunTrashPurge(ctx context.Context, db *sql.DB, interval time.Duration, logger *slog.Logger, onRun func(err error)) {
+	if interval <= 0 {
+		logger.Warn("trash purge is disabled: interval is not positive", "interval", interval)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			var purged []string
+			err := UpdateState(ctx, db, func() bool {
+				purged = PurgeDeletedCourses(now)
+				return len(purged) > 0
+			})
+			if err != nil {
+				logger.Error("failed to purge deleted courses", "error", err)
+			} else if len(purged) > 0 {
+				logger.Info("purged deleted courses", "courses", purged)
+			}
+			onRun(err)
+		}
+	}
+}
+
+// Хендлеры
+
+// DELETE /api/courses/:courseId
+// Требует If-Match, как и другие изменения курса
+func DeleteCourseHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	course, err := DeleteCourse(actor, c.Param("courseId"), version, time.Now())
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	c.Response().Header().Set("ETag", versionETag(course.Version))
+	return c.JSON(http.StatusOK, course)
+}
+
+// POST /api/courses/:courseId/restore
+// Требует If-Match с версией курса из корзины
+func RestoreCourseHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	course, err := RestoreCourse(actor, c.Param("courseId"), version)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	c.Response().Header().Set("ETag", versionETag(course.Version))
+	return c.JSON(http.StatusOK, course)
+}
+
+// GET /api/trash/courses
+func GetTrashHandler(c echo.Context) error {
+	actor, ok := currentUser(c)
+	if !ok {
+		return writeError(c, errUnauthorized)
+	}
+
+	trash, err := ListTrash(actor)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, trash)
+}
@@ -0,0 +1,343 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func setupEchoTrash() *echo.Echo {
+	e := echo.New()
+	api := e.Group("/api")
+
+	api.GET("/courses", GetCoursesHandler)
+	api.GET("/courses/:courseId", GetCourseHandler)
+	api.POST("/courses", CreateCourseHandler)
+	api.DELETE("/courses/:courseId", DeleteCourseHandler)
+	api.POST("/courses/:courseId/restore", RestoreCourseHandler)
+	api.GET("/trash/courses", GetTrashHandler)
+
+	return e
+}
+
+// trashReq - изменяющий запрос с авторизацией и If-Match
+func trashReq(method, path, token, ifMatch string) *http.Request {
+	req := authReq(method, path, token, nil)
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	return req
+}
+
+func resetTrashDB() {
+	resetEnrollmentDB()
+	resetBoardDB()
+
+	auditMu.Lock()
+	auditLog = []AuditEntry{}
+	auditMu.Unlock()
+
+	inviteMu.Lock()
+	inviteDB = map[string]Invite{
+		"course-invite": {Code: "course-invite", CourseID: "algorithms", NamespaceID: "ns-01", Role: RoleStudent, MaxUses: 10, ExpiresAt: time.Now().Add(time.Hour)},
+		"ns-invite":     {Code: "ns-invite", NamespaceID: "ns-01", Role: RoleStudent, MaxUses: 10, ExpiresAt: time.Now().Add(time.Hour)},
+	}
+	inviteMu.Unlock()
+
+	SetTrashRetention(30 * 24 * time.Hour)
+}
+
+func TestDeleteCourse_Permissions(t *testing.T) {
+	cases := []struct {
+		name    string
+		token   string
+		ifMatch string
+		want    int
+	}{
+		{"anonymous", "", `"1"`, http.StatusUnauthorized},
+		{"program manager", "pm-token", `"1"`, http.StatusForbidden},
+		{"student", "student-token", `"1"`, http.StatusForbidden},
+		{"without If-Match", "nsadmin-token", "", http.StatusPreconditionRequired},
+		{"stale version", "nsadmin-token", `"7"`, http.StatusPreconditionFailed},
+		{"namespace admin", "nsadmin-token", `"1"`, http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resetTrashDB()
+			e := setupEchoTrash()
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, trashReq(http.MethodDelete, "/api/courses/algorithms", tc.token, tc.ifMatch))
+
+			if rec.Code != tc.want {
+				t.Fatalf("expected %d, got %d: %s", tc.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestDeleteCourse_KeepsDataAndReservesSlug(t *testing.T) {
+	resetTrashDB()
+	e := setupEchoTrash()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, trashReq(http.MethodDelete, "/api/courses/algorithms", "nsadmin-token", `"1"`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var deleted Course
+	json.Unmarshal(rec.Body.Bytes(), &deleted)
+	if deleted.DeletedAt == nil || deleted.Version != 2 || rec.Header().Get("ETag") != `"2"` {
+		t.Fatalf("unexpected deleted course: %+v, ETag %q", deleted, rec.Header().Get("ETag"))
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses/algorithms", "admin-token", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("deleted course must not be served, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses", "admin-token", nil))
+	var courses []Course
+	json.Unmarshal(rec.Body.Bytes(), &courses)
+	if courseIDs(courses) != "hidden" {
+		t.Errorf("deleted course must not be listed, got %s", courseIDs(courses))
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/courses", "admin-token", []byte(`{"name":"Again","slug":"algorithms","namespaceId":"ns-01","status":"created","startDate":"2025-01-01","endDate":"2025-02-01","repoTemplate":"git@a:repo.git"}`)))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("slug of a deleted course must stay reserved, got %d", rec.Code)
+	}
+
+	enrollmentMu.RLock()
+	_, enrolled := enrollmentDB["algorithms"]["u-4"]
+	enrollmentMu.RUnlock()
+	submissionMu.RLock()
+	submissions := len(submissionDB)
+	submissionMu.RUnlock()
+	if !enrolled || submissions != 3 {
+		t.Errorf("enrollments and submissions must be kept, enrolled=%v submissions=%d", enrolled, submissions)
+	}
+
+	if _, err := redeemInvite("course-invite", time.Now()); apiStatus(err) != http.StatusGone {
+		t.Errorf("invite to a deleted course: expected 410, got %v", err)
+	}
+
+	if len(auditLog) != 1 || auditLog[0].Action != "course.delete" || auditLog[0].Actor != "nsadmin" {
+		t.Errorf("unexpected audit log: %+v", auditLog)
+	}
+}
+
+func TestRestoreCourse(t *testing.T) {
+	resetTrashDB()
+	e := setupEchoTrash()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, trashReq(http.MethodDelete, "/api/courses/algorithms", "nsadmin-token", `"1"`))
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, trashReq(http.MethodPost, "/api/courses/algorithms/restore", "student-token", `"2"`))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("student restore: expected 403, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, trashReq(http.MethodPost, "/api/courses/algorithms/restore", "nsadmin-token", `"1"`))
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale restore: expected 412, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, trashReq(http.MethodPost, "/api/courses/algorithms/restore", "nsadmin-token", `"2"`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("restore: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var restored Course
+	json.Unmarshal(rec.Body.Bytes(), &restored)
+	if restored.DeletedAt != nil || restored.Version != 3 {
+		t.Fatalf("unexpected restored course: %+v", restored)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodGet, "/api/courses/algorithms", "student-token", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("restored course must be visible to its student again, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, trashReq(http.MethodPost, "/api/courses/algorithms/restore", "nsadmin-token", "*"))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("restore of a live course: expected 404, got %d", rec.Code)
+	}
+}
+
+func TestGetTrash(t *testing.T) {
+	resetTrashDB()
+	e := setupEchoTrash()
+
+	deletedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	admin, _ := findUserByUsername("admin")
+	if _, err := DeleteCourse(admin, "algorithms", 0, deletedAt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DeleteCourse(admin, "hidden", 0, deletedAt.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Курс из чужого namespace видит только инстанс-админ
+	courseMu.Lock()
+	hidden := trashDB["hidden"]
+	hidden.NamespaceID = "ns-03"
+	trashDB["hidden"] = hidden
+	courseMu.Unlock()
+
+	cases := []struct {
+		token string
+		want  int
+		ids   string
+	}{
+		{"admin-token", http.StatusOK, "hidden,algorithms"},
+		{"nsadmin-token", http.StatusOK, "algorithms"},
+		{"pm-token", http.StatusForbidden, ""},
+		{"student-token", http.StatusForbidden, ""},
+	}
+
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, authReq(http.MethodGet, "/api/trash/courses", tc.token, nil))
+		if rec.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d", tc.token, tc.want, rec.Code)
+		}
+		if tc.want != http.StatusOK {
+			continue
+		}
+
+		var trash []TrashedCourse
+		json.Unmarshal(rec.Body.Bytes(), &trash)
+		courses := make([]Course, 0, len(trash))
+		for _, item := range trash {
+			courses = append(courses, item.Course)
+		}
+		if courseIDs(courses) != tc.ids {
+			t.Errorf("%s: expected %s, got %s", tc.token, tc.ids, courseIDs(courses))
+		}
+		if last := trash[len(trash)-1]; !last.PurgeAt.Equal(deletedAt.Add(30 * 24 * time.Hour)) {
+			t.Errorf("%s: unexpected purgeAt %s", tc.token, last.PurgeAt)
+		}
+	}
+}
+
+func TestPurgeDeletedCourses(t *testing.T) {
+	resetTrashDB()
+
+	deletedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	admin, _ := findUserByUsername("admin")
+	if _, err := DeleteCourse(admin, "algorithms", 0, deletedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	if purged := PurgeDeletedCourses(deletedAt.Add(29 * 24 * time.Hour)); len(purged) != 0 {
+		t.Fatalf("course purged before retention ended: %v", purged)
+	}
+
+	purged := PurgeDeletedCourses(deletedAt.Add(30 * 24 * time.Hour))
+	if len(purged) != 1 || purged[0] != "algorithms" {
+		t.Fatalf("expected algorithms to be purged, got %v", purged)
+	}
+
+	courseMu.RLock()
+	_, inTrash := trashDB["algorithms"]
+	courseMu.RUnlock()
+	enrollmentMu.RLock()
+	_, enrolled := enrollmentDB["algorithms"]
+	_, otherEnrolled := enrollmentDB["hidden"]
+	enrollmentMu.RUnlock()
+	boardMu.RLock()
+	_, hasBoard := boardData["algorithms"]
+	boardMu.RUnlock()
+	inviteMu.RLock()
+	_, courseInvite := inviteDB["course-invite"]
+	_, nsInvite := inviteDB["ns-invite"]
+	inviteMu.RUnlock()
+
+	if inTrash || enrolled || hasBoard || courseInvite || len(submissionDB) != 0 {
+		t.Errorf("purged course data left behind: trash=%v enrollments=%v board=%v invite=%v submissions=%d",
+			inTrash, enrolled, hasBoard, courseInvite, len(submissionDB))
+	}
+	if !otherEnrolled || !nsInvite {
+		t.Error("data of other courses must be kept")
+	}
+	if last := auditLog[len(auditLog)-1]; last.Action != "course.purge" || last.Actor != purgeActor.Username {
+		t.Errorf("unexpected audit entry: %+v", last)
+	}
+
+	// После очистки slug снова свободен
+	if _, err := CreateCourse(admin, PostCourseRequest{Name: "Algorithms", Slug: "algorithms", NamespaceID: "ns-01", Status: "created", StartDate: "2025-09-01", EndDate: "2025-12-20", RepoTemplate: "git@a:repo.git"}); err != nil {
+		t.Fatalf("slug must be free after purge: %v", err)
+	}
+}
+
+func TestRunTrashPurge_ReportsEveryRun(t *testing.T) {
+	resetTrashDB()
+
+	run := func(db *sql.DB) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		results := make(chan error, 1)
+		go RunTrashPurge(ctx, db, time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)), func(err error) {
+			select {
+			case results <- err:
+			default:
+			}
+		})
+
+		select {
+		case err := <-results:
+			return err
+		case <-time.After(time.Second):
+			t.Fatal("purge did not run")
+			return nil
+		}
+	}
+
+	// пустая корзина - тоже успешный запуск
+	if err := run(nil); err != nil {
+		t.Errorf("expected success, got %v", err)
+	}
+
+	db := openStateDB(t)
+	db.Close()
+	if err := run(db); err == nil {
+		t.Error("expected failure when the database is unavailable")
+	}
+}
+
+func TestRunTrashPurge_NonPositiveInterval(t *testing.T) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		RunTrashPurge(context.Background(), nil, 0, slog.New(slog.NewTextHandler(io.Discard, nil)), func(error) {
+			t.Error("purge must not run")
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("purge with a zero interval must return")
+	}
+}
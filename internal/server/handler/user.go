@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+
+	"fcstask-backend/internal/logging"
+)
+
+// Роли пользователей
+const (
+	RoleStudent        = "student"
+	RoleProgramManager = "program_manager"
+	RoleNamespaceAdmin = "namespace_admin"
+	RoleInstanceAdmin  = "instance_admin"
+)
+
+// User - пользователь инстанса
+type User struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	RmsID    string `json:"rmsId"`
+	Role     string `json:"role"` // Роль на уровне инстанса: student или instance_admin
+	Email    string `json:"email,omitempty"`
+	Telegram string `json:"telegram,omitempty"`
+	Group    string `json:"group,omitempty"`
+	Token    string `json:"-"`
+
+	// Хеш токена для пользователей, загруженных из базы: сам токен там не хранится
+	TokenHash string `json:"-"`
+
+	EmailVerified bool `json:"emailVerified"`
+
+	// Nonce последней ссылки подтверждения почты, пусто - ссылок нет
+	VerificationNonce string `json:"-"`
+}
+
+// PostUserRequest - заведение пользователя администратором, без инвайта
+type PostUserRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	RmsID    string `json:"rmsId"`
+	Role     string `json:"role"`
+}
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9._-]{2,64}$`)
+
+// In-memory storage
+var (
+	userDB = map[string]User{}
+
+	userMu sync.RWMutex
+)
+
+// randomToken возвращает случайную hex-строку из n байт
+func randomToken(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// currentUser возвращает пользователя по заголовку Authorization: Bearer <token>
+func currentUser(c echo.Context) (User, bool) {
+	token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+	if !ok || token == "" {
+		return User{}, false
+	}
+
+	hash := hashToken(token)
+
+	userMu.RLock()
+	defer userMu.RUnlock()
+
+	for _, user := range userDB {
+		if user.Token == token || user.TokenHash == hash {
+			logging.SetUser(c.Request().Context(), user.Username)
+			return user, true
+		}
+	}
+	return User{}, false
+}
+
+func findUserByEmail(email string) (User, bool) {
+	userMu.RLock()
+	defer userMu.RUnlock()
+
+	for _, user := range userDB {
+		if user.Email != "" && strings.EqualFold(user.Email, email) {
+			return user, true
+		}
+	}
+	return User{}, false
+}
+
+func findUserByUsername(username string) (User, bool) {
+	userMu.RLock()
+	defer userMu.RUnlock()
+
+	for _, user := range userDB {
+		if user.Username == username {
+			return user, true
+		}
+	}
+	return User{}, false
+}
+
+// nextUserID подбирает свободный идентификатор, вызывается под userMu
+func nextUserID() string {
+	for n := len(userDB) + 1; ; n++ {
+		id := fmt.Sprintf("u-%d", n)
+		if _, taken := userDB[id]; !taken {
+			return id
+		}
+	}
+}
+
+func isValidInstanceRole(role string) bool {
+	return role == RoleStudent || role == RoleInstanceAdmin
+}
+
+func (req *PostUserRequest) Validate() []ValidationError {
+	var errs []ValidationError
+
+	if req.Username == "" {
+		errs = append(errs, ValidationError{Field: "username", Message: "username is required"})
+	} else if !usernamePattern.MatchString(req.Username) {
+		errs = append(errs, ValidationError{Field: "username", Message: "username may contain only lowercase letters, digits, dots, dashes and underscores"})
+	}
+
+	if req.Email != "" {
+		if addr, err := mail.ParseAddress(req.Email); err != nil || addr.Address != req.Email {
+			errs = append(errs, ValidationError{Field: "email", Message: "invalid email address"})
+		}
+	}
+
+	if req.Role != "" && !isValidInstanceRole(req.Role) {
+		errs = append(errs, ValidationError{Field: "role", Message: "invalid role value"})
+	}
+
+	return errs
+}
+
+// LookupUser ищет пользователя по логину
+func LookupUser(username string) (User, error) {
+	user, exists := findUserByUsername(username)
+	if !exists {
+		return User{}, newAPIError(http.StatusNotFound, "user not found")
+	}
+	return user, nil
+}
+
+// CreateUser заводит пользователя с новым токеном, доступно только инстанс-админу.
+// Почта считается подтвержденной: пользователя завел администратор
+func CreateUser(actor User, req PostUserRequest) (User, error) {
+	if actor.Role != RoleInstanceAdmin {
+		return User{}, errForbidden
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return User{}, validationFailed(errs)
+	}
+
+	role := req.Role
+	if role == "" {
+		role = RoleStudent
+	}
+
+	userMu.Lock()
+	defer userMu.Unlock()
+
+	for _, user := range userDB {
+		if user.Username == req.Username {
+			return User{}, newAPIError(http.StatusConflict, "user with this username already exists")
+		}
+		if req.Email != "" && strings.EqualFold(user.Email, req.Email) {
+			return User{}, newAPIError(http.StatusConflict, "user with this email already exists")
+		}
+	}
+
+	user := User{
+		ID:       nextUserID(),
+		Username: req.Username,
+		RmsID:    req.RmsID,
+		Role:     role,
+		Email:    req.Email,
+		Token:    randomToken(24),
+
+		EmailVerified: true,
+	}
+	userDB[user.ID] = user
+
+	recordAudit(actor, "user.create", user.ID, map[string]string{"username": user.Username, "role": role})
+
+	return user, nil
+}
+
+// SetUserRole меняет роль пользователя на уровне инстанса. Последнего
+// инстанс-админа понизить нельзя, иначе управлять инстансом будет некому
+func SetUserRole(actor User, userID, role string) (User, error) {
+	if actor.Role != RoleInstanceAdmin {
+		return User{}, errForbidden
+	}
+
+	if !isValidInstanceRole(role) {
+		return User{}, validationFailed([]ValidationError{{Field: "role", Message: "invalid role value"}})
+	}
+
+	userMu.Lock()
+	defer userMu.Unlock()
+
+	user, exists := userDB[userID]
+	if !exists {
+		return User{}, newAPIError(http.StatusNotFound, "user not found")
+	}
+
+	if user.Role == RoleInstanceAdmin && role != RoleInstanceAdmin {
+		admins := 0
+		for _, other := range userDB {
+			if other.Role == RoleInstanceAdmin {
+				admins++
+			}
+		}
+		if admins == 1 {
+			return User{}, newAPIError(http.StatusConflict, "cannot demote the last instance admin")
+		}
+	}
+
+	from := user.Role
+	user.Role = role
+	userDB[userID] = user
+
+	recordAudit(actor, "user.role", userID, map[string]string{"from": from, "to": role})
+
+	return user, nil
+}
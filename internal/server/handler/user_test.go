@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func apiStatus(err error) int {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr.Status
+	}
+	return 0
+}
+
+func TestCreateUser(t *testing.T) {
+	resetNamespaceDB()
+	admin, _ := findUserByUsername("admin")
+	student, _ := findUserByUsername("student")
+
+	if _, err := CreateUser(student, PostUserRequest{Username: "bob"}); apiStatus(err) != http.StatusForbidden {
+		t.Fatalf("expected 403 for non-admin, got %v", err)
+	}
+
+	user, err := CreateUser(admin, PostUserRequest{Username: "bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.ID != "u-6" || user.Role != RoleStudent || user.Token == "" || !user.EmailVerified {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	if _, err := CreateUser(admin, PostUserRequest{Username: "bob"}); apiStatus(err) != http.StatusConflict {
+		t.Fatalf("expected 409 for duplicate username, got %v", err)
+	}
+	if _, err := CreateUser(admin, PostUserRequest{Username: "bobby", Email: "BOB@example.com"}); apiStatus(err) != http.StatusConflict {
+		t.Fatalf("expected 409 for duplicate email, got %v", err)
+	}
+
+	_, err = CreateUser(admin, PostUserRequest{Username: "Bad Name", Email: "nope", Role: RoleNamespaceAdmin})
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) || len(apiErr.Details) != 3 {
+		t.Fatalf("expected three field errors, got %v", err)
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	resetNamespaceDB()
+	admin, _ := findUserByUsername("admin")
+
+	if _, err := SetUserRole(admin, "u-1", RoleStudent); apiStatus(err) != http.StatusConflict {
+		t.Fatalf("last instance admin must not be demoted, got %v", err)
+	}
+
+	user, err := SetUserRole(admin, "u-4", RoleInstanceAdmin)
+	if err != nil || user.Role != RoleInstanceAdmin {
+		t.Fatalf("unexpected result: %+v, %v", user, err)
+	}
+
+	if _, err := SetUserRole(admin, "u-1", RoleStudent); err != nil {
+		t.Fatalf("demotion with another admin left: %v", err)
+	}
+	if _, err := SetUserRole(admin, "u-404", RoleStudent); apiStatus(err) != http.StatusNotFound {
+		t.Fatalf("expected 404, got %v", err)
+	}
+	if _, err := SetUserRole(admin, "u-4", RoleNamespaceAdmin); apiStatus(err) != http.StatusBadRequest {
+		t.Fatalf("namespace roles are not instance roles, got %v", err)
+	}
+}
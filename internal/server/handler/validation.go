@@ -0,0 +1,298 @@
+package handler
+
+import (
+	"errors"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Коды ошибок валидации. Код и params не меняются, по ним клиент подбирает
+// сообщение на своем языке; message - готовое сообщение на языке из Accept-Language
+const (
+	CodeRequired      = "required"
+	CodeTooLong       = "too_long"
+	CodeInvalidValue  = "invalid_value"
+	CodeInvalidType   = "invalid_type"
+	CodeNegative      = "negative"
+	CodeOutOfRange    = "out_of_range"
+	CodeDuplicate     = "duplicate"
+	CodeDateFormat    = "date_format"
+	CodeDateRange     = "date_range"
+	CodeTimeFormat    = "time_format"
+	CodeTimeSkipped   = "time_skipped"
+	CodeTimezone      = "timezone"
+	CodeSlugFormat    = "slug_format"
+	CodeSlugReserved  = "slug_reserved"
+	CodeSlugImmutable = "slug_immutable"
+	CodeRepoURL       = "repo_url"
+	CodeUnknownUser   = "unknown_user"
+)
+
+// Ограничения длины текстовых полей курса, в символах
+const (
+	maxCourseNameLength        = 200
+	maxCourseDescriptionLength = 10000
+	maxRepoTemplateLength      = 512
+)
+
+const defaultLanguage = "en"
+
+// Сообщения по кодам. {field} - имя поля без пути, остальное - из params
+var validationMessages = map[string]map[string]string{
+	"en": {
+		CodeRequired:      "{field} is required",
+		CodeTooLong:       "{field} must be at most {max} characters",
+		CodeInvalidValue:  "invalid {field} value: {value}, expected one of {allowed}",
+		CodeInvalidType:   "{field} must be of type {type}",
+		CodeNegative:      "{field} must not be negative",
+		CodeOutOfRange:    "{field} must be in {range}",
+		CodeDuplicate:     "duplicate {field} {value}",
+		CodeDateFormat:    "{field} must be in format YYYY-MM-DD",
+		CodeDateRange:     "endDate must be after startDate",
+		CodeTimeFormat:    "{field} must be RFC 3339 time, local time YYYY-MM-DDTHH:MM or date YYYY-MM-DD",
+		CodeTimeSkipped:   "{value} does not exist in {timezone} because of the clock change",
+		CodeTimezone:      "{field} must be an IANA time zone name, e.g. Europe/Moscow",
+		CodeSlugFormat:    "{field} may contain only lowercase latin letters, digits and single hyphens between them",
+		CodeSlugReserved:  "{field} {value} is reserved",
+		CodeSlugImmutable: "slug is changed with POST /api/courses/:courseId/rename",
+		CodeRepoURL:       "{field} must be an ssh (git@host:group/repo.git, ssh://host/group/repo.git) or https repository URL",
+		CodeUnknownUser:   "unknown user: {value}",
+	},
+	"ru": {
+		CodeRequired:      "поле {field} обязательно",
+		CodeTooLong:       "поле {field} должно быть не длиннее {max} символов",
+		CodeInvalidValue:  "недопустимое значение поля {field}: {value}, допустимо: {allowed}",
+		CodeInvalidType:   "поле {field} должно иметь тип {type}",
+		CodeNegative:      "поле {field} не может быть отрицательным",
+		CodeOutOfRange:    "поле {field} должно быть в диапазоне {range}",
+		CodeDuplicate:     "повторяется {field} {value}",
+		CodeDateFormat:    "поле {field} должно быть датой в формате YYYY-MM-DD",
+		CodeDateRange:     "endDate должна быть позже startDate",
+		CodeTimeFormat:    "поле {field} должно быть временем RFC 3339, местным временем YYYY-MM-DDTHH:MM или датой YYYY-MM-DD",
+		CodeTimeSkipped:   "времени {value} в поясе {timezone} нет из-за перевода часов",
+		CodeTimezone:      "поле {field} должно быть именем часового пояса IANA, например Europe/Moscow",
+		CodeSlugFormat:    "поле {field} может содержать только строчные латинские буквы, цифры и одиночные дефисы между ними",
+		CodeSlugReserved:  "{field} {value} зарезервирован",
+		CodeSlugImmutable: "slug меняется через POST /api/courses/:courseId/rename",
+		CodeRepoURL:       "поле {field} должно быть ssh- (git@host:group/repo.git, ssh://host/group/repo.git) или https-адресом репозитория",
+		CodeUnknownUser:   "неизвестный пользователь: {value}",
+	},
+}
+
+// newValidationError - ошибка поля с кодом, сообщение - на языке по умолчанию
+func newValidationError(field, code string, params map[string]string) ValidationError {
+	e := ValidationError{Field: field, Code: code, Params: params}
+	e.Message = e.message(defaultLanguage)
+	return e
+}
+
+// message - сообщение об ошибке на языке lang. Ошибки без кода и
+// непереведенные коды остаются с исходным сообщением
+func (e ValidationError) message(lang string) string {
+	template, ok := validationMessages[lang][e.Code]
+	if !ok {
+		return e.Message
+	}
+
+	replacements := []string{"{field}", fieldName(e.Field)}
+	for key, value := range e.Params {
+		replacements = append(replacements, "{"+key+"}", value)
+	}
+	return strings.NewReplacer(replacements...).Replace(template)
+}
+
+// fieldName - имя поля без пути: groups[0].tasks[1].name -> name
+func fieldName(field string) string {
+	return field[strings.LastIndex(field, ".")+1:]
+}
+
+// prefixErrors добавляет путь к полям вложенного объекта
+func prefixErrors(prefix string, errs []ValidationError) []ValidationError {
+	prefixed := make([]ValidationError, 0, len(errs))
+	for _, e := range errs {
+		e.Field = prefix + e.Field
+		prefixed = append(prefixed, e)
+	}
+	return prefixed
+}
+
+// localizeErrors переводит сообщения ошибок на язык lang
+func localizeErrors(errs []ValidationError, lang string) []ValidationError {
+	localized := make([]ValidationError, 0, len(errs))
+	for _, e := range errs {
+		e.Message = e.message(lang)
+		localized = append(localized, e)
+	}
+	return localized
+}
+
+// preferredLanguage - язык сообщений по заголовку Accept-Language с учетом q,
+// если ни один из языков не поддерживается - английский
+func preferredLanguage(header string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := validationMessages[lang]; !ok {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{lang, q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return defaultLanguage
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
+
+// rule - правило для значения поля. Все правила, кроме required, пропускают пустое
+// значение: обязательность поля задается отдельно
+type rule struct {
+	code   string
+	params map[string]string
+	valid  func(value string) bool
+
+	// значение попадает в params["value"]
+	withValue bool
+}
+
+var (
+	required = rule{code: CodeRequired, valid: func(value string) bool { return strings.TrimSpace(value) != "" }}
+
+	isDate     = rule{code: CodeDateFormat, valid: isValidDate}
+	isTimezone = rule{code: CodeTimezone, valid: func(value string) bool {
+		_, err := loadTimezone(value)
+		return err == nil
+	}}
+	isRepoURL   = rule{code: CodeRepoURL, valid: isValidRepoURL}
+	isKnownUser = rule{code: CodeUnknownUser, withValue: true, valid: func(value string) bool {
+		_, exists := findUserByUsername(value)
+		return exists
+	}}
+)
+
+func maxLength(n int) rule {
+	return rule{
+		code:   CodeTooLong,
+		params: map[string]string{"max": strconv.Itoa(n)},
+		valid:  func(value string) bool { return utf8.RuneCountInString(value) <= n },
+	}
+}
+
+func oneOf(values []string) rule {
+	return rule{
+		code:      CodeInvalidValue,
+		params:    map[string]string{"allowed": strings.Join(values, ", ")},
+		withValue: true,
+		valid:     func(value string) bool { return slices.Contains(values, value) },
+	}
+}
+
+// validator собирает ошибки всех полей, а не останавливается на первой
+type validator struct {
+	errs []ValidationError
+}
+
+// check проверяет значение правилами по порядку и записывает первую ошибку поля.
+// Возвращает, прошло ли поле проверку
+func (v *validator) check(field, value string, rules ...rule) bool {
+	for _, r := range rules {
+		if value == "" && r.code != CodeRequired {
+			continue
+		}
+		if r.valid(value) {
+			continue
+		}
+
+		params := r.params
+		if r.withValue {
+			params = maps.Clone(params)
+			if params == nil {
+				params = map[string]string{}
+			}
+			params["value"] = value
+		}
+		v.add(field, r.code, params)
+		return false
+	}
+	return true
+}
+
+func (v *validator) add(field, code string, params map[string]string) {
+	v.errs = append(v.errs, newValidationError(field, code, params))
+}
+
+// checkTime разбирает время, которое вводит преподаватель (см. parseCourseTime)
+func (v *validator) checkTime(field, value string, loc *time.Location, endOfDay bool) (time.Time, bool) {
+	t, err := parseCourseTime(value, loc, endOfDay)
+	switch {
+	case err == nil:
+		return t, true
+	case errors.Is(err, errTimeSkipped):
+		v.add(field, CodeTimeSkipped, map[string]string{"value": value, "timezone": loc.String()})
+	default:
+		v.add(field, CodeTimeFormat, nil)
+	}
+	return time.Time{}, false
+}
+
+// checkUnique проверяет, что ID не повторяется среди seen
+func (v *validator) checkUnique(field, id string, seen map[string]bool) {
+	if seen[id] {
+		v.add(field, CodeDuplicate, map[string]string{"value": id})
+	}
+	seen[id] = true
+}
+
+// git@gitlab.local:group/repo.git - короткая ssh-форма, как ее показывает GitLab
+var scpRepoPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:[^/\s]\S*$`)
+
+// isValidRepoURL - адрес репозитория: git@host:path, ssh://[user@]host[:port]/path
+// или https://host/path без пароля в адресе
+func isValidRepoURL(value string) bool {
+	if scpRepoPattern.MatchString(value) {
+		return true
+	}
+	if strings.ContainsAny(value, " \t\r\n") {
+		return false
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || u.Hostname() == "" || strings.Trim(u.Path, "/") == "" || u.RawQuery != "" || u.Fragment != "" {
+		return false
+	}
+
+	switch u.Scheme {
+	case "ssh":
+		return true
+	case "https":
+		_, hasPassword := u.User.Password()
+		return !hasPassword
+	default:
+		return false
+	}
+}
@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"fcstask-backend/internal/logging"
+	"fcstask-backend/internal/mailer"
+)
+
+// EmailVerification - настройки подтверждения почты
+type EmailVerification struct {
+	Mailer  mailer.Mailer
+	Secret  []byte
+	LinkURL string // адрес GET /api/signup/verify, к нему добавляется ?token=
+	TTL     time.Duration
+}
+
+// nil - подтверждение выключено, новые пользователи сразу считаются подтвержденными
+var emailVerification *EmailVerification
+
+var (
+	errInvalidVerificationToken = newAPIError(http.StatusBadRequest, "invalid or expired verification token")
+	errEmailNotVerified         = newAPIError(http.StatusForbidden, "email is not verified")
+)
+
+// SetEmailVerification включает подтверждение почты при регистрации
+func SetEmailVerification(v *EmailVerification) {
+	emailVerification = v
+}
+
+func signVerification(payload string) string {
+	mac := hmac.New(sha256.New, emailVerification.Secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// issueVerificationToken выпускает токен вида payload.signature,
+// payload = userID:nonce:expiresAt. Nonce хранится у пользователя, поэтому
+// ссылка переживает рестарт; новый токен отменяет предыдущий
+func issueVerificationToken(userID string, now time.Time) string {
+	nonce := randomToken(12)
+
+	userMu.Lock()
+	if user, exists := userDB[userID]; exists {
+		user.VerificationNonce = nonce
+		userDB[userID] = user
+	}
+	userMu.Unlock()
+
+	payload := fmt.Sprintf("%s:%s:%d", userID, nonce, now.Add(emailVerification.TTL).Unix())
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+
+	return encoded + "." + signVerification(encoded)
+}
+
+// consumeVerificationToken проверяет подпись и срок и гасит токен
+func consumeVerificationToken(token string, now time.Time) (string, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signVerification(encoded))) {
+		return "", errInvalidVerificationToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errInvalidVerificationToken
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 {
+		return "", errInvalidVerificationToken
+	}
+	userID, nonce := parts[0], parts[1]
+
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || now.Unix() >= expiresAt {
+		return "", errInvalidVerificationToken
+	}
+
+	userMu.Lock()
+	defer userMu.Unlock()
+
+	user, exists := userDB[userID]
+	if !exists || user.VerificationNonce == "" || user.VerificationNonce != nonce {
+		return "", errInvalidVerificationToken
+	}
+	user.VerificationNonce = ""
+	userDB[userID] = user
+
+	return userID, nil
+}
+
+// sendVerification выпускает ссылку подтверждения и отправляет ее пользователю,
+// когда изменение записано в базу. Ошибка отправки только логируется:
+// ссылку можно запросить повторно
+func sendVerification(ctx context.Context, user User) {
+	v := emailVerification
+	token := issueVerificationToken(user.ID, time.Now())
+
+	afterStateSaved(ctx, func(ctx context.Context) {
+		err := v.Mailer.Send(ctx, mailer.Message{
+			To:      user.Email,
+			Subject: "Подтверждение почты FCSTask",
+			Body: fmt.Sprintf(
+				"Здравствуйте, %s!\n\nЧтобы подтвердить почту, перейдите по ссылке:\n%s?token=%s\n\nСсылка действует %s.\n",
+				user.Username, v.LinkURL, token, v.TTL,
+			),
+		})
+		if err != nil {
+			logging.FromContext(ctx).Warn("failed to send verification email", "user_id", user.ID, "error", err)
+		}
+	})
+}
+
+// verifiedUser - как currentUser, но еще требует подтвержденную почту.
+// Неподтвержденные пользователи могут только читать
+func verifiedUser(c echo.Context) (User, error) {
+	user, ok := currentUser(c)
+	if !ok {
+		return User{}, errUnauthorized
+	}
+	if !user.EmailVerified {
+		return User{}, errEmailNotVerified
+	}
+	return user, nil
+}
+
+// VerifyEmail подтверждает почту по токену и запускает отложенный провижининг
+func VerifyEmail(ctx context.Context, token string) (User, error) {
+	if emailVerification == nil {
+		return User{}, errInvalidVerificationToken
+	}
+
+	userID, err := consumeVerificationToken(token, time.Now())
+	if err != nil {
+		return User{}, err
+	}
+
+	userMu.Lock()
+	user, exists := userDB[userID]
+	if exists {
+		user.EmailVerified = true
+		userDB[userID] = user
+	}
+	userMu.Unlock()
+
+	if !exists {
+		return User{}, errInvalidVerificationToken
+	}
+
+	activatePendingEnrollments(user.ID)
+	startPendingProvisioning(ctx, user)
+
+	return user, nil
+}
+
+// ResendVerification повторно отправляет ссылку. Доступно тем, кто может
+// управлять студентами в namespace пользователя, и владельцам его курсов
+func ResendVerification(ctx context.Context, actor User, userID string) error {
+	if emailVerification == nil {
+		return newAPIError(http.StatusConflict, "email verification is disabled")
+	}
+
+	userMu.RLock()
+	user, exists := userDB[userID]
+	userMu.RUnlock()
+
+	if !exists {
+		return newAPIError(http.StatusNotFound, "user not found")
+	}
+
+	if !canManageStudent(actor, userID) {
+		return errForbidden
+	}
+
+	if user.EmailVerified {
+		return newAPIError(http.StatusConflict, "email is already verified")
+	}
+
+	sendVerification(ctx, user)
+	return nil
+}
+
+// canManageStudent - может ли actor управлять учебными делами пользователя
+func canManageStudent(actor User, userID string) bool {
+	if actor.Role == RoleInstanceAdmin {
+		return true
+	}
+
+	namespaceMu.RLock()
+	var namespaces []string
+	for namespaceID, members := range namespaceMembers {
+		if _, member := members[userID]; member {
+			namespaces = append(namespaces, namespaceID)
+		}
+	}
+	namespaceMu.RUnlock()
+
+	for _, namespaceID := range namespaces {
+		if canAssignNamespaceRole(actor, namespaceID, RoleStudent) {
+			return true
+		}
+	}
+
+	enrollmentMu.RLock()
+	var courses []string
+	for courseID, enrollments := range enrollmentDB {
+		if _, enrolled := enrollments[userID]; enrolled {
+			courses = append(courses, courseID)
+		}
+	}
+	enrollmentMu.RUnlock()
+
+	courseMu.RLock()
+	defer courseMu.RUnlock()
+
+	for _, courseID := range courses {
+		if isCourseOwner(courseDB[courseID], actor) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCourseOwner(course Course, actor User) bool {
+	return slices.Contains(course.Owners, actor.Username)
+}
+
+// Хендлеры
+
+// GET /api/signup/verify?token=...
+func VerifyEmailHandler(c echo.Context) error {
+	user, err := VerifyEmail(c.Request().Context(), c.QueryParam("token"))
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"status": "verified", "userId": user.ID})
+}
+
+// POST /api/users/:userId/verification
+func ResendVerificationHandler(c echo.Context) error {
+	actor, err := verifiedUser(c)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	if err := ResendVerification(c.Request().Context(), actor, c.Param("userId")); err != nil {
+		return writeError(c, err)
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
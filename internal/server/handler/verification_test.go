@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"fcstask-backend/internal/mailer"
+	"fcstask-backend/internal/provision"
+)
+
+var tokenInMail = regexp.MustCompile(`token=(\S+)`)
+
+func setupEchoVerification() *echo.Echo {
+	e := setupEchoSignup()
+	api := e.Group("/api")
+
+	api.GET("/signup/verify", VerifyEmailHandler)
+	api.POST("/users/:userId/verification", ResendVerificationHandler)
+	api.POST("/invites", CreateInviteHandler)
+
+	return e
+}
+
+// enableVerification включает подтверждение почты с письмами в каталог теста
+func enableVerification(t *testing.T) *mailer.Outbox {
+	t.Helper()
+
+	outbox, err := mailer.NewOutbox(t.TempDir(), "noreply@fcstask.local")
+	if err != nil {
+		t.Fatalf("outbox: %v", err)
+	}
+
+	SetEmailVerification(&EmailVerification{
+		Mailer:  outbox,
+		Secret:  []byte("test-secret"),
+		LinkURL: "http://localhost/api/signup/verify",
+		TTL:     time.Hour,
+	})
+	t.Cleanup(func() { SetEmailVerification(nil) })
+
+	return outbox
+}
+
+func lastMailToken(t *testing.T, outbox *mailer.Outbox) string {
+	t.Helper()
+
+	files, _ := outbox.Messages()
+	if len(files) == 0 {
+		t.Fatal("no mail sent")
+	}
+
+	data, _ := os.ReadFile(files[len(files)-1])
+	match := tokenInMail.FindSubmatch(data)
+	if match == nil {
+		t.Fatalf("no token in mail:\n%s", data)
+	}
+	return string(match[1])
+}
+
+func signupNewStudent(t *testing.T, e *echo.Echo) SignupResponse {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodPost, "/api/signup", []byte(`{"inviteCode":"course-code","email":"new@example.com"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("signup failed: %d %s", rec.Code, rec.Body.String())
+	}
+
+	var resp SignupResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	return resp
+}
+
+func TestVerification_SignupDefersProvisioning(t *testing.T) {
+	prov := &stubProvisioner{status: provision.Status{State: provision.StatePending}}
+	resetSignupDB(prov)
+	outbox := enableVerification(t)
+	e := setupEchoVerification()
+
+	resp := signupNewStudent(t, e)
+	if resp.EmailVerified || resp.Status != SignupAwaitingVerification {
+		t.Fatalf("unexpected signup response: %+v", resp)
+	}
+	if len(prov.jobs) != 0 {
+		t.Fatal("repository must not be created before verification")
+	}
+	if state := enrollmentState("algorithms", resp.UserID); state != EnrollmentPending {
+		t.Fatalf("expected pending enrollment, got %q", state)
+	}
+
+	// неподтвержденный пользователь ограничен чтением
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/invites", resp.Token, inviteBody(`"courseId":"algorithms"`, RoleStudent, 1)))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for unverified user, got %d", rec.Code)
+	}
+
+	token := lastMailToken(t, outbox)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodGet, "/api/signup/verify?token="+token, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	userMu.RLock()
+	verified := userDB[resp.UserID].EmailVerified
+	userMu.RUnlock()
+	if !verified {
+		t.Fatal("user should be verified")
+	}
+	if state := enrollmentState("algorithms", resp.UserID); state != EnrollmentActive {
+		t.Fatalf("expected active enrollment after verification, got %q", state)
+	}
+	if len(prov.jobs) != 1 {
+		t.Fatalf("provisioning should start after verification, got %d jobs", len(prov.jobs))
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodGet, "/api/signup/status?signupId="+resp.SignupID, nil))
+	var status SignupStatus
+	json.Unmarshal(rec.Body.Bytes(), &status)
+	if status.Status != SignupProvisioning {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+
+	// ссылка одноразовая
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodGet, "/api/signup/verify?token="+token, nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 on reuse, got %d", rec.Code)
+	}
+}
+
+func TestVerification_LinkSurvivesRestart(t *testing.T) {
+	db := openStateDB(t)
+	ctx := context.Background()
+	resetStateDB()
+	prov := &stubProvisioner{status: provision.Status{State: provision.StatePending}}
+	resetSignupDB(prov)
+	outbox := enableVerification(t)
+	e := setupEchoVerification()
+
+	resp := signupNewStudent(t, e)
+	token := lastMailToken(t, outbox)
+	if err := SaveState(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	// рестарт: память теряется, остается база
+	userMu.Lock()
+	userDB = map[string]User{}
+	userMu.Unlock()
+	signupMu.Lock()
+	signupDB = map[string]Signup{}
+	signupMu.Unlock()
+	if _, err := LoadState(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, plainReq(http.MethodGet, "/api/signup/verify?token="+token, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 after restart, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(prov.jobs) != 1 {
+		t.Fatalf("pending provisioning must survive the restart, got %d jobs", len(prov.jobs))
+	}
+
+	signupMu.RLock()
+	signup := signupDB[resp.SignupID]
+	signupMu.RUnlock()
+	if signup.AwaitingVerification || signup.JobID == "" {
+		t.Errorf("signup not updated after verification: %+v", signup)
+	}
+}
+
+func TestVerification_RejectsBadTokens(t *testing.T) {
+	resetSignupDB(nil)
+	enableVerification(t)
+
+	token := issueVerificationToken("u-4", time.Now())
+
+	if _, err := consumeVerificationToken(token+"x", time.Now()); err == nil {
+		t.Error("tampered signature must be rejected")
+	}
+	if _, err := consumeVerificationToken("garbage", time.Now()); err == nil {
+		t.Error("garbage must be rejected")
+	}
+	if _, err := consumeVerificationToken(token, time.Now().Add(2*time.Hour)); err == nil {
+		t.Error("expired token must be rejected")
+	}
+	if userID, err := consumeVerificationToken(token, time.Now()); err != nil || userID != "u-4" {
+		t.Errorf("valid token rejected: %v", err)
+	}
+}
+
+func TestVerification_Resend(t *testing.T) {
+	resetSignupDB(nil)
+	outbox := enableVerification(t)
+	e := setupEchoVerification()
+
+	resp := signupNewStudent(t, e)
+	firstToken := lastMailToken(t, outbox)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/users/"+resp.UserID+"/verification", "outsider-token", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for outsider, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/users/"+resp.UserID+"/verification", "pm-token", nil))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	files, _ := outbox.Messages()
+	if len(files) != 2 {
+		t.Fatalf("expected a second mail, got %d", len(files))
+	}
+
+	// старая ссылка перестает работать после повторной отправки
+	if _, err := VerifyEmail(context.Background(), firstToken); err == nil {
+		t.Fatal("previous link must be invalidated")
+	}
+	if _, err := VerifyEmail(context.Background(), lastMailToken(t, outbox)); err != nil {
+		t.Fatalf("new link rejected: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, authReq(http.MethodPost, "/api/users/"+resp.UserID+"/verification", "pm-token", nil))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for verified user, got %d", rec.Code)
+	}
+}
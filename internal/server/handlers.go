@@ -5,8 +5,7 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+// PostV1Echo - POST /v1/echo: возвращает тело запроса как есть
 func (s *Server) PostV1Echo(ctx echo.Context) error {
 	return handler.Echo(ctx)
 }
-
-// тут добавятся ручки....
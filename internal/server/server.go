@@ -3,32 +3,129 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
-type IServer interface {
-	Start(ctx context.Context) error
-	Shutdown(ctx context.Context) error
+// Options - таймауты и лимиты HTTP-сервера, нулевые значения не ограничивают
+type Options struct {
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	IdleTimeout    time.Duration
+	MaxHeaderBytes int
 }
 
+// Server - HTTP-сервер вместе с фоновыми обработчиками (очереди, планировщики).
+// При остановке сначала дожидается текущих запросов, потом останавливает обработчики
 type Server struct {
 	httpServer *http.Server
+
+	mu       sync.Mutex
+	seq      uint64
+	inFlight map[uint64]string // id -> "METHOD /path"
+	workers  []*worker
+}
+
+type worker struct {
+	name   string
+	run    func(ctx context.Context)
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// ShutdownError - остановка не уложилась в таймаут; перечисляет, что было прервано
+type ShutdownError struct {
+	Requests []string
+	Workers  []string
+}
+
+func (e *ShutdownError) Error() string {
+	var parts []string
+	if len(e.Requests) > 0 {
+		parts = append(parts, fmt.Sprintf("%d requests cut off (%s)", len(e.Requests), strings.Join(e.Requests, ", ")))
+	}
+	if len(e.Workers) > 0 {
+		parts = append(parts, fmt.Sprintf("workers not stopped (%s)", strings.Join(e.Workers, ", ")))
+	}
+	return "shutdown timed out: " + strings.Join(parts, "; ")
 }
 
-func NewServer(addr string, handler http.Handler) *Server {
-	return &Server{
-		httpServer: &http.Server{
-			Addr:    addr,
-			Handler: handler,
-		},
+func NewServer(addr string, handler http.Handler, opts Options) *Server {
+	s := &Server{inFlight: make(map[uint64]string)}
+
+	s.httpServer = &http.Server{
+		Addr:           addr,
+		Handler:        s.track(handler),
+		ReadTimeout:    opts.ReadTimeout,
+		WriteTimeout:   opts.WriteTimeout,
+		IdleTimeout:    opts.IdleTimeout,
+		MaxHeaderBytes: opts.MaxHeaderBytes,
 	}
+
+	return s
+}
+
+// AddWorker регистрирует фоновый обработчик. run должен вернуться после отмены ctx.
+// Обработчики останавливаются в обратном порядке регистрации
+func (s *Server) AddWorker(name string, run func(ctx context.Context)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.workers = append(s.workers, &worker{name: name, run: run, done: make(chan struct{})})
+}
+
+// track учитывает запросы в обработке, чтобы при таймауте сообщить, какие были прерваны
+func (s *Server) track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.seq++
+		id := s.seq
+		s.inFlight[id] = r.Method + " " + r.URL.Path
+		s.mu.Unlock()
+
+		defer func() {
+			s.mu.Lock()
+			delete(s.inFlight, id)
+			s.mu.Unlock()
+		}()
+
+		next.ServeHTTP(w, r)
+	})
 }
 
+// Addr возвращает адрес, который слушает сервер
+func (s *Server) Addr() string {
+	return s.httpServer.Addr
+}
+
+// InFlight возвращает число запросов в обработке
+func (s *Server) InFlight() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.inFlight)
+}
+
+// Start запускает обработчики и принимает соединения до вызова Shutdown.
+// Отмена ctx не останавливает сервер: порядок остановки задает Shutdown
 func (s *Server) Start(ctx context.Context) error {
-	go func() {
-		<-ctx.Done()
-		_ = s.httpServer.Shutdown(context.Background())
-	}()
+	base := context.WithoutCancel(ctx)
+
+	s.mu.Lock()
+	for _, w := range s.workers {
+		var workerCtx context.Context
+		workerCtx, w.cancel = context.WithCancel(base)
+
+		go func() {
+			defer close(w.done)
+			w.run(workerCtx)
+		}()
+	}
+	s.mu.Unlock()
 
 	err := s.httpServer.ListenAndServe()
 	if errors.Is(err, http.ErrServerClosed) {
@@ -37,6 +134,43 @@ func (s *Server) Start(ctx context.Context) error {
 	return err
 }
 
+// Shutdown дожидается текущих запросов, затем останавливает обработчики.
+// Все укладывается в ctx; если не уложилось — возвращает *ShutdownError
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.httpServer.Shutdown(ctx)
+	httpErr := s.httpServer.Shutdown(ctx)
+
+	var cut ShutdownError
+	if httpErr != nil {
+		s.mu.Lock()
+		for _, request := range s.inFlight {
+			cut.Requests = append(cut.Requests, request)
+		}
+		s.mu.Unlock()
+		sort.Strings(cut.Requests)
+
+		_ = s.httpServer.Close()
+	}
+
+	s.mu.Lock()
+	workers := s.workers
+	s.mu.Unlock()
+
+	for i := len(workers) - 1; i >= 0; i-- {
+		w := workers[i]
+		if w.cancel == nil {
+			continue // сервер не запускался
+		}
+
+		w.cancel()
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			cut.Workers = append(cut.Workers, w.name)
+		}
+	}
+
+	if len(cut.Requests) > 0 || len(cut.Workers) > 0 {
+		return &cut
+	}
+	return httpErr
 }
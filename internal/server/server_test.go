@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func waitListening(t *testing.T, addr string) {
+	deadline := time.Now().Add(time.Second)
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server did not start: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestServer_ShutdownOrder(t *testing.T) {
+	addr := freeAddr(t)
+
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	record := func(event string) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}
+
+	release := make(chan struct{})
+	srv := NewServer(addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		record("request done")
+	}), Options{})
+
+	for _, name := range []string{"queue", "scheduler"} {
+		srv.AddWorker(name, func(ctx context.Context) {
+			<-ctx.Done()
+			record(name + " stopped")
+		})
+	}
+
+	go srv.Start(context.Background())
+	waitListening(t, addr)
+
+	go http.Get("http://" + addr + "/slow")
+	for srv.InFlight() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"request done", "scheduler stopped", "queue stopped"}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != len(want) {
+		t.Fatalf("unexpected events: %v", events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("unexpected shutdown order: %v", events)
+		}
+	}
+}
+
+func TestServer_ShutdownTimeoutReportsCutOff(t *testing.T) {
+	addr := freeAddr(t)
+
+	block := make(chan struct{})
+	defer close(block)
+
+	srv := NewServer(addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}), Options{})
+	srv.AddWorker("stuck", func(context.Context) { <-block })
+
+	go srv.Start(context.Background())
+	waitListening(t, addr)
+
+	go http.Get("http://" + addr + "/api/courses/algorithms/board")
+	for srv.InFlight() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := srv.Shutdown(ctx)
+
+	var cut *ShutdownError
+	if !errors.As(err, &cut) {
+		t.Fatalf("expected ShutdownError, got %v", err)
+	}
+	if len(cut.Requests) != 1 || cut.Requests[0] != "GET /api/courses/algorithms/board" {
+		t.Errorf("unexpected requests: %v", cut.Requests)
+	}
+	if len(cut.Workers) != 1 || cut.Workers[0] != "stuck" {
+		t.Errorf("unexpected workers: %v", cut.Workers)
+	}
+}
@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"fcstask-backend/internal/config"
+)
+
+// Tracer берется из глобального провайдера при каждом вызове, чтобы
+// подхватывать провайдер, установленный после инициализации пакета.
+// name - путь пакета, который создает спаны
+func Tracer(name string) trace.Tracer {
+	return otel.GetTracerProvider().Tracer(name)
+}
+
+// Setup настраивает глобальный TracerProvider и W3C traceparent-пропагацию.
+// Возвращает функцию, которая досылает накопленные спаны при остановке
+func Setup(ctx context.Context, cfg config.TracingConfig, stdout io.Writer) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch cfg.Exporter {
+	case "", "none":
+		// Провайдер по умолчанию - noop, спаны никуда не уходят
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(stdout))
+	case "otlp":
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := NewProvider(exporter, cfg.ServiceName, cfg.SampleRatio)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// NewProvider собирает TracerProvider с батчевой отправкой; в тестах
+// сюда передается tracetest.InMemoryExporter
+func NewProvider(exporter sdktrace.SpanExporter, serviceName string, sampleRatio float64) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+}
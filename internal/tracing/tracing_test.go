@@ -0,0 +1,90 @@
+package tracing_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"fcstask-backend/internal/config"
+	"fcstask-backend/internal/fixtures"
+	"fcstask-backend/internal/server/handler"
+	"fcstask-backend/internal/tracing"
+)
+
+func TestSetup_Exporters(t *testing.T) {
+	for _, exporter := range []string{"", "none", "stdout"} {
+		shutdown, err := tracing.Setup(context.Background(), config.TracingConfig{Exporter: exporter, ServiceName: "test", SampleRatio: 1}, io.Discard)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", exporter, err)
+		}
+		if err := shutdown(context.Background()); err != nil {
+			t.Fatalf("%q: shutdown failed: %v", exporter, err)
+		}
+	}
+
+	if _, err := tracing.Setup(context.Background(), config.TracingConfig{Exporter: "jaeger"}, io.Discard); err == nil {
+		t.Fatal("expected error for unknown exporter")
+	}
+}
+
+func TestScoresRequestSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider(exporter, "test", 1)
+	if _, err := tracing.Setup(context.Background(), config.TracingConfig{}, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	otel.SetTracerProvider(provider)
+
+	if err := handler.ApplyFixtures(fixtures.Demo()); err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.Use(otelecho.Middleware("test"))
+	e.GET("/api/courses/:courseId/scores", handler.GetCourseScoresHandler)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/api/courses/algorithms/scores", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	req.Header.Set("Authorization", "Bearer alex-token")
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	provider.ForceFlush(context.Background())
+	spans := exporter.GetSpans()
+
+	var server, gradebook tracetest.SpanStub
+	for _, span := range spans {
+		if span.SpanContext.TraceID().String() != traceID {
+			t.Errorf("span %q is not in the incoming trace", span.Name)
+		}
+		switch span.Name {
+		case "GET /api/courses/:courseId/scores":
+			server = span
+		case "scores.gradebook":
+			gradebook = span
+		}
+	}
+
+	if !server.SpanContext.IsValid() || !gradebook.SpanContext.IsValid() {
+		t.Fatalf("server or gradebook span is missing: %+v", spans)
+	}
+	if gradebook.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("gradebook span must be a child of the server span")
+	}
+	// чтения из памяти отдельными спанами не оборачиваются
+	if len(spans) != 2 {
+		t.Errorf("expected only server and gradebook spans, got %d", len(spans))
+	}
+}